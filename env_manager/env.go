@@ -24,6 +24,7 @@ import "os"
 
 type EnvManager struct {
 	ConfigDir string
+	ConfigURL string
 	LogDir    string
 }
 
@@ -32,9 +33,10 @@ func NewEnvManager() *EnvManager {
 	if !ok {
 		panic("Environment variable 'URUFLOW_LOG_DIR' is not set")
 	}
+	configURL := os.Getenv("URUFLOW_CONFIG_URL")
 	configDir, ok := os.LookupEnv("URUFLOW_CONFIG_DIR")
-	if !ok {
+	if !ok && configURL == "" {
 		panic("Environment variable 'URUFLOW_CONFIG_DIR' is not set")
 	}
-	return &EnvManager{ConfigDir: configDir, LogDir: logDir}
+	return &EnvManager{ConfigDir: configDir, ConfigURL: configURL, LogDir: logDir}
 }
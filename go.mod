@@ -5,6 +5,7 @@ go 1.23.3
 require (
 	github.com/gorilla/mux v1.8.1
 	github.com/spf13/cobra v1.9.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
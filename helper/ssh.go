@@ -26,7 +26,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 
+	"uruflow.com/internal/models"
 	"uruflow.com/internal/utils"
 )
 
@@ -35,6 +37,10 @@ type SSHHelper struct {
 	logger  *utils.Logger
 	keyPath string
 	ready   bool
+
+	hostsMu    sync.RWMutex
+	hosts      []models.SSHHost
+	configPath string
 }
 
 // NewSSHHelper creates SSH helper instance
@@ -145,25 +151,38 @@ func (s *SSHHelper) testConnection() error {
 // GetGitEnvironment returns Git environment with SSH setup
 func (s *SSHHelper) GetGitEnvironment() []string {
 	env := os.Environ()
-	if s.ready && s.keyPath != "" {
-		absKeyPath, err := filepath.Abs(s.keyPath)
-		if err != nil {
-			s.logger.Warning("Failed to get absolute path for SSH key: %v", err)
-			absKeyPath = s.keyPath
+	configPath := s.hostsConfigPath()
+	if (s.ready && s.keyPath != "") || configPath != "" {
+		identity := ""
+		if configPath != "" {
+			// the generated config lists the per-host identities before the default key
+			identity = "-F " + configPath
+		} else {
+			identity = "-i " + s.absKeyPath()
 		}
 
-		sshCmd := fmt.Sprintf("ssh -i %s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -o BatchMode=yes -o ConnectTimeout=15", absKeyPath)
+		sshCmd := fmt.Sprintf("ssh %s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -o BatchMode=yes -o ConnectTimeout=15", identity)
 		env = append(env, "GIT_SSH_COMMAND="+sshCmd)
-		s.logger.Info("Git SSH environment configured with key: %s", absKeyPath)
+		s.logger.Info("Git SSH environment configured with %s", identity)
 	} else {
 		s.logger.Warning("SSH not ready, Git operations may fail")
 	}
 	return env
 }
 
-// IsReady returns true if SSH is configured
+// absKeyPath returns the absolute path of the default SSH key
+func (s *SSHHelper) absKeyPath() string {
+	absKeyPath, err := filepath.Abs(s.keyPath)
+	if err != nil {
+		s.logger.Warning("Failed to get absolute path for SSH key: %v", err)
+		return s.keyPath
+	}
+	return absKeyPath
+}
+
+// IsReady returns true if SSH is configured, with the default key or a per-host identity file
 func (s *SSHHelper) IsReady() bool {
-	return s.ready
+	return s.ready || s.hasHostIdentity()
 }
 
 // TestGitHubConnection tests the current SSH setup
@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package helper
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"uruflow.com/internal/models"
+)
+
+// ValidateSSHHosts checks the per-host SSH options of the ssh_hosts setting
+func ValidateSSHHosts(hosts []models.SSHHost) error {
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		if host.Host == "" || strings.ContainsAny(host.Host, " \t\r\n") {
+			return fmt.Errorf("ssh_hosts entries need a host without whitespace")
+		}
+		if seen[host.Host] {
+			return fmt.Errorf("duplicate ssh_hosts entry for %s", host.Host)
+		}
+		seen[host.Host] = true
+		if host.Port < 0 || host.Port > 65535 {
+			return fmt.Errorf("invalid port %d for ssh host %s: must be between 1 and 65535", host.Port, host.Host)
+		}
+		for _, value := range []string{host.HostName, host.User, host.IdentityFile} {
+			if strings.ContainsAny(value, "\r\n") {
+				return fmt.Errorf("invalid value for ssh host %s: must be a single line", host.Host)
+			}
+		}
+	}
+	return nil
+}
+
+// SetHosts applies per-host SSH options by generating an ssh config file used by Git and the
+// connection test; host entries work as aliases like Host blocks of ~/.ssh/config
+func (s *SSHHelper) SetHosts(hosts []models.SSHHost) error {
+	if err := ValidateSSHHosts(hosts); err != nil {
+		return err
+	}
+
+	s.hostsMu.Lock()
+	defer s.hostsMu.Unlock()
+	s.hosts = hosts
+	if len(hosts) == 0 {
+		s.configPath = ""
+		return nil
+	}
+
+	var config strings.Builder
+	for _, host := range hosts {
+		fmt.Fprintf(&config, "Host %s\n", host.Host)
+		if host.HostName != "" {
+			fmt.Fprintf(&config, "  HostName %s\n", host.HostName)
+		}
+		if host.Port != 0 {
+			fmt.Fprintf(&config, "  Port %d\n", host.Port)
+		}
+		if host.User != "" {
+			fmt.Fprintf(&config, "  User %s\n", host.User)
+		}
+		if host.IdentityFile != "" {
+			fmt.Fprintf(&config, "  IdentityFile %s\n", host.IdentityFile)
+			os.Chmod(host.IdentityFile, 0600)
+		}
+	}
+	if s.keyPath != "" {
+		fmt.Fprintf(&config, "Host *\n  IdentityFile %s\n", s.absKeyPath())
+	}
+
+	if s.configPath == "" {
+		file, err := os.CreateTemp("", "uruflow-ssh-config-*")
+		if err != nil {
+			return fmt.Errorf("failed to create ssh config: %v", err)
+		}
+		file.Close()
+		s.configPath = file.Name()
+	}
+	if err := os.WriteFile(s.configPath, []byte(config.String()), 0600); err != nil {
+		return fmt.Errorf("failed to write ssh config: %v", err)
+	}
+	s.logger.Info("SSH options configured for %d host(s)", len(hosts))
+	return nil
+}
+
+// hostsConfigPath returns the generated ssh config, empty when no ssh_hosts are configured
+func (s *SSHHelper) hostsConfigPath() string {
+	s.hostsMu.RLock()
+	defer s.hostsMu.RUnlock()
+	return s.configPath
+}
+
+// hasHostIdentity reports whether an ssh_hosts entry brings its own identity file
+func (s *SSHHelper) hasHostIdentity() bool {
+	s.hostsMu.RLock()
+	defer s.hostsMu.RUnlock()
+	for _, host := range s.hosts {
+		if host.IdentityFile != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// TestHostConnection tests the SSH connection to a Git host or ssh_hosts alias with the options Git uses
+func (s *SSHHelper) TestHostConnection(host string) error {
+	if !s.IsReady() {
+		return fmt.Errorf("SSH not configured")
+	}
+
+	args := []string{"-T",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+		"-o", "ConnectTimeout=15",
+		"-o", "BatchMode=yes",
+	}
+	target := "git@" + host
+	s.hostsMu.RLock()
+	if s.configPath != "" {
+		args = append(args, "-F", s.configPath)
+	}
+	for _, configured := range s.hosts {
+		if configured.Host == host && configured.User != "" {
+			// the configured user applies, user@ on the command line would override it
+			target = host
+		}
+	}
+	s.hostsMu.RUnlock()
+	if s.configPath == "" && s.keyPath != "" {
+		args = append(args, "-i", s.keyPath)
+	}
+
+	output, err := exec.Command("ssh", append(args, target)...).CombinedOutput()
+	outputStr := string(output)
+	// Git hosts refuse a shell but greet authenticated users, GitHub and GitLab with different messages
+	if err == nil || strings.Contains(outputStr, "successfully authenticated") || strings.Contains(outputStr, "Welcome to") {
+		return nil
+	}
+	if strings.Contains(outputStr, "Permission denied (publickey)") {
+		return fmt.Errorf("permission denied - SSH key not authorized on %s", host)
+	}
+	if strings.Contains(outputStr, "Host key verification failed") {
+		return fmt.Errorf("host key verification failed")
+	}
+	return fmt.Errorf("connection to %s failed: exit status %v, output: %s", host, err, strings.TrimSpace(outputStr))
+}
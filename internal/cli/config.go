@@ -21,12 +21,15 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"uruflow.com/internal/config"
+	"uruflow.com/internal/models"
 )
 
 var configCmd = &cobra.Command{
@@ -39,50 +42,96 @@ var configInfoCmd = &cobra.Command{
 	Use:   "info",
 	Short: "📋 Show configuration information",
 	Long:  `Display current configuration details and file location.`,
-	Run:   showConfigInfo,
+	RunE:  showConfigInfo,
 }
 
 var configReloadCmd = &cobra.Command{
 	Use:   "reload",
 	Short: "🔄 Reload configuration",
 	Long:  `Reload configuration from file without restarting the application.`,
-	Run:   reloadConfig,
+	RunE:  reloadConfig,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "✏️ Change a setting in config.json",
+	Long: `Change a single scalar setting in config.json without an editor. The value is validated
+together with the rest of the configuration and the file is replaced atomically; a
+running server reloads it within a few seconds. Lists and secrets are edited in the file.
+
+Supported keys:
+	` + strings.Join(config.SettableKeys(), "\n\t") + `
+
+Examples:
+	uruflow config set settings.max_concurrent 5
+	uruflow config set settings.cleanup_enabled false
+	uruflow config set webhook.port 9090`,
+	Args: cobra.ExactArgs(2),
+	RunE: setConfigValue,
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "🧾 Print the configuration JSON Schema",
+	Long: `Print a JSON Schema describing config.json, generated from the configuration model.
+Point your editor at it for autocompletion and validation:
+
+	uruflow config schema > /etc/uruflow/config.schema.json`,
+	// the schema does not depend on the loaded configuration, skip service initialization
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run:              showConfigSchema,
 }
 
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configInfoCmd)
 	configCmd.AddCommand(configReloadCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configSetCmd)
+	configInfoCmd.Flags().Bool("effective", false, "Print the fully resolved configuration as it is used (defaults applied, secrets masked)")
 }
 
-func showConfigInfo(cmd *cobra.Command, args []string) {
+func showConfigInfo(cmd *cobra.Command, args []string) error {
+	effective, _ := cmd.Flags().GetBool("effective")
+	if effective {
+		return showEffectiveConfig()
+	}
+
 	configPath := config.GetConfigPath(envManager)
 
-	fmt.Printf("📋 Configuration Information\n")
-	fmt.Printf("============================\n\n")
+	fmt.Fprintf(out, "📋 Configuration Information\n")
+	fmt.Fprintf(out, "============================\n\n")
 
-	fmt.Printf("📄 Config file: %s\n", configPath)
-	if fileExists(configPath) {
-		fmt.Printf("	✅ File exists\n")
+	if config.ReloadSupported(envManager) {
+		fmt.Fprintf(out, "📄 Config file: %s\n", configPath)
+		if fileExists(configPath) {
+			fmt.Fprintf(out, "	✅ File exists\n")
+		} else {
+			fmt.Fprintf(out, "	❌ File does not exist\n")
+		}
+		fmt.Fprintf(out, "📂 Repositories dir: %s\n", config.GetRepositoriesDir(envManager))
 	} else {
-		fmt.Printf("	❌ File does not exist\n")
+		fmt.Fprintf(out, "📄 Config source: %s (hot reload disabled)\n", config.ConfigSource(envManager))
 	}
-	fmt.Printf("📁 Config dir env: %s\n", envManager.ConfigDir)
-	fmt.Printf("📝 Log dir env: %s\n", envManager.LogDir)
-	fmt.Printf("\n")
+	fmt.Fprintf(out, "📁 Config dir env: %s\n", envManager.ConfigDir)
+	fmt.Fprintf(out, "📝 Log dir env: %s\n", envManager.LogDir)
+	fmt.Fprintf(out, "\n")
 
-	fmt.Printf("⚙️ Settings:\n")
-	fmt.Printf("   📂 Work directory: %s\n", cfg.Settings.WorkDir)
-	fmt.Printf("   🔢 Max concurrent: %d\n", cfg.Settings.MaxConcurrent)
-	fmt.Printf("   🔄 Auto clone: %t\n", cfg.Settings.AutoClone)
-	fmt.Printf("   🧹 Cleanup enabled: %t\n", cfg.Settings.CleanupEnabled)
-	fmt.Printf("\n")
+	fmt.Fprintf(out, "⚙️ Settings:\n")
+	fmt.Fprintf(out, "   📂 Work directory: %s\n", cfg.Settings.WorkDir)
+	fmt.Fprintf(out, "   🔢 Max concurrent: %d\n", cfg.Settings.MaxConcurrent)
+	fmt.Fprintf(out, "   🔄 Auto clone: %t\n", cfg.Settings.AutoClone)
+	fmt.Fprintf(out, "   🧹 Cleanup enabled: %t\n", cfg.Settings.CleanupEnabled)
+	fmt.Fprintf(out, "\n")
 
-	fmt.Printf("🌐 Webhook:\n")
-	fmt.Printf("   🔌 Port: %s\n", cfg.Webhook.Port)
-	fmt.Printf("   📍 Path: %s\n", cfg.Webhook.Path)
-	fmt.Printf("   🔐 Secret: %s\n", getSecretDisplay(cfg.Webhook.Secret))
-	fmt.Printf("\n")
+	fmt.Fprintf(out, "🌐 Webhook:\n")
+	fmt.Fprintf(out, "   🔌 Port: %s\n", cfg.Webhook.Port)
+	fmt.Fprintf(out, "   📍 Path: %s\n", cfg.Webhook.Path)
+	if len(cfg.Webhook.Paths) > 0 {
+		fmt.Fprintf(out, "   📍 Extra paths: %s\n", strings.Join(cfg.Webhook.Paths, ", "))
+	}
+	fmt.Fprintf(out, "   🔐 Secret: %s\n", getSecretDisplay(cfg.Webhook.Secret))
+	fmt.Fprintf(out, "\n")
 
 	enabledCount := 0
 	for _, repo := range cfg.Repositories {
@@ -91,29 +140,122 @@ func showConfigInfo(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	fmt.Printf("📦 Repositories:\n")
-	fmt.Printf("   📊 Total: %d\n", len(cfg.Repositories))
-	fmt.Printf("   ✅ Enabled: %d\n", enabledCount)
-	fmt.Printf("   ❌ Disabled: %d\n", len(cfg.Repositories)-enabledCount)
+	fmt.Fprintf(out, "📦 Repositories:\n")
+	fmt.Fprintf(out, "   📊 Total: %d\n", len(cfg.Repositories))
+	fmt.Fprintf(out, "   ✅ Enabled: %d\n", enabledCount)
+	fmt.Fprintf(out, "   ❌ Disabled: %d\n", len(cfg.Repositories)-enabledCount)
+	return nil
 }
 
-func reloadConfig(cmd *cobra.Command, args []string) {
+func reloadConfig(cmd *cobra.Command, args []string) error {
+	if !config.ReloadSupported(envManager) {
+		fmt.Fprintf(out, "❌ Configuration from %s cannot be reloaded\n", config.ConfigSource(envManager))
+		return fmt.Errorf("reload is not available for configuration from %s", config.ConfigSource(envManager))
+	}
 	configPath := filepath.Join(envManager.ConfigDir, "config.json")
 	logger.Config("Reloading configuration from: %s", configPath)
 
 	newConfig, err := config.Load(envManager)
 	if err != nil {
 		logger.Error("Failed to reload configuration: %v", err)
-		fmt.Printf("❌ Failed to reload configuration: %v\n", err)
-		return
+		fmt.Fprintf(out, "❌ Failed to reload configuration: %v\n", err)
+		return fmt.Errorf("failed to reload configuration: %v", err)
 	}
 
 	repositoryService.UpdateConfig(newConfig)
+	dockerService.UpdateConfig(newConfig)
+	deploymentService.UpdateConfig(newConfig)
 	cfg = newConfig
 
 	logger.Success("Configuration reloaded successfully")
-	fmt.Printf("✅ Configuration reloaded successfully\n")
-	fmt.Printf("📦 Managing %d repositories\n", len(cfg.Repositories))
+	fmt.Fprintf(out, "✅ Configuration reloaded successfully\n")
+	fmt.Fprintf(out, "📦 Managing %d repositories\n", len(cfg.Repositories))
+	return nil
+}
+
+// setConfigValue writes a single setting to config.json and applies the new configuration
+func setConfigValue(cmd *cobra.Command, args []string) error {
+	key, value := args[0], args[1]
+	newConfig, err := config.Set(envManager, key, value)
+	if err != nil {
+		fmt.Fprintf(out, "❌ Failed to set %s: %v\n", key, err)
+		return err
+	}
+
+	repositoryService.UpdateConfig(newConfig)
+	dockerService.UpdateConfig(newConfig)
+	deploymentService.UpdateConfig(newConfig)
+	cfg = newConfig
+
+	logger.Config("Set %s to %s in %s", key, value, config.GetConfigPath(envManager))
+	fmt.Fprintf(out, "✅ Set %s = %s\n", key, value)
+	if config.RequiresRestart(key) {
+		fmt.Fprintf(out, "🔄 Restart the server to apply %s\n", key)
+	} else {
+		fmt.Fprintf(out, "🔄 A running server reloads the configuration within a few seconds\n")
+	}
+	return nil
+}
+
+// showEffectiveConfig prints the configuration after defaults are applied, with secrets masked
+func showEffectiveConfig() error {
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to encode configuration: %v\n", err)
+		return fmt.Errorf("failed to encode configuration: %v", err)
+	}
+
+	// work on a copy so masking never leaks into the running configuration
+	var effective models.Config
+	if err := json.Unmarshal(raw, &effective); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to copy configuration: %v\n", err)
+		return fmt.Errorf("failed to copy configuration: %v", err)
+	}
+	maskConfigSecrets(&effective)
+
+	output, err := json.MarshalIndent(effective, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to encode configuration: %v\n", err)
+		return fmt.Errorf("failed to encode configuration: %v", err)
+	}
+
+	fmt.Fprintf(out, "📋 Effective configuration (%s)\n", config.ConfigSource(envManager))
+	fmt.Fprintf(out, "   URUFLOW_CONFIG_DIR=%s\n", envManager.ConfigDir)
+	fmt.Fprintf(out, "   URUFLOW_LOG_DIR=%s\n\n", envManager.LogDir)
+	// JSON goes to stdout unfiltered, plain output would drop its non-ASCII characters
+	fmt.Fprintln(os.Stdout, string(output))
+	return nil
+}
+
+// maskConfigSecrets replaces every secret value in the configuration with its masked display form
+func maskConfigSecrets(c *models.Config) {
+	if c.Webhook.Secret != "" {
+		c.Webhook.Secret = getSecretDisplay(c.Webhook.Secret)
+	}
+	if c.Webhook.APIToken != "" {
+		c.Webhook.APIToken = getSecretDisplay(c.Webhook.APIToken)
+	}
+	for i := range c.Settings.Registries {
+		if c.Settings.Registries[i].Password != "" {
+			c.Settings.Registries[i].Password = getSecretDisplay(c.Settings.Registries[i].Password)
+		}
+	}
+	for i := range c.Settings.NotificationChannels {
+		// headers carry tokens such as Authorization, so every value is masked
+		for name, value := range c.Settings.NotificationChannels[i].Headers {
+			c.Settings.NotificationChannels[i].Headers[name] = getSecretDisplay(value)
+		}
+	}
+}
+
+func showConfigSchema(cmd *cobra.Command, args []string) {
+	output, err := json.MarshalIndent(config.Schema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to generate schema: %v\n", err)
+		return
+	}
+	// JSON goes to stdout unfiltered, plain output would drop its non-ASCII characters
+	fmt.Fprintln(os.Stdout, string(output))
 }
 
 func getSecretDisplay(secret string) string {
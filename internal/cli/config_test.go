@@ -0,0 +1,61 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package cli
+
+import (
+	"testing"
+
+	"uruflow.com/internal/models"
+)
+
+func TestMaskConfigSecrets(t *testing.T) {
+	c := &models.Config{
+		Webhook: models.WebhookConfig{Secret: "webhook-secret", APIToken: "api-token-value"},
+		Settings: models.Settings{
+			Registries: []models.RegistryCredential{
+				{URL: "ghcr.io", Username: "deploy", Password: "registry-password"},
+				{URL: "registry.example.com", Username: "ci", PasswordEnv: "REGISTRY_PASSWORD"},
+			},
+			NotificationChannels: []models.NotificationChannel{
+				{URL: "https://hooks.example.com/deploy", Headers: map[string]string{"Authorization": "Bearer channel-token", "X-Team": "ops"}},
+			},
+		},
+	}
+	maskConfigSecrets(c)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"webhook secret", c.Webhook.Secret, "we***et"},
+		{"api token", c.Webhook.APIToken, "ap***ue"},
+		{"registry password", c.Settings.Registries[0].Password, "re***rd"},
+		{"registry without password", c.Settings.Registries[1].Password, ""},
+		{"registry password env", c.Settings.Registries[1].PasswordEnv, "REGISTRY_PASSWORD"},
+		{"authorization header", c.Settings.NotificationChannels[0].Headers["Authorization"], "Be***en"},
+		{"short header", c.Settings.NotificationChannels[0].Headers["X-Team"], "***"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
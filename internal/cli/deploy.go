@@ -21,117 +21,608 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
+	"uruflow.com/internal/models"
+	"uruflow.com/internal/services"
 )
 
 var deployCmd = &cobra.Command{
 	Use:   "deploy [repository] [branch]",
 	Short: "🚀 Deploy a repository manually",
-	Long:  `Manually trigger deployment of a specific repository and branch.`,
-	Args:  cobra.ExactArgs(2),
-	Run:   runDeploy,
+	Long: `Manually trigger deployment of a specific repository and branch.
+Use --all to redeploy every enabled repository and branch.
+Use --detach to hand the deploy to the running server and return right away with its
+job ID; follow it with 'uruflow deploy status <job-id>' (requires webhook.api_token).`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if all, _ := cmd.Flags().GetBool("all"); all {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: runDeploy,
 }
 
 var deployStatusCmd = &cobra.Command{
-	Use:   "status",
+	Use:   "status [job-id]",
 	Short: "📊 Show deployment status",
-	Long:  `Show current deployment queue and jobs.`,
-	Run:   showDeployStatus,
+	Long: `Show current deployment queue and jobs.
+With a job ID, show the progress of that job on the running server (requires webhook.api_token).`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: showDeployStatus,
+}
+
+var deployCancelCmd = &cobra.Command{
+	Use:   "cancel [repository] [branch]",
+	Short: "🛑 Cancel a running deployment",
+	Long: `Cancel the in-flight deployment of a repository branch on the running server.
+The running git/compose process is killed and partially started services are removed.
+Requires webhook.api_token to be configured.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDeployCancel,
 }
 
 func init() {
 	rootCmd.AddCommand(deployCmd)
 	deployCmd.AddCommand(deployStatusCmd)
-	deployCmd.Flags().BoolP("force", "f", false, "Force deployment even if containers are running")
+	deployCmd.AddCommand(deployCancelCmd)
+	deployCmd.Flags().BoolP("force", "f", false, "Recreate the project without asking even if its containers are running")
+	deployCmd.Flags().Bool("all", false, "Redeploy every enabled repository and branch")
+	deployCmd.Flags().StringSlice("services", nil, "Only rebuild and recreate these compose services (comma separated)")
+	deployCmd.Flags().Bool("plan", false, "Show what compose would change for the current checkout without deploying")
+	deployCmd.Flags().Bool("reset", false, "Reset the circuit breaker of the branch on the running server before deploying")
+	deployCmd.Flags().BoolP("detach", "d", false, "Run the deploy on the running server in the background and print its job ID")
 }
 
-func runDeploy(cmd *cobra.Command, args []string) {
+func runDeploy(cmd *cobra.Command, args []string) error {
+	if all, _ := cmd.Flags().GetBool("all"); all {
+		if cmd.Flags().Changed("services") {
+			return fmt.Errorf("--services cannot be combined with --all")
+		}
+		if plan, _ := cmd.Flags().GetBool("plan"); plan {
+			return fmt.Errorf("--plan cannot be combined with --all")
+		}
+		if detach, _ := cmd.Flags().GetBool("detach"); detach {
+			return fmt.Errorf("--detach cannot be combined with --all")
+		}
+		return runDeployAll(cmd)
+	}
+	detach, _ := cmd.Flags().GetBool("detach")
+	if plan, _ := cmd.Flags().GetBool("plan"); plan && detach {
+		return fmt.Errorf("--detach cannot be combined with --plan")
+	}
+
 	repoName := args[0]
 	branch := args[1]
 
-	fmt.Printf("🚀 Starting Manual deployment: %s:%s\n", repoName, branch)
+	fmt.Fprintf(out, "🚀 Starting Manual deployment: %s:%s\n", repoName, branch)
 	logger.Info("Manual deployment requested: %s:%s", repoName, branch)
 
 	repo := repositoryService.GetRepository(repoName)
 	if repo == nil {
 		logger.Error("Repository '%s' not found or disabled", repoName)
-		fmt.Printf("❌ Repository '%s' not found or disabled\n", repoName)
-		fmt.Printf("\n📦 Available repositories:\n")
+		fmt.Fprintf(out, "❌ Repository '%s' not found or disabled\n", repoName)
+		fmt.Fprintf(out, "\n📦 Available repositories:\n")
 		for _, r := range repositoryService.ListRepositories() {
-			fmt.Printf("  - %s (branches: %v)\n", r.Name, r.Branches)
+			fmt.Fprintf(out, "  - %s (branches: %v)\n", r.Name, r.Branches)
+		}
+		return fmt.Errorf("repository '%s' not found or disabled", repoName)
+	}
+
+	if repo.DeployDefaultBranch {
+		if _, err := repositoryService.ResolveDefaultBranch(*repo); err != nil {
+			logger.Warning("Could not resolve default branch of %s: %v", repoName, err)
 		}
-		return
 	}
 
 	if !repositoryService.IsBranchConfigured(repo, branch) {
 		logger.Error("Branch '%s' not configured for repository '%s'", branch, repoName)
-		fmt.Printf("❌ Branch '%s' not configured for repository '%s'\n", branch, repoName)
-		fmt.Printf("🌿 Available branches for %s: %v\n", repoName, repo.Branches)
-		return
+		fmt.Fprintf(out, "❌ Branch '%s' not configured for repository '%s'\n", branch, repoName)
+		fmt.Fprintf(out, "🌿 Available branches for %s: %v\n", repoName, repo.Branches)
+		return fmt.Errorf("branch '%s' not configured for repository '%s'", branch, repoName)
+	}
+	only, _ := cmd.Flags().GetStringSlice("services")
+	if plan, _ := cmd.Flags().GetBool("plan"); plan {
+		return runDeployPlan(*repo, branch, only)
+	}
+	if reset, _ := cmd.Flags().GetBool("reset"); reset {
+		resetServerCircuit(repoName, branch)
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	if !force && !confirmRunningDeploy(*repo, branch) {
+		logger.Info("Manual deployment of %s:%s aborted, containers are running", repoName, branch)
+		return fmt.Errorf("deployment of %s:%s aborted, containers are running (use --force)", repoName, branch)
+	}
+	if detach {
+		return runDeployDetached(repoName, branch, only)
 	}
+
 	startTime := time.Now()
-	fmt.Printf("⚡ Executing deployment...\n")
+	fmt.Fprintf(out, "⚡ Executing deployment...\n")
 
-	if err := deploymentService.DeployDirect(*repo, branch); err != nil {
+	stageStart := startTime
+	lastStage := ""
+	printStage := func(stage string) {
+		now := time.Now()
+		if lastStage != "" {
+			fmt.Fprintf(out, "   ✔ %s done (%v)\n", lastStage, now.Sub(stageStart).Round(time.Millisecond))
+		}
+		fmt.Fprintf(out, "[%s +%v] ▶ %s\n", now.Format("15:04:05"), now.Sub(startTime).Round(time.Second), stage)
+		stageStart = now
+		lastStage = stage
+	}
+
+	if len(only) > 0 {
+		fmt.Fprintf(out, "🎯 Only deploying services: %s\n", strings.Join(only, ", "))
+	}
+	opts := services.DeployOptions{Progress: printStage, Services: only, Trigger: manualTrigger()}
+	err := deploymentService.DeployDirectWithOptions(context.Background(), *repo, branch, opts)
+	if errors.Is(err, services.ErrDeploySkipped) {
+		logger.Info("Deployment skipped: %v", err)
+		fmt.Fprintf(out, "⏭️ Deployment skipped, %s:%s has no new commits and is running (skip_if_unchanged)\n", repoName, branch)
+		return nil
+	}
+	if err != nil {
 		duration := time.Since(startTime)
 		logger.Error("Deployment failed: %v", err)
-		fmt.Printf("❌ Deployment failed after %v: %v\n", duration.Round(time.Second), err)
-		return
+		fmt.Fprintf(out, "❌ Deployment failed after %v: %v\n", duration.Round(time.Second), err)
+		return err
 	}
 
 	duration := time.Since(startTime)
 	logger.Success("Deployment completed successfully for %s:%s (took %v)", repoName, branch, duration.Round(time.Second))
-	fmt.Printf("✅ Deployment completed successfully for %s:%s (took %v)\n", repoName, branch, duration.Round(time.Second))
+	fmt.Fprintf(out, "✅ Deployment completed successfully for %s:%s (took %v)\n", repoName, branch, duration.Round(time.Second))
 
-	fmt.Printf("\n🔍 Checking deployed containers...\n")
+	fmt.Fprintf(out, "\n🔍 Checking deployed containers...\n")
 	showDeployedContainers()
+	return nil
+}
+
+// runDeployPlan prints the actions compose would perform for the current checkout of a branch, applying nothing
+func runDeployPlan(repo models.Repository, branch string, only []string) error {
+	if !repositoryService.IsRepositoryInitialized(repo.Name, branch) {
+		fmt.Fprintf(out, "❌ %s:%s is not deployed, run 'uruflow deploy %s %s' first\n", repo.Name, branch, repo.Name, branch)
+		return fmt.Errorf("%s:%s is not initialized", repo.Name, branch)
+	}
+	repoPath, err := services.RepositoryPath(cfg.Settings.WorkDir, repo.Name, branch)
+	if err != nil {
+		fmt.Fprintf(out, "❌ %v\n", err)
+		return err
+	}
+
+	fmt.Fprintf(out, "📝 Planning deployment of %s:%s (current checkout, nothing is applied)\n", repo.Name, branch)
+	if len(only) > 0 {
+		fmt.Fprintf(out, "🎯 Only planning services: %s\n", strings.Join(only, ", "))
+	}
+	for _, daemon := range dockerService.Daemons(repo) {
+		plan, err := daemon.Docker.PlanWithContext(context.Background(), repo, branch, repoPath, only)
+		if err != nil {
+			logger.Error("Deployment plan failed%s: %v", onHost(daemon.Host), err)
+			fmt.Fprintf(out, "❌ Deployment plan failed%s: %v\n", onHost(daemon.Host), err)
+			return err
+		}
+
+		fmt.Fprintf(out, "\n")
+		if daemon.Host != "" {
+			fmt.Fprintf(out, "🖥️ %s:\n", daemon.Host)
+		}
+		for _, action := range plan {
+			fmt.Fprintf(out, "   %s\n", action)
+		}
+	}
+	fmt.Fprintf(out, "\n💡 Run 'uruflow deploy %s %s' to apply\n", repo.Name, branch)
+	return nil
+}
+
+// deployResult is the outcome of one branch of a fleet-wide deploy
+type deployResult struct {
+	target   string
+	duration time.Duration
+	err      error
+}
+
+// runDeployAll redeploys every enabled repository and branch, continuing past failures
+func runDeployAll(cmd *cobra.Command) error {
+	type target struct {
+		repo   models.Repository
+		branch string
+	}
+
+	var targets []target
+	for _, repo := range repositoryService.ListRepositories() {
+		if repo.DeployDefaultBranch {
+			if _, err := repositoryService.ResolveDefaultBranch(repo); err != nil {
+				logger.Warning("Could not resolve default branch of %s: %v", repo.Name, err)
+			}
+		}
+		for _, branch := range repositoryService.GetDeployBranches(repo) {
+			targets = append(targets, target{repo: repo, branch: branch})
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(out, "💤 No enabled repositories to deploy\n")
+		return nil
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	if !force && !confirm(fmt.Sprintf("⚠️ This redeploys %d branch(es) and recreates their containers.", len(targets))) {
+		return fmt.Errorf("deployment of all repositories aborted (use --force)")
+	}
+
+	fmt.Fprintf(out, "🚀 Redeploying %d branch(es) (max concurrent: %d)\n\n", len(targets), cfg.Settings.MaxConcurrent)
+	logger.Info("Manual deployment of all repositories requested: %d branch(es)", len(targets))
+
+	results := make([]deployResult, len(targets))
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		go func(i int, t target) {
+			defer wg.Done()
+			jobKey := fmt.Sprintf("%s:%s", t.repo.Name, t.branch)
+			// the deployment waits for a free max_concurrent slot
+			start := time.Now()
+			err := deploymentService.DeployDirectWithOptions(context.Background(), t.repo, t.branch, services.DeployOptions{Trigger: manualTrigger()})
+			results[i] = deployResult{target: jobKey, duration: time.Since(start), err: err}
+			if errors.Is(err, services.ErrDeploySkipped) {
+				fmt.Fprintf(out, "⏭️ %s skipped, no new commits\n", jobKey)
+			} else if err != nil {
+				fmt.Fprintf(out, "❌ %s failed after %v: %v\n", jobKey, results[i].duration.Round(time.Second), err)
+			} else {
+				fmt.Fprintf(out, "✅ %s deployed (took %v)\n", jobKey, results[i].duration.Round(time.Second))
+			}
+		}(i, t)
+	}
+	wg.Wait()
+
+	return printDeploySummary(results)
+}
+
+// manualTrigger describes a deployment started from the CLI by the current OS user
+func manualTrigger() models.DeployTrigger {
+	actor := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		actor = current.Username
+	}
+	return models.DeployTrigger{Source: "manual", Actor: actor}
+}
+
+// printDeploySummary prints a table of deploy results and returns an error when any deploy failed
+func printDeploySummary(results []deployResult) error {
+	fmt.Fprintf(out, "\n📊 Deployment Summary\n")
+	fmt.Fprintf(out, "====================\n\n")
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TARGET\tRESULT\tDURATION\tERROR\n")
+	failed, skipped := 0, 0
+	for _, result := range results {
+		status, message := "✅ success", ""
+		if errors.Is(result.err, services.ErrDeploySkipped) {
+			status = "⏭️ skipped"
+			skipped++
+		} else if result.err != nil {
+			status, message = "❌ failed", result.err.Error()
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%v\t%s\n", result.target, status, result.duration.Round(time.Second), message)
+	}
+	w.Flush()
+
+	succeeded := len(results) - failed - skipped
+	fmt.Fprintf(out, "\n✅ %d succeeded, ⏭️ %d skipped, ❌ %d failed\n", succeeded, skipped, failed)
+	logger.Info("Deployment of all repositories finished: %d succeeded, %d skipped, %d failed", succeeded, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d deployments failed", failed, len(results))
+	}
+	return nil
+}
+
+// runDeployCancel asks the running server to cancel a deployment
+func runDeployCancel(cmd *cobra.Command, args []string) error {
+	repoName := args[0]
+	branch := args[1]
+
+	if cfg.Webhook.APIToken == "" {
+		fmt.Fprintf(out, "❌ webhook.api_token is not configured, the cancel endpoint is disabled\n")
+		return fmt.Errorf("webhook.api_token is not configured")
+	}
+
+	endpoint := localServerURL(fmt.Sprintf("/deployments/%s/%s/cancel", url.PathEscape(repoName), url.PathEscape(branch)))
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		fmt.Fprintf(out, "❌ Failed to create request: %v\n", err)
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.Webhook.APIToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(out, "❌ Request failed: %v\n", err)
+		fmt.Fprintf(out, "💡 Is the server running? Start it with 'uruflow server'\n")
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		logger.Info("Cancellation requested for %s:%s", repoName, branch)
+		fmt.Fprintf(out, "🛑 Cancellation requested for %s:%s\n", repoName, branch)
+	case http.StatusNotFound:
+		fmt.Fprintf(out, "💤 No active deployment for %s:%s\n", repoName, branch)
+		return fmt.Errorf("no active deployment for %s:%s", repoName, branch)
+	default:
+		fmt.Fprintf(out, "❌ Cancel failed (%s): %v\n", resp.Status, result["message"])
+		return fmt.Errorf("cancel failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// runDeployDetached asks the running server to deploy a branch in the background and prints the job ID
+func runDeployDetached(repoName, branch string, only []string) error {
+	if cfg.Webhook.APIToken == "" {
+		fmt.Fprintf(out, "❌ webhook.api_token is not configured, detached deploys need the server API\n")
+		return fmt.Errorf("webhook.api_token is not configured")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"services": only, "actor": manualTrigger().Actor})
+	if err != nil {
+		return err
+	}
+	endpoint := localServerURL(fmt.Sprintf("/deployments/%s/%s/deploy", url.PathEscape(repoName), url.PathEscape(branch)))
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(out, "❌ Failed to create request: %v\n", err)
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.Webhook.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(out, "❌ No UruFlow server reachable at %s: %v\n", localServerURL(""), err)
+		fmt.Fprintf(out, "💡 Start it with 'uruflow server', or deploy in the foreground without --detach\n")
+		return fmt.Errorf("no server running to run a detached deploy: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusAccepted {
+		fmt.Fprintf(out, "❌ Deploy not queued (%s): %v\n", resp.Status, result["message"])
+		return fmt.Errorf("deploy not queued: %s", resp.Status)
+	}
+
+	logger.Info("Detached deployment of %s:%s queued as job %v", repoName, branch, result["job_id"])
+	fmt.Fprintf(out, "🚀 Deployment of %s:%s queued on the server\n", repoName, branch)
+	fmt.Fprintf(out, "🆔 Job ID: %v\n", result["job_id"])
+	fmt.Fprintf(out, "💡 Check progress with 'uruflow deploy status %v'\n", result["job_id"])
+	return nil
+}
+
+// showJobStatus prints a deployment job of the running server
+func showJobStatus(id string) error {
+	if cfg.Webhook.APIToken == "" {
+		fmt.Fprintf(out, "❌ webhook.api_token is not configured, job lookups need the server API\n")
+		return fmt.Errorf("webhook.api_token is not configured")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, localServerURL("/deployments/jobs/"+url.PathEscape(id)), nil)
+	if err != nil {
+		fmt.Fprintf(out, "❌ Failed to create request: %v\n", err)
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.Webhook.APIToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(out, "❌ Request failed: %v\n", err)
+		fmt.Fprintf(out, "💡 Is the server running? Start it with 'uruflow server'\n")
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		fmt.Fprintf(out, "❓ Job %s not found, the server only keeps recent jobs since it started\n", id)
+		return fmt.Errorf("job %s not found", id)
+	default:
+		fmt.Fprintf(out, "❌ Job lookup failed: %s\n", resp.Status)
+		return fmt.Errorf("job lookup failed: %s", resp.Status)
+	}
+
+	var job services.Job
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		fmt.Fprintf(out, "❌ Invalid job response: %v\n", err)
+		return err
+	}
+
+	fmt.Fprintf(out, "📊 Job %s\n", job.ID)
+	fmt.Fprintf(out, "==================\n\n")
+	fmt.Fprintf(out, "📦 Target: %s:%s\n", job.Repository, job.Branch)
+	fmt.Fprintf(out, "🔄 Status: %s\n", job.Status)
+	if job.Commit != "" {
+		fmt.Fprintf(out, "🔖 Commit: %s\n", job.Commit)
+	}
+	fmt.Fprintf(out, "🕐 Queued: %s\n", job.CreatedAt.Format(time.RFC3339))
+	if job.StartedAt != nil {
+		fmt.Fprintf(out, "▶️ Started: %s\n", job.StartedAt.Format(time.RFC3339))
+	}
+	if job.FinishedAt != nil {
+		fmt.Fprintf(out, "🏁 Finished: %s\n", job.FinishedAt.Format(time.RFC3339))
+		if job.StartedAt != nil {
+			fmt.Fprintf(out, "⏱️ Duration: %v\n", job.FinishedAt.Sub(*job.StartedAt).Round(time.Second))
+		}
+	}
+	if job.Error != "" {
+		fmt.Fprintf(out, "❌ Error: %s\n", job.Error)
+	}
+	if len(job.Hosts) > 0 {
+		fmt.Fprintf(out, "\n🖥️ Hosts:\n")
+		for _, host := range job.Hosts {
+			switch host.Status {
+			case "succeeded":
+				fmt.Fprintf(out, "  ✅ %s: %d services\n", host.Host, len(host.Services))
+			case "failed":
+				fmt.Fprintf(out, "  ❌ %s: %s\n", host.Host, host.Error)
+			default:
+				fmt.Fprintf(out, "  ⏭️ %s: %s\n", host.Host, host.Status)
+			}
+		}
+	}
+	if job.Status == services.JobFailed {
+		return fmt.Errorf("job %s failed", job.ID)
+	}
+	return nil
+}
+
+// resetServerCircuit asks the running server to reset the circuit breaker of a branch
+func resetServerCircuit(repoName, branch string) {
+	if cfg.Webhook.APIToken == "" {
+		fmt.Fprintf(out, "⚠️ webhook.api_token is not configured, cannot reset the server circuit breaker\n")
+		return
+	}
+
+	endpoint := localServerURL(fmt.Sprintf("/deployments/%s/%s/reset", url.PathEscape(repoName), url.PathEscape(branch)))
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		fmt.Fprintf(out, "⚠️ Failed to create reset request: %v\n", err)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.Webhook.APIToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(out, "⚠️ Could not reach the server to reset the circuit breaker: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintf(out, "⚠️ Circuit reset failed: %s\n", resp.Status)
+		return
+	}
+	logger.Info("Circuit breaker reset for %s:%s", repoName, branch)
+	fmt.Fprintf(out, "🔌 Circuit breaker reset for %s:%s\n", repoName, branch)
+}
+
+// confirmRunningDeploy warns when the project is already running and asks before recreating it.
+// Without an interactive terminal the deploy is refused, pass --force to recreate anyway.
+func confirmRunningDeploy(repo models.Repository, branch string) bool {
+	var running []string
+	for _, daemon := range dockerService.Daemons(repo) {
+		containers, err := daemon.Docker.GetRunningProjectContainers(repo, branch)
+		if err != nil {
+			logger.Warning("Could not check running containers%s: %v", onHost(daemon.Host), err)
+			continue
+		}
+		for _, container := range containers {
+			running = append(running, container+onHost(daemon.Host))
+		}
+	}
+	if len(running) == 0 {
+		return true
+	}
+
+	fmt.Fprintf(out, "⚠️ %d container(s) already running for %s:%s:\n", len(running), repo.Name, branch)
+	for _, container := range running {
+		fmt.Fprintf(out, "  - %s\n", container)
+	}
+
+	return confirm("   Deploying will stop and recreate them.")
+}
+
+// confirm prints a warning and asks the user to continue. Without an interactive terminal it refuses.
+func confirm(warning string) bool {
+	fmt.Fprintf(out, "%s\n", warning)
+
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		fmt.Fprintf(out, "❌ Refusing to interrupt running services without confirmation, use --force to recreate them\n")
+		return false
+	}
+
+	fmt.Fprintf(out, "❓ Continue? [y/N]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		fmt.Fprintf(out, "🛑 Deployment aborted\n")
+		return false
+	}
+	return true
 }
 
 // showDeployedContainers displays information about deployed containers
 func showDeployedContainers() {
 	status, err := dockerService.GetStatusOutput()
 	if err != nil {
-		fmt.Printf("❌ Could not get container status: %v\n", err)
+		fmt.Fprintf(out, "❌ Could not get container status: %v\n", err)
 		return
 	}
 
 	if status == "" {
-		fmt.Printf("🔴 No containers found\n")
+		fmt.Fprintf(out, "🔴 No containers found\n")
 		return
 	}
 
-	fmt.Printf("%s\n", status)
+	fmt.Fprintf(out, "%s\n", status)
 }
 
-func showDeployStatus(cmd *cobra.Command, args []string) {
+func showDeployStatus(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		return showJobStatus(args[0])
+	}
 	stats := deploymentService.GetDeploymentStats()
 	activeJobs := deploymentService.GetActiveJobs()
 
-	fmt.Printf("📊 Deployment Status\n")
-	fmt.Printf("==================\n\n")
+	fmt.Fprintf(out, "📊 Deployment Status\n")
+	fmt.Fprintf(out, "==================\n\n")
 
-	fmt.Printf("⚙️ Max Workers: %d\n", stats["max_workers"])
-	fmt.Printf("⚡ Active Jobs: %d\n", stats["active_jobs"])
+	fmt.Fprintf(out, "⚙️ Max Workers: %d\n", stats["max_workers"])
+	fmt.Fprintf(out, "⚡ Active Jobs: %d\n", stats["active_jobs"])
 
 	if len(activeJobs) > 0 {
-		fmt.Printf("\n🔄 Currently Running:\n")
+		fmt.Fprintf(out, "\n🔄 Currently Running:\n")
 		for _, job := range activeJobs {
-			fmt.Printf("  - %s\n", job)
+			fmt.Fprintf(out, "  - %s\n", job)
 		}
 	} else {
-		fmt.Printf("\n💤 No active deployments\n")
+		fmt.Fprintf(out, "\n💤 No active deployments\n")
 	}
 
-	fmt.Printf("\n📦 Current Docker Containers:\n")
-	status, err := dockerService.GetStatusOutput()
+	fmt.Fprintf(out, "\n📦 Current Docker Containers:\n")
+	containers, err := dockerService.GetStatusJSON()
 	if err != nil {
-		fmt.Printf("❌ Could not get container status: %v\n", err)
-	} else if status == "" {
-		fmt.Printf("🔴 No containers running\n")
+		fmt.Fprintf(out, "❌ Could not get container status: %v\n", err)
+	} else if len(containers) == 0 {
+		fmt.Fprintf(out, "🔴 No containers running\n")
 	} else {
-		fmt.Printf("%s\n", status)
+		for _, container := range containers {
+			fmt.Fprintf(out, "  - %s (%s) %s\n", container.Name, container.Image, container.Status)
+			if container.Ports != "" {
+				fmt.Fprintf(out, "    🔌 %s\n", container.Ports)
+			}
+		}
 	}
+	return nil
 }
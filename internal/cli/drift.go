@@ -0,0 +1,157 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"uruflow.com/internal/services"
+)
+
+var driftCmd = &cobra.Command{
+	Use:   "drift",
+	Short: "🧭 Show configured vs running project drift",
+	Long: `Compare the compose projects of every enabled repository branch against the
+running compose projects under the work directory. Missing projects are configured
+but not running, orphaned projects are running but no longer configured.`,
+	RunE: showDrift,
+}
+
+// driftProject is a compose project reported by the drift check
+type driftProject struct {
+	Project    string `json:"project"`
+	Repository string `json:"repository,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+	WorkingDir string `json:"working_dir,omitempty"`
+	// Host is the repository host the project is missing on or running on, empty for the local daemon
+	Host string `json:"host,omitempty"`
+}
+
+// driftReport lists configured projects that are not running and running projects that are not configured
+type driftReport struct {
+	Missing  []driftProject `json:"missing"`
+	Orphaned []driftProject `json:"orphaned"`
+}
+
+func init() {
+	rootCmd.AddCommand(driftCmd)
+}
+
+// computeDrift compares the configured compose projects with the running managed projects of the local
+// daemon and of every repository host
+func computeDrift() (*driftReport, error) {
+	local, err := dockerService.GetManagedProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	// a daemon shared by several repositories is the same service and is only listed once
+	running := map[*services.DockerService]map[string]string{dockerService: local}
+	daemons := []services.HostDaemon{{Docker: dockerService}}
+	report := &driftReport{Missing: []driftProject{}, Orphaned: []driftProject{}}
+	configured := make(map[string]bool)
+	for _, repo := range currentConfig().Repositories {
+		if !repo.Enabled {
+			continue
+		}
+		repoDaemons := dockerService.Daemons(repo)
+		for _, daemon := range repoDaemons {
+			if _, ok := running[daemon.Docker]; ok {
+				continue
+			}
+			projects, err := daemon.Docker.GetManagedProjects()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", daemon.Host, err)
+			}
+			running[daemon.Docker] = projects
+			daemons = append(daemons, daemon)
+		}
+
+		for _, branch := range repositoryService.GetDeployBranches(repo) {
+			for _, projectName := range services.ProjectNames(repo, branch) {
+				configured[projectName] = true
+				for _, daemon := range repoDaemons {
+					if _, ok := running[daemon.Docker][projectName]; !ok {
+						report.Missing = append(report.Missing, driftProject{Project: projectName, Repository: repo.Name, Branch: branch, Host: daemon.Host})
+					}
+				}
+			}
+		}
+	}
+
+	for _, daemon := range daemons {
+		for projectName, workingDir := range running[daemon.Docker] {
+			if !configured[projectName] {
+				report.Orphaned = append(report.Orphaned, driftProject{Project: projectName, WorkingDir: workingDir, Host: daemon.Host})
+			}
+		}
+	}
+	sort.Slice(report.Orphaned, func(i, j int) bool {
+		if report.Orphaned[i].Project != report.Orphaned[j].Project {
+			return report.Orphaned[i].Project < report.Orphaned[j].Project
+		}
+		return report.Orphaned[i].Host < report.Orphaned[j].Host
+	})
+
+	return report, nil
+}
+
+// showDrift prints the drift between configured and running projects
+func showDrift(cmd *cobra.Command, args []string) error {
+	report, err := computeDrift()
+	if err != nil {
+		fmt.Fprintf(out, "❌ Failed to check drift: %v\n", err)
+		return err
+	}
+
+	fmt.Fprintf(out, "🧭 Project Drift\n")
+	fmt.Fprintf(out, "================\n\n")
+
+	if len(report.Missing) == 0 {
+		fmt.Fprintf(out, "✅ All configured projects are running\n\n")
+	} else {
+		fmt.Fprintf(out, "🔴 Missing (configured but not running):\n")
+		for _, p := range report.Missing {
+			fmt.Fprintf(out, "   - %s (%s:%s)%s\n", p.Project, p.Repository, p.Branch, onHost(p.Host))
+		}
+		fmt.Fprintf(out, "\n")
+	}
+
+	if len(report.Orphaned) == 0 {
+		fmt.Fprintf(out, "✅ No orphaned projects\n")
+	} else {
+		fmt.Fprintf(out, "👻 Orphaned (running but not configured):\n")
+		for _, p := range report.Orphaned {
+			fmt.Fprintf(out, "   - %s (%s)%s\n", p.Project, p.WorkingDir, onHost(p.Host))
+		}
+	}
+	return nil
+}
+
+// onHost returns " on <host>" for a repository host and nothing for the local daemon
+func onHost(host string) string {
+	if host == "" {
+		return ""
+	}
+	return " on " + host
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"uruflow.com/internal/services"
+)
+
+var reloadEnvCmd = &cobra.Command{
+	Use:   "reload-env [repository] [branch]",
+	Short: "♻️ Apply changed environment without rebuilding",
+	Long: `Recreate the containers of a deployed branch so changed .env or env_file values
+take effect. The checkout is not updated and images are not rebuilt; only containers
+whose configuration changed are recreated. The .env file is read from the directory of
+each compose file in the checkout, exactly as on deploy.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runReloadEnv,
+}
+
+func init() {
+	rootCmd.AddCommand(reloadEnvCmd)
+}
+
+// runReloadEnv recreates the containers of a repository branch with its current environment
+func runReloadEnv(cmd *cobra.Command, args []string) error {
+	repoName := args[0]
+	branch := args[1]
+
+	repo := repositoryService.GetRepository(repoName)
+	if repo == nil {
+		fmt.Fprintf(out, "❌ Repository '%s' not found or disabled\n", repoName)
+		return fmt.Errorf("repository '%s' not found or disabled", repoName)
+	}
+	if !repositoryService.IsBranchConfigured(repo, branch) {
+		fmt.Fprintf(out, "❌ Branch '%s' not configured for repository '%s'\n", branch, repoName)
+		fmt.Fprintf(out, "🌿 Available branches for %s: %v\n", repoName, repo.Branches)
+		return fmt.Errorf("branch '%s' not configured for repository '%s'", branch, repoName)
+	}
+	if !repositoryService.IsRepositoryInitialized(repoName, branch) {
+		fmt.Fprintf(out, "❌ %s:%s is not deployed, run 'uruflow deploy %s %s' first\n", repoName, branch, repoName, branch)
+		return fmt.Errorf("%s:%s is not initialized", repoName, branch)
+	}
+
+	repoPath, err := services.RepositoryPath(cfg.Settings.WorkDir, repoName, branch)
+	if err != nil {
+		fmt.Fprintf(out, "❌ %v\n", err)
+		return err
+	}
+	units, err := services.ComposeUnits(*repo, branch, repoPath)
+	if err != nil {
+		fmt.Fprintf(out, "❌ %v\n", err)
+		return err
+	}
+
+	fmt.Fprintf(out, "♻️ Reloading environment for %s:%s\n", repoName, branch)
+	for _, unit := range units {
+		envFile := services.EnvFilePath(unit, repoPath)
+		if fileExists(envFile) {
+			fmt.Fprintf(out, "   📄 %s: %s\n", unit.ProjectName, envFile)
+		} else {
+			fmt.Fprintf(out, "   📄 %s: no .env file (%s), only env_file entries apply\n", unit.ProjectName, envFile)
+		}
+	}
+
+	logger.Info("Reloading environment for %s:%s", repoName, branch)
+	var projects []string
+	for _, daemon := range dockerService.Daemons(*repo) {
+		reloaded, err := daemon.Docker.ReloadEnv(*repo, branch, repoPath)
+		if err != nil {
+			logger.Error("Environment reload failed%s: %v", onHost(daemon.Host), err)
+			fmt.Fprintf(out, "❌ Environment reload failed%s: %v\n", onHost(daemon.Host), err)
+			return err
+		}
+		for _, project := range reloaded {
+			projects = append(projects, project+onHost(daemon.Host))
+		}
+	}
+
+	logger.Success("Environment reloaded for %s:%s", repoName, branch)
+	fmt.Fprintf(out, "✅ Environment reloaded for %d project(s): %v\n", len(projects), projects)
+	return nil
+}
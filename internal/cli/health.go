@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var healthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "💓 Check the health of the running server",
+	Long: `Query the /health endpoint of a running UruFlow server and exit non-zero when it
+is unreachable or not healthy. Defaults to the locally configured webhook port.
+
+Examples:
+	uruflow health
+	uruflow health --url http://deploy.example.com:8080`,
+	RunE: runHealth,
+}
+
+func init() {
+	rootCmd.AddCommand(healthCmd)
+	healthCmd.Flags().String("url", "", "Base URL of the server (defaults to http://127.0.0.1:<webhook.port>)")
+	healthCmd.Flags().Duration("timeout", 10*time.Second, "Request timeout")
+}
+
+// runHealth fetches /health from the server and reports its status
+func runHealth(cmd *cobra.Command, args []string) error {
+	baseURL, _ := cmd.Flags().GetString("url")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+
+	endpoint := localServerURL("/health")
+	if baseURL != "" {
+		endpoint = strings.TrimSuffix(baseURL, "/") + "/health"
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		fmt.Fprintf(out, "❌ Server unreachable at %s: %v\n", endpoint, err)
+		return fmt.Errorf("server unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var health map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		fmt.Fprintf(out, "❌ Invalid health response from %s (%s): %v\n", endpoint, resp.Status, err)
+		return fmt.Errorf("invalid health response: %v", err)
+	}
+
+	fmt.Fprintf(out, "💓 UruFlow Health (%s)\n", endpoint)
+	fmt.Fprintf(out, "   📊 Status: %v\n", health["status"])
+	fmt.Fprintf(out, "   ⚡ Active jobs: %v\n", health["active_jobs"])
+	fmt.Fprintf(out, "   📥 Queue size: %v\n", health["queue_size"])
+
+	if resp.StatusCode != http.StatusOK || health["status"] != "healthy" {
+		fmt.Fprintf(out, "❌ Server is not healthy (%s)\n", resp.Status)
+		return fmt.Errorf("server is not healthy: %v", health["status"])
+	}
+	fmt.Fprintf(out, "✅ Server is healthy\n")
+	return nil
+}
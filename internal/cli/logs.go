@@ -21,19 +21,24 @@
 package cli
 
 import (
+	"bufio"
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
+const logDateLayout = "2006-01-02"
+
 var logsCmd = &cobra.Command{
 	Use:   "logs",
 	Short: "📝 Show application logs",
 	Long:  `Display UruFlow application logs.`,
-	Run:   showLogs,
+	RunE:  showLogs,
 }
 
 func init() {
@@ -42,38 +47,62 @@ func init() {
 	logsCmd.Flags().BoolP("follow", "f", false, "Follow log output")
 	logsCmd.Flags().StringP("grep", "g", "", "Filter logs containing this text")
 	logsCmd.Flags().BoolP("today", "", false, "Show only today's logs")
+	logsCmd.Flags().String("date", "", "Show the logs of a specific day (YYYY-MM-DD)")
+	logsCmd.Flags().String("since", "", "Show logs from this day on (YYYY-MM-DD), across daily files")
+	logsCmd.Flags().String("until", "", "Show logs up to and including this day (YYYY-MM-DD), across daily files")
 }
 
-func showLogs(cmd *cobra.Command, args []string) {
+func showLogs(cmd *cobra.Command, args []string) error {
 	var logDir = envManager.LogDir
 	if logDir == "" {
 		fmt.Fprintf(os.Stderr, "❌ Environment variable URUFLOW_LOG_DIR is not set\n")
 		logger.Warning("Please set environment variable URUFLOW_LOG_DIR")
-		return
+		return fmt.Errorf("URUFLOW_LOG_DIR is not set")
 	}
 
 	tail, _ := cmd.Flags().GetInt("tail")
 	follow, _ := cmd.Flags().GetBool("follow")
 	grep, _ := cmd.Flags().GetString("grep")
 	today, _ := cmd.Flags().GetBool("today")
+	date, _ := cmd.Flags().GetString("date")
+	since, _ := cmd.Flags().GetString("since")
+	until, _ := cmd.Flags().GetString("until")
+
+	if since != "" || until != "" {
+		if follow || today || date != "" {
+			fmt.Fprintf(out, "❌ --since/--until cannot be combined with --follow, --today or --date\n")
+			return fmt.Errorf("--since/--until cannot be combined with --follow, --today or --date")
+		}
+		// --tail only limits a range when given explicitly, otherwise the whole range is shown
+		if !cmd.Flags().Changed("tail") {
+			tail = 0
+		}
+		return showLogRange(logDir, since, until, grep, tail)
+	}
 
 	var logFile string
-	if today {
-		logFile = filepath.Join(logDir, fmt.Sprintf("uruflow-%s.log", time.Now().Format("2006-01-02")))
+	if date != "" {
+		if _, err := time.Parse(logDateLayout, date); err != nil {
+			fmt.Fprintf(out, "❌ Invalid --date '%s', expected YYYY-MM-DD\n", date)
+			return fmt.Errorf("invalid --date '%s'", date)
+		}
+		logFile = filepath.Join(logDir, fmt.Sprintf("uruflow-%s.log", date))
+	} else if today {
+		logFile = filepath.Join(logDir, fmt.Sprintf("uruflow-%s.log", time.Now().Format(logDateLayout)))
 	} else {
 		logFile = findMostRecentLogFile(logDir)
 		if logFile == "" {
-			fmt.Printf("❌ No log files found in: %s\n", logDir)
-			return
+			fmt.Fprintf(out, "❌ No log files found in: %s\n", logDir)
+			return fmt.Errorf("no log files found in %s", logDir)
 		}
 	}
 
 	if !fileExists(logFile) {
-		fmt.Printf("❌ Log file not found: %s\n", logFile)
-		return
+		fmt.Fprintf(out, "❌ Log file not found: %s\n", logFile)
+		return fmt.Errorf("log file not found: %s", logFile)
 	}
 
-	fmt.Printf("📄 Showing logs from: %s\n\n", logFile)
+	fmt.Fprintf(out, "📄 Showing logs from: %s\n\n", logFile)
 
 	var cmdArgs []string
 	if follow {
@@ -91,19 +120,20 @@ func showLogs(cmd *cobra.Command, args []string) {
 		tailCmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
 		pipe, err := tailCmd.StdoutPipe()
 		if err != nil {
-			fmt.Printf("❌ Error creating pipe: %v\n", err)
-			return
+			fmt.Fprintf(out, "❌ Error creating pipe: %v\n", err)
+			return err
 		}
 		grepCmd.Stdin = pipe
 		grepCmd.Stdout = os.Stdout
 		grepCmd.Stderr = os.Stderr
 		if err := tailCmd.Start(); err != nil {
-			fmt.Printf("❌ Error starting tail: %v\n", err)
-			return
+			fmt.Fprintf(out, "❌ Error starting tail: %v\n", err)
+			return err
 		}
 		if err := grepCmd.Run(); err != nil {
 			if err.Error() != "exit status 1" {
-				fmt.Printf("❌ Error running grep: %v\n", err)
+				fmt.Fprintf(out, "❌ Error running grep: %v\n", err)
+				return err
 			}
 		}
 		tailCmd.Wait()
@@ -113,10 +143,94 @@ func showLogs(cmd *cobra.Command, args []string) {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
 		if err := cmd.Run(); err != nil {
-			fmt.Printf("❌ Error showing logs: %v\n", err)
-			return
+			fmt.Fprintf(out, "❌ Error showing logs: %v\n", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// showLogRange prints the daily log files between since and until (inclusive), filtered by grep and limited to the last tail lines
+func showLogRange(logDir, since, until, grep string, tail int) error {
+	var sinceDate, untilDate time.Time
+	var err error
+	if since != "" {
+		if sinceDate, err = time.Parse(logDateLayout, since); err != nil {
+			fmt.Fprintf(out, "❌ Invalid --since '%s', expected YYYY-MM-DD\n", since)
+			return fmt.Errorf("invalid --since '%s'", since)
+		}
+	}
+	if until != "" {
+		if untilDate, err = time.Parse(logDateLayout, until); err != nil {
+			fmt.Fprintf(out, "❌ Invalid --until '%s', expected YYYY-MM-DD\n", until)
+			return fmt.Errorf("invalid --until '%s'", until)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(logDir, "uruflow-*.log"))
+	sort.Strings(files)
+
+	var selected []string
+	for _, file := range files {
+		day, err := time.Parse(logDateLayout, strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "uruflow-"), ".log"))
+		if err != nil {
+			continue
 		}
+		if (since != "" && day.Before(sinceDate)) || (until != "" && day.After(untilDate)) {
+			continue
+		}
+		selected = append(selected, file)
+	}
+
+	if len(selected) == 0 {
+		fmt.Fprintf(out, "❌ No log files found in %s for the requested range\n", logDir)
+		return fmt.Errorf("no log files found in %s for the requested range", logDir)
+	}
+
+	fmt.Fprintf(out, "📄 Showing logs from %d files: %s .. %s\n\n", len(selected), filepath.Base(selected[0]), filepath.Base(selected[len(selected)-1]))
+
+	var lines []string
+	if grep != "" {
+		// the same grep as the single-file path, so --grep takes the same patterns with or without a range
+		grepCmd := exec.Command("grep", append([]string{"-h", "-e", grep, "--"}, selected...)...)
+		grepCmd.Stderr = os.Stderr
+		output, err := grepCmd.Output()
+		if exitErr, ok := err.(*exec.ExitError); err != nil && (!ok || exitErr.ExitCode() != 1) {
+			fmt.Fprintf(out, "❌ Error running grep: %v\n", err)
+			return err
+		}
+		if text := strings.TrimSuffix(string(output), "\n"); text != "" {
+			lines = strings.Split(text, "\n")
+		}
+	} else {
+		for _, file := range selected {
+			f, err := os.Open(file)
+			if err != nil {
+				fmt.Fprintf(out, "❌ Error reading %s: %v\n", file, err)
+				continue
+			}
+			scanner := bufio.NewScanner(f)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			err = scanner.Err()
+			f.Close()
+			if err != nil {
+				fmt.Fprintf(out, "❌ Error reading %s: %v\n", file, err)
+				return fmt.Errorf("failed to read %s: %v", file, err)
+			}
+		}
+	}
+
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	// the log lines go to stdout untouched, the plain writer only applies to the decorations
+	for _, line := range lines {
+		fmt.Fprintln(os.Stdout, line)
 	}
+	return nil
 }
 
 // Helper function to find the most recent log file
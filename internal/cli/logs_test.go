@@ -0,0 +1,126 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what fn writes to os.Stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	w.Close()
+	return <-done
+}
+
+// writeLogDay writes the daily log file of a day
+func writeLogDay(t *testing.T, logDir, day string, lines ...string) {
+	t.Helper()
+	content := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(logDir, "uruflow-"+day+".log"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShowLogRange(t *testing.T) {
+	logDir := t.TempDir()
+	writeLogDay(t, logDir, "2026-10-12", "[12:00:00] ✅ Deployed api:main to café", "====================")
+	writeLogDay(t, logDir, "2026-10-13", "[09:30:00] ❌ Deployment of api:dev failed")
+
+	// piped output is plain, the log lines must still arrive unchanged
+	previous := out
+	out = &plainWriter{w: io.Discard}
+	defer func() { out = previous }()
+
+	got := captureStdout(t, func() {
+		if err := showLogRange(logDir, "2026-10-12", "2026-10-13", "", 0); err != nil {
+			t.Fatal(err)
+		}
+	})
+	want := "[12:00:00] ✅ Deployed api:main to café\n====================\n[09:30:00] ❌ Deployment of api:dev failed\n"
+	if got != want {
+		t.Errorf("log range output %q, want %q", got, want)
+	}
+}
+
+func TestShowLogRangeGrep(t *testing.T) {
+	logDir := t.TempDir()
+	writeLogDay(t, logDir, "2026-10-12", "[12:00:00] ✅ Deployed api:main", "[12:05:00] ❌ Deployment of api:dev failed")
+	writeLogDay(t, logDir, "2026-10-13", "[09:30:00] ✅ Deployed web:main")
+	previous := out
+	out = io.Discard
+	defer func() { out = previous }()
+
+	tests := []struct {
+		name string
+		grep string
+		tail int
+		want string
+	}{
+		{"substring", "web", 0, "[09:30:00] ✅ Deployed web:main\n"},
+		{"regular expression", "Deployed [a-z]*:main", 0, "[12:00:00] ✅ Deployed api:main\n[09:30:00] ✅ Deployed web:main\n"},
+		{"tail after grep", "Deployed", 1, "[09:30:00] ✅ Deployed web:main\n"},
+		{"no match", "api:stage", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := captureStdout(t, func() {
+				if err := showLogRange(logDir, "2026-10-12", "", tt.grep, tt.tail); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if got != tt.want {
+				t.Errorf("output %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShowLogRangeLongLine(t *testing.T) {
+	logDir := t.TempDir()
+	writeLogDay(t, logDir, "2026-10-12", strings.Repeat("x", 2*1024*1024))
+	previous := out
+	out = io.Discard
+	defer func() { out = previous }()
+
+	captureStdout(t, func() {
+		if err := showLogRange(logDir, "2026-10-12", "", "", 0); err == nil {
+			t.Error("a line over the scanner limit was silently truncated")
+		}
+	})
+}
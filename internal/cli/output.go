@@ -0,0 +1,107 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// out receives all command output, wrapped by a plainWriter when plain output is selected
+var out io.Writer = os.Stdout
+
+// plainSymbols maps status symbols to ASCII words in plain output, every other non-ASCII rune is dropped
+var plainSymbols = strings.NewReplacer(
+	"✅", "[ok]",
+	"❌", "[error]",
+	"⚠️", "[warn]",
+	"⚠", "[warn]",
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Plain ASCII output without emoji, for scripts and log capture")
+	rootCmd.PersistentFlags().Bool("plain", false, "Alias for --quiet")
+	cobra.OnInitialize(setupOutput)
+}
+
+// setupOutput selects plain output for --quiet/--plain, when NO_COLOR is set or when stdout is not a terminal
+func setupOutput() {
+	quiet, _ := rootCmd.PersistentFlags().GetBool("quiet")
+	plain, _ := rootCmd.PersistentFlags().GetBool("plain")
+	if quiet || plain || os.Getenv("NO_COLOR") != "" || !isTerminal(os.Stdout) {
+		out = &plainWriter{w: os.Stdout}
+	}
+}
+
+// isTerminal reports whether f is a character device such as an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// plainWriter strips emoji and decorative rules from output, keeping it ASCII-only
+type plainWriter struct {
+	w io.Writer
+}
+
+// Write converts p to plain text; it always reports len(p) so fmt callers see a complete write
+func (pw *plainWriter) Write(p []byte) (int, error) {
+	lines := strings.SplitAfter(string(p), "\n")
+	var plain strings.Builder
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		text := plainText(line)
+		// rules such as ===== only decorate headings
+		if strings.Trim(text, "=-_ \t\r\n") == "" && strings.TrimSpace(line) != "" {
+			continue
+		}
+		plain.WriteString(text)
+	}
+	if _, err := io.WriteString(pw.w, plain.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// plainText replaces status symbols with words and drops other non-ASCII runes with the space following them
+func plainText(s string) string {
+	s = plainSymbols.Replace(s)
+	var b strings.Builder
+	dropped := false
+	for _, r := range s {
+		if r > 127 {
+			dropped = true
+			continue
+		}
+		if dropped && r == ' ' {
+			dropped = false
+			continue
+		}
+		dropped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
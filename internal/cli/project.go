@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"uruflow.com/internal/services"
+)
+
+var projectNameCmd = &cobra.Command{
+	Use:   "project-name [repository] [branch]",
+	Short: "🏷️ Show the compose project name of a branch",
+	Long: `Print the compose project name UruFlow uses for a repository branch, including
+project_name and unit overrides, together with the compose file it deploys, so the
+same project can be targeted with manual docker compose commands.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runProjectName,
+}
+
+func init() {
+	rootCmd.AddCommand(projectNameCmd)
+}
+
+// runProjectName prints the resolved compose project and file of every unit of a branch
+func runProjectName(cmd *cobra.Command, args []string) error {
+	repoName := args[0]
+	branch := args[1]
+
+	repo := repositoryService.GetRepository(repoName)
+	if repo == nil {
+		fmt.Fprintf(out, "❌ Repository '%s' not found or disabled\n", repoName)
+		return fmt.Errorf("repository '%s' not found or disabled", repoName)
+	}
+
+	repoPath, err := services.RepositoryPath(cfg.Settings.WorkDir, repoName, branch)
+	if err != nil {
+		fmt.Fprintf(out, "❌ %v\n", err)
+		return err
+	}
+
+	units, err := services.ComposeUnits(*repo, branch, repoPath)
+	if err != nil {
+		// without a checkout the default compose file cannot be detected, the project name is still known
+		for _, project := range services.ProjectNames(*repo, branch) {
+			fmt.Fprintf(out, "%s\n", project)
+		}
+		fmt.Fprintf(out, "⚠️ Compose file not resolved: %v\n", err)
+		return nil
+	}
+
+	for _, unit := range units {
+		composeFile := filepath.Join(repoPath, unit.ComposeFile)
+		fmt.Fprintf(out, "%s\n", unit.ProjectName)
+		fmt.Fprintf(out, "   📄 %s\n", composeFile)
+		fmt.Fprintf(out, "   💡 %s -p %s -f %s ps\n", dockerService.ComposeCommandFor(*repo), unit.ProjectName, composeFile)
+	}
+	return nil
+}
@@ -21,10 +21,12 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"uruflow.com/internal/services"
 )
 
 var repoCmd = &cobra.Command{
@@ -45,7 +47,7 @@ var repoInfoCmd = &cobra.Command{
 	Short: "ℹ️  Show repository information",
 	Long:  `Show detailed information about a specific repository.`,
 	Args:  cobra.MaximumNArgs(1),
-	Run:   showRepositoryInfo,
+	RunE:  showRepositoryInfo,
 }
 
 var repoUpdateCmd = &cobra.Command{
@@ -53,7 +55,16 @@ var repoUpdateCmd = &cobra.Command{
 	Short: "🔄 Update repository",
 	Long:  `Update a specific repository by pulling latest changes.`,
 	Args:  cobra.ExactArgs(1),
-	Run:   updateRepository,
+	RunE:  updateRepository,
+}
+
+var repoBranchesCmd = &cobra.Command{
+	Use:   "branches [repository]",
+	Short: "🌿 List remote branches",
+	Long: `List the branches that exist on the remote of a repository with git ls-remote,
+without cloning, marking the ones configured for deployment.`,
+	Args: cobra.ExactArgs(1),
+	RunE: listRemoteBranches,
 }
 
 func init() {
@@ -61,6 +72,7 @@ func init() {
 	repoCmd.AddCommand(repoListCmd)
 	repoCmd.AddCommand(repoInfoCmd)
 	repoCmd.AddCommand(repoUpdateCmd)
+	repoCmd.AddCommand(repoBranchesCmd)
 }
 
 // listRepositories displays all configured repositories with their basic information and status
@@ -68,91 +80,201 @@ func listRepositories(cmd *cobra.Command, args []string) {
 	repos := repositoryService.ListRepositories()
 
 	if len(repos) == 0 {
-		fmt.Printf("📭 No repositories configured\n")
+		fmt.Fprintf(out, "📭 No repositories configured\n")
 		return
 	}
-	fmt.Printf("📦 Configured Repositories (%d)\n", len(repos))
-	fmt.Printf("===========================\n\n")
+	fmt.Fprintf(out, "📦 Configured Repositories (%d)\n", len(repos))
+	fmt.Fprintf(out, "===========================\n\n")
 	for _, repo := range repos {
 		status := "🟢"
 		if !repo.Enabled {
 			status = "🔴"
 		}
 
-		fmt.Printf("%s %s\n", status, repo.Name)
-		fmt.Printf("   🌐 URL: %s\n", repo.GitURL)
-		fmt.Printf("   🌿 Branches: %s\n", strings.Join(repo.Branches, ", "))
-		fmt.Printf("   🚀 Auto-deploy: %t\n", repo.AutoDeploy)
-		fmt.Printf("   📄 Compose file: %s\n", repo.ComposeFile)
-		fmt.Printf("\n")
+		fmt.Fprintf(out, "%s %s\n", status, repo.Name)
+		fmt.Fprintf(out, "   🌐 URL: %s\n", repo.GitURL)
+		fmt.Fprintf(out, "   🌿 Branches: %s\n", strings.Join(repo.Branches, ", "))
+		fmt.Fprintf(out, "   🚀 Auto-deploy: %t\n", repo.AutoDeploy)
+		fmt.Fprintf(out, "   📄 Compose file: %s\n", getComposeFileDisplay(repo.ComposeFile))
+		fmt.Fprintf(out, "\n")
 	}
 }
 
 // showRepositoryInfo displays detailed information for a specific repository or all repositories if no argument provided
-func showRepositoryInfo(cmd *cobra.Command, args []string) {
+func showRepositoryInfo(cmd *cobra.Command, args []string) error {
 	if len(args) == 0 {
 		info := repositoryService.GetRepositoryInfo()
 
-		fmt.Printf("ℹ️  Repository Information\n")
-		fmt.Printf("========================\n\n")
+		fmt.Fprintf(out, "ℹ️  Repository Information\n")
+		fmt.Fprintf(out, "========================\n\n")
 
 		for name, repoInfo := range info {
-			fmt.Printf("📦 %s\n", name)
+			fmt.Fprintf(out, "📦 %s\n", name)
 			if repoInfoMap, ok := repoInfo.(map[string]interface{}); ok {
-				fmt.Printf("   🌐 Git URL: %s\n", repoInfoMap["git_url"])
-				fmt.Printf("   🌿 Branches: %v\n", repoInfoMap["branches"])
-				fmt.Printf("   🚀 Auto-deploy: %t\n", repoInfoMap["auto_deploy"])
-				fmt.Printf("   📄 Compose file: %s\n", repoInfoMap["compose_file"])
+				fmt.Fprintf(out, "   🌐 Git URL: %s\n", repoInfoMap["git_url"])
+				fmt.Fprintf(out, "   🌿 Branches: %v\n", repoInfoMap["branches"])
+				fmt.Fprintf(out, "   🚀 Auto-deploy: %t\n", repoInfoMap["auto_deploy"])
+				fmt.Fprintf(out, "   📄 Compose file: %s\n", getComposeFileDisplay(fmt.Sprint(repoInfoMap["compose_file"])))
 
 				if status, ok := repoInfoMap["status"].(map[string]string); ok {
-					fmt.Printf("   📊 Status:\n")
+					fmt.Fprintf(out, "   📊 Status:\n")
 					for branch, branchStatus := range status {
 						statusEmoji := getStatusEmoji(branchStatus)
-						fmt.Printf("     %s %s: %s\n", statusEmoji, branch, branchStatus)
+						fmt.Fprintf(out, "     %s %s: %s\n", statusEmoji, branch, branchStatus)
 					}
 				}
 			}
-			fmt.Printf("\n")
+			fmt.Fprintf(out, "\n")
 		}
-		return
+		return nil
 	}
 	repoName := args[0]
 	repo := repositoryService.GetRepository(repoName)
 	if repo == nil {
 		logger.Error("Repository '%s' not found", repoName)
-		return
+		return fmt.Errorf("repository '%s' not found", repoName)
 	}
 
-	fmt.Printf("📦 Repository: %s\n", repo.Name)
-	fmt.Printf("================\n\n")
-	fmt.Printf("🌐 Git URL: %s\n", repo.GitURL)
-	fmt.Printf("🌿 Branches: %s\n", strings.Join(repo.Branches, ", "))
-	fmt.Printf("🚀 Auto-deploy: %t\n", repo.AutoDeploy)
-	fmt.Printf("✅ Enabled: %t\n", repo.Enabled)
-	fmt.Printf("📄 Compose file: %s\n", repo.ComposeFile)
+	fmt.Fprintf(out, "📦 Repository: %s\n", repo.Name)
+	fmt.Fprintf(out, "================\n\n")
+	fmt.Fprintf(out, "🌐 Git URL: %s\n", repo.GitURL)
+	fmt.Fprintf(out, "🌿 Branches: %s\n", strings.Join(repo.Branches, ", "))
+	if repo.DeployDefaultBranch {
+		fmt.Fprintf(out, "🌱 Deploy default branch: enabled\n")
+	}
+	if repo.SkipIfUnchanged {
+		fmt.Fprintf(out, "⏭️ Skip if unchanged: enabled\n")
+	}
+	fmt.Fprintf(out, "🚀 Auto-deploy: %t\n", repo.AutoDeploy)
+	fmt.Fprintf(out, "✅ Enabled: %t\n", repo.Enabled)
+	fmt.Fprintf(out, "📄 Compose file: %s\n", getComposeFileDisplay(repo.ComposeFile))
 
 	if len(repo.BranchConfig) > 0 {
-		fmt.Printf("\n⚙️  Branch Configuration:\n")
+		fmt.Fprintf(out, "\n⚙️  Branch Configuration:\n")
 		for branch, config := range repo.BranchConfig {
-			fmt.Printf("  🌿 %s:\n", branch)
-			fmt.Printf("    📁 Project name: %s\n", config.ProjectName)
+			fmt.Fprintf(out, "  🌿 %s:\n", branch)
+			fmt.Fprintf(out, "    📁 Project name: %s\n", config.ProjectName)
+			if config.AutoDeploy != nil {
+				fmt.Fprintf(out, "    🚀 Auto-deploy: %t\n", *config.AutoDeploy)
+			}
+			if config.ComposeFile != "" {
+				fmt.Fprintf(out, "    📄 Compose file: %s\n", config.ComposeFile)
+			}
+			if config.EnvFile != "" {
+				fmt.Fprintf(out, "    🔐 Env file: %s\n", config.EnvFile)
+			}
+			if len(config.Secrets) > 0 {
+				fmt.Fprintf(out, "    🔐 Secrets: %d\n", len(config.Secrets))
+			}
+			if len(config.Units) > 0 {
+				fmt.Fprintf(out, "    🧩 Compose units:\n")
+				projects := services.ProjectNames(*repo, branch)
+				for i, unit := range config.Units {
+					fmt.Fprintf(out, "      - %s: %s (project: %s)\n", unit.Name, getComposeFileDisplay(unit.ComposeFile), projects[i])
+				}
+			}
 		}
 	}
+	return nil
 }
 
 // updateRepository pulls the latest changes for a specific repository
-func updateRepository(cmd *cobra.Command, args []string) {
+func updateRepository(cmd *cobra.Command, args []string) error {
 	repoName := args[0]
 
 	logger.Info("Updating repository: %s", repoName)
 
 	if err := repositoryService.UpdateRepository(repoName); err != nil {
 		logger.Error("Failed to update repository: %v", err)
-		return
+		return fmt.Errorf("failed to update repository %s: %v", repoName, err)
 	}
 
 	logger.Success("Repository %s updated successfully", repoName)
-	fmt.Printf("Repository %s updated successfully\n", repoName)
+	fmt.Fprintf(out, "Repository %s updated successfully\n", repoName)
+	return nil
+}
+
+// listRemoteBranches prints the branches of a repository remote, marking configured, default and excluded branches
+func listRemoteBranches(cmd *cobra.Command, args []string) error {
+	repoName := args[0]
+	repo := repositoryService.GetRepository(repoName)
+	if repo == nil {
+		logger.Error("Repository '%s' not found", repoName)
+		return fmt.Errorf("repository '%s' not found", repoName)
+	}
+
+	if gitService.RequiresSSH(repo.GitURL) && !gitService.IsSSHAvailable() {
+		fmt.Fprintf(out, "❌ SSH is not configured\n")
+		fmt.Fprintf(out, "💡 Run 'uruflow ssh setup' for setup instructions\n")
+		return fmt.Errorf("SSH is not configured")
+	}
+
+	branches, defaultBranch, err := gitService.ListRemoteBranches(repo.GitURL)
+	if err != nil {
+		fmt.Fprintf(out, "❌ Failed to list branches of %s: %v\n", repo.GitURL, err)
+		if errors.Is(err, services.ErrSSHAuthFailed) {
+			fmt.Fprintf(out, "💡 Check the connection with 'uruflow ssh test' and make sure the key is added to the Git host\n")
+			fmt.Fprintf(out, "💡 Run 'uruflow ssh setup' for setup instructions\n")
+		}
+		return err
+	}
+
+	configured := make(map[string]bool)
+	for _, branch := range repo.Branches {
+		configured[branch] = true
+	}
+
+	fmt.Fprintf(out, "🌿 Remote branches of %s (%d)\n", repo.Name, len(branches))
+	fmt.Fprintf(out, "===========================\n\n")
+	for _, branch := range branches {
+		var marks []string
+		deployed := configured[branch] || (repo.DeployDefaultBranch && branch == defaultBranch)
+		if configured[branch] {
+			marks = append(marks, "configured")
+		}
+		if branch == defaultBranch {
+			marks = append(marks, "default")
+		}
+		if repositoryService.IsBranchExcluded(repo, branch) {
+			deployed = false
+			marks = append(marks, "excluded")
+		}
+
+		status := "  "
+		if deployed {
+			status = "✅"
+		}
+		line := fmt.Sprintf("%s %s", status, branch)
+		if len(marks) > 0 {
+			line += " (" + strings.Join(marks, ", ") + ")"
+		}
+		fmt.Fprintf(out, "%s\n", line)
+	}
+
+	for _, branch := range repo.Branches {
+		if !containsBranch(branches, branch) {
+			fmt.Fprintf(out, "⚠️ Configured branch '%s' does not exist on the remote\n", branch)
+		}
+	}
+	return nil
+}
+
+// containsBranch reports whether branch is in the list
+func containsBranch(branches []string, branch string) bool {
+	for _, b := range branches {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// getComposeFileDisplay shows the compose file or the auto-detection note when unset
+func getComposeFileDisplay(composeFile string) string {
+	if composeFile == "" {
+		return "(auto-detect: " + strings.Join(services.DefaultComposeFiles, ", ") + ")"
+	}
+	return composeFile
 }
 
 // Add simple emoji for status
@@ -21,6 +21,7 @@
 package cli
 
 import (
+	"errors"
 	"github.com/spf13/cobra"
 	"os"
 	"uruflow.com/env_manager"
@@ -38,6 +39,7 @@ var (
 	dockerService     *services.DockerService
 	repositoryService *services.RepositoryService
 	deploymentService *services.DeploymentService
+	notifier          *services.NotificationService
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -54,9 +56,12 @@ Examples:
 	uruflow repo list            List all repositories
 	uruflow config info          Show configuration information`,
 	PersistentPreRun: initializeServices,
-	Run: func(cmd *cobra.Command, args []string) {
+	// errors returned by commands are printed once by cmd/main.go and set the exit code, without the usage dump
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		logger.Info("No command specified, starting webhook server...")
-		runServer(cmd, args)
+		return runServer(cmd, args)
 	},
 }
 
@@ -73,6 +78,10 @@ func Execute() error {
 
 func initializeServices(cmd *cobra.Command, args []string) {
 	logger = utils.NewLogger("[URUFLOW] ")
+	// keep JSON output on stdout parseable
+	if asJSON, err := cmd.Flags().GetBool("json"); err == nil && asJSON {
+		logger.ConsoleToStderr()
+	}
 
 	debug, _ := cmd.Flags().GetBool("debug")
 	if debug {
@@ -86,9 +95,8 @@ func initializeServices(cmd *cobra.Command, args []string) {
 
 	envManager = env_manager.NewEnvManager()
 
-	configPath := config.GetConfigPath(envManager)
 	if verbose {
-		logger.Config("Using configuration file: %s", configPath)
+		logger.Config("Using configuration from: %s", config.ConfigSource(envManager))
 	}
 
 	var err error
@@ -98,18 +106,28 @@ func initializeServices(cmd *cobra.Command, args []string) {
 	}
 
 	gitService = services.NewGitService(logger)
-	dockerService = services.NewDockerService(logger)
+	dockerService = services.NewDockerService(cfg, logger)
 	repositoryService = services.NewRepositoryService(cfg, gitService, logger)
 	deploymentService = services.NewDeploymentService(cfg, repositoryService, gitService, dockerService, logger)
+	notifier = services.NewNotificationService(cfg, logger)
+	notifier.SetEventBus(deploymentService.Events())
+	repositoryService.SetNotifier(notifier)
+	deploymentService.SetNotifier(notifier)
 
 	if verbose {
 		logger.Info("Initializing Git service with SSH support...")
 	}
 	if err := gitService.Initialize(); err != nil {
-		if verbose {
+		if errors.Is(err, services.ErrGitNotFound) {
+			// deployments cannot work at all without git, so say so even without --verbose
+			logger.Error("%v", err)
+		} else if verbose {
 			logger.Warning("Git service initialization failed: %v", err)
 		}
 	} else if verbose {
 		logger.Success("SSH authentication configured")
 	}
+	if err := gitService.SetSSHHosts(cfg.Settings.SSHHosts); err != nil {
+		logger.Warning("Failed to apply ssh_hosts: %v", err)
+	}
 }
@@ -22,10 +22,18 @@ package cli
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -34,13 +42,14 @@ import (
 	"uruflow.com/internal/config"
 	"uruflow.com/internal/handlers"
 	"uruflow.com/internal/models"
+	"uruflow.com/internal/services"
 )
 
 var serverCmd = &cobra.Command{
 	Use:   "server",
 	Short: "🌐 Start the webhook server",
 	Long:  `Start the UruFlow webhook server to listen for Git webhooks and auto-deploy.`,
-	Run:   runServer,
+	RunE:  runServer,
 }
 
 func init() {
@@ -48,8 +57,19 @@ func init() {
 	serverCmd.Flags().StringP("port", "p", "", "Port to run server on (overrides config)")
 }
 
+// serverConfig holds the configuration of the running server, swapped atomically on reload
+var serverConfig atomic.Pointer[models.Config]
+
+// currentConfig returns the configuration of the running server, or the loaded one outside of it
+func currentConfig() *models.Config {
+	if c := serverConfig.Load(); c != nil {
+		return c
+	}
+	return cfg
+}
+
 // runServer starts the webhook server
-func runServer(cmd *cobra.Command, args []string) {
+func runServer(cmd *cobra.Command, args []string) error {
 	logger.Startup("Starting UruFlow Auto-Deploy System...")
 
 	port, _ := cmd.Flags().GetString("port")
@@ -58,28 +78,69 @@ func runServer(cmd *cobra.Command, args []string) {
 		logger.Info("Port moved to: %s", port)
 	}
 
-	config.WatchConfig(envManager, func(newConfig *models.Config) {
-		logger.Config("Configuration file changed, reloading...")
+	var selfHeal *services.SelfHealService
+	if cfg.Settings.SelfHealInterval > 0 {
+		selfHeal = services.NewSelfHealService(cfg, repositoryService, deploymentService, dockerService, logger)
+		selfHeal.Start(time.Duration(cfg.Settings.SelfHealInterval) * time.Second)
+	}
+
+	if cfg.Settings.MaxJobDuration > 0 {
+		deploymentService.StartJobReaper(time.Minute)
+	}
+
+	webhookHandler := handlers.NewWebhookHandler(cfg, repositoryService, deploymentService, gitService, dockerService, logger)
+	teardownService := services.NewTeardownService(cfg, repositoryService, deploymentService, gitService, dockerService, logger)
+
+	serverConfig.Store(cfg)
+	applyConfig := func(newConfig *models.Config) {
 		repositoryService.UpdateConfig(newConfig)
-		cfg = newConfig
+		dockerService.UpdateConfig(newConfig)
+		deploymentService.UpdateConfig(newConfig)
+		notifier.UpdateConfig(newConfig)
+		if err := gitService.SetSSHHosts(newConfig.Settings.SSHHosts); err != nil {
+			logger.Warning("Failed to apply ssh_hosts: %v", err)
+		}
+		if selfHeal != nil {
+			selfHeal.UpdateConfig(newConfig)
+		}
+		webhookHandler.UpdateConfig(newConfig)
+		teardownService.UpdateConfig(newConfig)
+		serverConfig.Store(newConfig)
 		logger.Success("Configuration reloaded successfully")
-	})
+	}
+	if config.ReloadSupported(envManager) {
+		config.WatchConfig(envManager, func(newConfig *models.Config) {
+			logger.Config("Configuration file changed, reloading...")
+			applyConfig(newConfig)
+		})
+		setupReloadSignal(applyConfig)
+	} else {
+		logger.Config("Configuration loaded from %s, hot reload is disabled", config.ConfigSource(envManager))
+	}
 
 	if cfg.Settings.AutoClone {
-		logger.Info("Initializing repositories...")
-		if err := repositoryService.InitializeRepositories(); err != nil {
-			logger.Error("Failed to initialize repositories: %v", err)
-			logger.Info("Continuing without repository initialization...")
+		if err := initializeRepositories(); err != nil {
+			return err
 		}
 	}
 
-	server := setupHTTPServer()
+	if cfg.Settings.Prepull {
+		go prepullImages()
+	}
+
+	server := setupHTTPServer(webhookHandler, teardownService)
 	setupGracefulShutdown(server)
 
 	logger.Deploy("UruFlow webhook server started on port %s", cfg.Webhook.Port)
-	logger.Info("Webhook endpoint: http://0.0.0.0:%s%s", cfg.Webhook.Port, cfg.Webhook.Path)
+	for _, path := range config.WebhookPaths(cfg) {
+		logger.Info("Webhook endpoint: http://0.0.0.0:%s%s", cfg.Webhook.Port, path)
+	}
 	logger.Info("Managing %d repositories", len(cfg.Repositories))
 
+	if cfg.Webhook.AllowUnsignedLocalhost {
+		logger.Security("allow_unsigned_localhost is enabled: unsigned webhooks from loopback are accepted, disable it in production")
+	}
+
 	if gitService.IsSSHAvailable() {
 		logger.Success("SSH authentication is configured and ready")
 	} else {
@@ -93,18 +154,47 @@ func runServer(cmd *cobra.Command, args []string) {
 	logger.Info("Starting server on %s", address)
 
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		logger.Fatal("Server failed to start: %v", err)
+		logger.Error("Server failed to start: %v", err)
+		return fmt.Errorf("server failed to start: %v", err)
 	}
+	return nil
+}
+
+// initializeRepositories clones the configured repositories; a failure only stops startup with fail_on_init_error
+func initializeRepositories() error {
+	logger.Info("Initializing repositories...")
+	if err := repositoryService.InitializeRepositories(); err != nil {
+		logger.Error("Failed to initialize repositories: %v", err)
+		if cfg.Settings.FailOnInitError {
+			logger.Error("Refusing to start, fail_on_init_error is enabled")
+			return fmt.Errorf("repository initialization failed: %v", err)
+		}
+		logger.Info("Continuing without repository initialization (set fail_on_init_error to abort startup instead)...")
+	}
+	return nil
 }
 
 // setupHTTPServer configures and returns the HTTP server
-func setupHTTPServer() *http.Server {
-	r := mux.NewRouter()
-	webhookHandler := handlers.NewWebhookHandler(cfg, repositoryService, deploymentService, gitService, dockerService, logger)
+func setupHTTPServer(webhookHandler *handlers.WebhookHandler, teardownService *services.TeardownService) *http.Server {
+	// branch names such as feature/x arrive as one escaped segment, see routeVar
+	r := mux.NewRouter().UseEncodedPath()
+	if err := teardownService.Restore(); err != nil {
+		logger.Warning("Could not restore pending teardowns: %v", err)
+	}
+	webhookHandler.SetTeardownService(teardownService)
 
-	r.HandleFunc(cfg.Webhook.Path, webhookHandler.HandleWebhook).Methods("POST")
+	for _, path := range config.WebhookPaths(cfg) {
+		r.HandleFunc(path, webhookHandler.HandleWebhook).Methods("POST")
+	}
 	r.HandleFunc("/health", handleHealth).Methods("GET")
 	r.HandleFunc("/status", handleStatus).Methods("GET")
+	r.HandleFunc("/drift", handleDrift).Methods("GET")
+	r.HandleFunc("/metrics", handleMetrics).Methods("GET")
+	r.HandleFunc("/deployments/{repository}/{branch}/deploy", requireAPIToken(handleEnqueueDeployment)).Methods("POST")
+	r.HandleFunc("/deployments/{repository}/{branch}/cancel", requireAPIToken(handleCancelDeployment)).Methods("POST")
+	r.HandleFunc("/deployments/{repository}/{branch}/reset", requireAPIToken(handleResetCircuit)).Methods("POST")
+	r.HandleFunc("/deployments/jobs/{id}", requireAPIToken(handleGetJob)).Methods("GET")
+	r.HandleFunc("/events", requireAPIToken(handleEvents)).Methods("GET")
 
 	return &http.Server{
 		Addr:         "0.0.0.0:" + cfg.Webhook.Port,
@@ -141,9 +231,86 @@ func setupGracefulShutdown(server *http.Server) {
 	}()
 }
 
+// setupReloadSignal reloads the configuration on SIGHUP, in addition to the file watcher
+func setupReloadSignal(applyConfig func(*models.Config)) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+
+	go func() {
+		for range c {
+			logger.Config("Received SIGHUP, reloading configuration from %s", config.GetConfigPath(envManager))
+			newConfig, err := config.Load(envManager)
+			if err != nil {
+				logger.Error("Failed to reload configuration, keeping the current one: %v", err)
+				continue
+			}
+			applyConfig(newConfig)
+		}
+	}()
+}
+
+// prepullImages pulls the images of every initialized enabled repository branch, at most max_concurrent at a time
+func prepullImages() {
+	type target struct {
+		repo   models.Repository
+		branch string
+	}
+
+	var targets []target
+	for _, repo := range repositoryService.ListRepositories() {
+		for _, branch := range repositoryService.GetDeployBranches(repo) {
+			if repositoryService.IsRepositoryInitialized(repo.Name, branch) {
+				targets = append(targets, target{repo: repo, branch: branch})
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	workers := cfg.Settings.MaxConcurrent
+	if workers < 1 {
+		workers = 1
+	}
+	logger.Docker("Pre-pulling images for %d branch(es) in the background (%d at a time)", len(targets), workers)
+	start := time.Now()
+
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	var failed atomic.Int64
+	for _, t := range targets {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(t target) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			repoPath, err := services.RepositoryPath(cfg.Settings.WorkDir, t.repo.Name, t.branch)
+			if err == nil {
+				for _, daemon := range dockerService.Daemons(t.repo) {
+					if err = daemon.Docker.PrepullImages(context.Background(), t.repo, t.branch, repoPath); err != nil {
+						if daemon.Host != "" {
+							err = fmt.Errorf("%s: %v", daemon.Host, err)
+						}
+						break
+					}
+				}
+			}
+			if err != nil {
+				failed.Add(1)
+				logger.Warning("Pre-pull failed for %s:%s: %v", t.repo.Name, t.branch, err)
+				return
+			}
+			logger.Docker("Pre-pulled images for %s:%s", t.repo.Name, t.branch)
+		}(t)
+	}
+	wg.Wait()
+
+	logger.Success("Pre-pull finished for %d branch(es) in %v (%d failed)", len(targets), time.Since(start).Round(time.Second), failed.Load())
+}
+
 // handleHealth provides a health check endpoint
 func handleHealth(w http.ResponseWriter, r *http.Request) {
-	logger.Info("Health check request from %s", r.RemoteAddr)
+	logger.Info("Health check request from %s", handlers.ClientIP(r, currentConfig().Webhook.TrustedProxies))
 
 	stats := deploymentService.GetDeploymentStats()
 	activeJobs := deploymentService.GetActiveJobs()
@@ -159,7 +326,10 @@ func handleHealth(w http.ResponseWriter, r *http.Request) {
 
 // handleStatus provides a detailed status endpoint
 func handleStatus(w http.ResponseWriter, r *http.Request) {
-	logger.Info("Status request from %s", r.RemoteAddr)
+	logger.Info("Status request from %s", handlers.ClientIP(r, currentConfig().Webhook.TrustedProxies))
+	if r.URL.Query().Get("fresh") == "true" {
+		repositoryService.InvalidateStatus("", "")
+	}
 
 	stats := deploymentService.GetDeploymentStats()
 	activeJobs := deploymentService.GetActiveJobs()
@@ -175,13 +345,249 @@ func handleStatus(w http.ResponseWriter, r *http.Request) {
 		"total_jobs":         stats["total_jobs"],
 		"completed_jobs":     stats["completed_jobs"],
 		"failed_jobs":        stats["failed_jobs"],
+		"cancelled_jobs":     stats["cancelled_jobs"],
+		"reaped_jobs":        stats["reaped_jobs"],
+		"stuck_jobs":         deploymentService.StuckJobs(),
 		"timeout_jobs":       stats["timeout_jobs"],
 		"success_rate":       stats["success_rate"],
 		"active_job_details": activeJobs,
-		"repositories":       len(cfg.Repositories),
+		"repositories":       len(currentConfig().Repositories),
+		"repository_state":   repositoryService.GetDeploymentState(),
 		"ssh_available":      gitService.IsSSHAvailable(),
 		"timestamp":          time.Now().Unix(),
 	}
 
 	json.NewEncoder(w).Encode(response)
 }
+
+// handleMetrics serves the deployment counters and per-branch gauges for Prometheus
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	deploymentService.WriteMetrics(w)
+}
+
+// handleDrift reports configured projects that are not running and running projects that are not configured
+func handleDrift(w http.ResponseWriter, r *http.Request) {
+	logger.Info("Drift request from %s", handlers.ClientIP(r, currentConfig().Webhook.TrustedProxies))
+
+	report, err := computeDrift()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"status":  "error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// requireAPIToken rejects requests without the configured webhook.api_token as a Bearer token
+func requireAPIToken(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conf := currentConfig()
+		clientIP := handlers.ClientIP(r, conf.Webhook.TrustedProxies)
+		if conf.Webhook.APIToken == "" {
+			logger.Security("Rejected %s %s from %s: webhook.api_token is not configured", r.Method, r.URL.Path, clientIP)
+			writeJSON(w, http.StatusForbidden, map[string]interface{}{
+				"status":  "forbidden",
+				"message": "API token not configured (set webhook.api_token)",
+			})
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(conf.Webhook.APIToken)) != 1 {
+			logger.Security("Rejected %s %s from %s: invalid API token", r.Method, r.URL.Path, clientIP)
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+				"status":  "unauthorized",
+				"message": "invalid or missing API token",
+			})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// enqueueRequest is the optional body of a deploy request
+type enqueueRequest struct {
+	Services []string `json:"services,omitempty"`
+	Actor    string   `json:"actor,omitempty"`
+}
+
+// handleEnqueueDeployment starts a deployment of a repository branch in the background and returns its job ID
+func handleEnqueueDeployment(w http.ResponseWriter, r *http.Request) {
+	repoName, branch := routeVar(r, "repository"), routeVar(r, "branch")
+	logger.Info("Deploy request for %s:%s from %s", repoName, branch, handlers.ClientIP(r, currentConfig().Webhook.TrustedProxies))
+
+	var request enqueueRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&request); err != nil && err != io.EOF {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"status":  "failed",
+				"message": fmt.Sprintf("invalid request body: %v", err),
+			})
+			return
+		}
+	}
+
+	repo := repositoryService.GetRepository(repoName)
+	if repo == nil || !repositoryService.IsBranchConfigured(repo, branch) {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"status":  "failed",
+			"message": fmt.Sprintf("%s:%s is not a configured repository branch", repoName, branch),
+		})
+		return
+	}
+
+	trigger := models.DeployTrigger{Source: "manual", Actor: request.Actor}
+	jobID, err := deploymentService.Enqueue(context.Background(), *repo, branch, services.DeployOptions{Services: request.Services, Trigger: trigger})
+	if err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, services.ErrCircuitOpen) {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"status":  "failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status":     "queued",
+		"job_id":     jobID,
+		"repository": repoName,
+		"branch":     branch,
+		"timestamp":  time.Now().Unix(),
+	})
+}
+
+// handleCancelDeployment cancels the in-flight deployment of a repository branch
+func handleCancelDeployment(w http.ResponseWriter, r *http.Request) {
+	repoName, branch := routeVar(r, "repository"), routeVar(r, "branch")
+	logger.Info("Cancel request for %s:%s from %s", repoName, branch, handlers.ClientIP(r, currentConfig().Webhook.TrustedProxies))
+
+	if err := deploymentService.CancelDeployment(repoName, branch); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrNoActiveDeployment) {
+			status = http.StatusNotFound
+		}
+		writeJSON(w, status, map[string]interface{}{
+			"status":  "failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status":     "cancelling",
+		"repository": repoName,
+		"branch":     branch,
+		"timestamp":  time.Now().Unix(),
+	})
+}
+
+// handleGetJob returns the status of a deployment job started by the webhook handler or the CLI
+func handleGetJob(w http.ResponseWriter, r *http.Request) {
+	id := routeVar(r, "id")
+	job, exists := deploymentService.GetJob(id)
+	if !exists {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{
+			"status":  "failed",
+			"message": fmt.Sprintf("job %s not found", id),
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// handleResetCircuit closes the circuit breaker of a repository branch
+func handleResetCircuit(w http.ResponseWriter, r *http.Request) {
+	repoName, branch := routeVar(r, "repository"), routeVar(r, "branch")
+	logger.Info("Circuit reset request for %s:%s from %s", repoName, branch, handlers.ClientIP(r, currentConfig().Webhook.TrustedProxies))
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":     "reset",
+		"repository": repoName,
+		"branch":     branch,
+		"was_open":   deploymentService.ResetCircuit(repoName, branch),
+		"timestamp":  time.Now().Unix(),
+	})
+}
+
+// handleEvents streams deployment lifecycle events to the client as Server-Sent Events
+func handleEvents(w http.ResponseWriter, r *http.Request) {
+	clientIP := handlers.ClientIP(r, currentConfig().Webhook.TrustedProxies)
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"status":  "error",
+			"message": "streaming not supported",
+		})
+		return
+	}
+
+	events, err := deploymentService.Events().Subscribe()
+	if err != nil {
+		logger.Warning("Rejected event stream from %s: %v", clientIP, err)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status":  "unavailable",
+			"message": err.Error(),
+		})
+		return
+	}
+	defer deploymentService.Events().Unsubscribe(events)
+
+	// the stream outlives the server write timeout
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	logger.Info("Event stream opened by %s", clientIP)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			logger.Info("Event stream closed by %s", clientIP)
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Event, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// routeVar returns the unescaped value of a route variable; the router matches the escaped path so that
+// %2F in a branch name stays inside its segment
+func routeVar(r *http.Request, name string) string {
+	value := mux.Vars(r)[name]
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
+
+// writeJSON writes a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
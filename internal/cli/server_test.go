@@ -0,0 +1,181 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"uruflow.com/internal/handlers"
+	"uruflow.com/internal/models"
+	"uruflow.com/internal/services"
+	"uruflow.com/internal/utils"
+)
+
+// setupTestServer initializes the services of the server on an empty configuration with the given API token
+func setupTestServer(t *testing.T, apiToken string) {
+	t.Helper()
+	t.Setenv("URUFLOW_LOG_DIR", t.TempDir())
+	logger = utils.NewLogger("[TEST] ")
+	t.Cleanup(func() { logger.Close() })
+
+	cfg = &models.Config{
+		Settings: models.Settings{WorkDir: filepath.Join(t.TempDir(), "work"), MaxConcurrent: 1},
+		Webhook:  models.WebhookConfig{APIToken: apiToken},
+	}
+	gitService = services.NewGitService(logger)
+	dockerService = services.NewDockerService(cfg, logger)
+	repositoryService = services.NewRepositoryService(cfg, gitService, logger)
+	deploymentService = services.NewDeploymentService(cfg, repositoryService, gitService, dockerService, logger)
+	serverConfig.Store(cfg)
+	t.Cleanup(func() { serverConfig.Store(nil) })
+}
+
+// TestServerConfigReload reloads the server configuration while the status, health and token-guarded
+// endpoints serve requests; run it with go test -race.
+func TestServerConfigReload(t *testing.T) {
+	setupTestServer(t, "first")
+
+	guarded := requireAPIToken(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tokens := []string{"first", "second"}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			reloaded := *cfg
+			reloaded.Webhook.APIToken = tokens[i%2]
+			reloaded.Repositories = make([]models.Repository, i%3)
+			serverConfig.Store(&reloaded)
+		}
+	}()
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				for _, handler := range []http.HandlerFunc{handleHealth, handleStatus} {
+					rec := httptest.NewRecorder()
+					handler(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+					if rec.Code != http.StatusOK {
+						t.Errorf("status %d during reload", rec.Code)
+					}
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "/deployments/api/main/cancel", nil)
+				req.Header.Set("Authorization", "Bearer "+tokens[j%2])
+				rec := httptest.NewRecorder()
+				guarded(rec, req)
+				if rec.Code != http.StatusNoContent && rec.Code != http.StatusUnauthorized {
+					t.Errorf("token check returned %d during reload", rec.Code)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	final := *cfg
+	final.Webhook.APIToken = "second"
+	serverConfig.Store(&final)
+	req := httptest.NewRequest(http.MethodPost, "/deployments/api/main/cancel", nil)
+	req.Header.Set("Authorization", "Bearer first")
+	rec := httptest.NewRecorder()
+	guarded(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("old token accepted after reload: %d", rec.Code)
+	}
+}
+
+// TestBranchRoutes sends branch names with slashes escaped into one path segment, as the CLI does
+func TestBranchRoutes(t *testing.T) {
+	setupTestServer(t, "token")
+	webhookHandler := handlers.NewWebhookHandler(cfg, repositoryService, deploymentService, gitService, dockerService, logger)
+	teardownService := services.NewTeardownService(cfg, repositoryService, deploymentService, gitService, dockerService, logger)
+	router := setupHTTPServer(webhookHandler, teardownService).Handler
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		branch string
+	}{
+		{"plain branch", "/deployments/api/main/reset", http.StatusOK, "main"},
+		{"escaped slash", "/deployments/api/" + url.PathEscape("feature/x") + "/reset", http.StatusOK, "feature/x"},
+		{"nested slashes", "/deployments/api/" + url.PathEscape("release/2.0/rc") + "/reset", http.StatusOK, "release/2.0/rc"},
+		{"unescaped slash", "/deployments/api/feature/x/reset", http.StatusNotFound, ""},
+		{"cancel escaped slash", "/deployments/api/" + url.PathEscape("feature/x") + "/cancel", http.StatusNotFound, "feature/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer token")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("%s: status %d, want %d: %s", tt.path, rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.branch == "" {
+				return
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			// cancel reports the missing deployment, naming the branch in its message
+			if got, _ := body["branch"].(string); got != tt.branch && !strings.Contains(fmt.Sprint(body["message"]), tt.branch) {
+				t.Errorf("branch %q, want %q: %v", got, tt.branch, body)
+			}
+		})
+	}
+}
+
+func TestFailOnInitError(t *testing.T) {
+	for _, failOnInitError := range []bool{false, true} {
+		t.Run(fmt.Sprintf("fail_on_init_error %v", failOnInitError), func(t *testing.T) {
+			setupTestServer(t, "")
+			// nothing listens on port 1, so the clone fails
+			cfg.Repositories = []models.Repository{{Name: "api", GitURL: "http://127.0.0.1:1/api.git", Branches: []string{"main"}, Enabled: true}}
+			cfg.Settings.AutoClone = true
+			cfg.Settings.FailOnInitError = failOnInitError
+			repositoryService.UpdateConfig(cfg)
+
+			err := initializeRepositories()
+			if !failOnInitError {
+				if err != nil {
+					t.Fatalf("startup stopped without fail_on_init_error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), "repository initialization failed") {
+				t.Fatalf("error %v, want the initialization failure", err)
+			}
+		})
+	}
+}
@@ -43,10 +43,16 @@ var sshStatusCmd = &cobra.Command{
 }
 
 var sshTestCmd = &cobra.Command{
-	Use:   "test",
+	Use:   "test [host]",
 	Short: "🔗 Test SSH connection",
-	Long:  `Test SSH connection to GitHub.`,
-	Run:   testSSH,
+	Long: `Test SSH connection to GitHub, or to another Git host or ssh_hosts alias
+using the configured port, user and identity file.
+
+Examples:
+	uruflow ssh test
+	uruflow ssh test gitlab.internal`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: testSSH,
 }
 
 var sshSetupCmd = &cobra.Command{
@@ -107,140 +113,153 @@ func findExistingSSHKey() (string, bool) {
 
 // showSSHStatus displays SSH configuration status using clean Go APIs
 func showSSHStatus(cmd *cobra.Command, args []string) {
-	fmt.Printf("🔐 SSH Status\n")
-	fmt.Printf("===============\n\n")
+	fmt.Fprintf(out, "🔐 SSH Status\n")
+	fmt.Fprintf(out, "===============\n\n")
 
 	if gitService.IsSSHAvailable() {
-		fmt.Printf("✅ SSH is configured and ready\n")
+		fmt.Fprintf(out, "✅ SSH is configured and ready\n")
 
 		if err := gitService.TestSSHConnection(); err != nil {
-			fmt.Printf("❌ SSH configured but connection test failed: %v\n", err)
+			fmt.Fprintf(out, "❌ SSH configured but connection test failed: %v\n", err)
 		} else {
-			fmt.Printf("✅ GitHub connection test passed\n")
+			fmt.Fprintf(out, "✅ GitHub connection test passed\n")
 		}
 	} else {
-		fmt.Printf("❌ SSH is not configured\n")
-		fmt.Printf("\n💡 To set up SSH:\n")
-		fmt.Printf("uruflow ssh setup\n")
+		fmt.Fprintf(out, "❌ SSH is not configured\n")
+		fmt.Fprintf(out, "\n💡 To set up SSH:\n")
+		fmt.Fprintf(out, "uruflow ssh setup\n")
 	}
 
-	fmt.Printf("\n📋 SSH Configuration:\n")
+	fmt.Fprintf(out, "\n📋 SSH Configuration:\n")
 
 	sshDir, err := getSSHDirectory()
 	if err != nil {
-		fmt.Printf("❌ Cannot determine SSH directory: %v\n", err)
+		fmt.Fprintf(out, "❌ Cannot determine SSH directory: %v\n", err)
 		return
 	}
 
-	fmt.Printf("📁 SSH Directory: %s\n", sshDir)
+	fmt.Fprintf(out, "📁 SSH Directory: %s\n", sshDir)
 
 	if fileExists(sshDir) {
-		fmt.Printf("✅ SSH Directory: Exists\n")
+		fmt.Fprintf(out, "✅ SSH Directory: Exists\n")
 	} else {
-		fmt.Printf("❌ SSH Directory: Not found\n")
+		fmt.Fprintf(out, "❌ SSH Directory: Not found\n")
 		return
 	}
 
 	if keyPath, found := findExistingSSHKey(); found {
 		keyName := filepath.Base(keyPath)
-		fmt.Printf("🔑 SSH Key (%s): Found\n", keyName)
+		fmt.Fprintf(out, "🔑 SSH Key (%s): Found\n", keyName)
 
 		pubKeyPath := keyPath + ".pub"
 		if fileExists(pubKeyPath) {
-			fmt.Printf("🔓 Public Key (%s.pub): Found\n", keyName)
+			fmt.Fprintf(out, "🔓 Public Key (%s.pub): Found\n", keyName)
 		} else {
-			fmt.Printf("❌ Public Key (%s.pub): Missing\n", keyName)
+			fmt.Fprintf(out, "❌ Public Key (%s.pub): Missing\n", keyName)
 		}
 	} else {
-		fmt.Printf("❌ SSH Keys: No keys found\n")
+		fmt.Fprintf(out, "❌ SSH Keys: No keys found\n")
 	}
-	fmt.Printf("💻 Platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(out, "💻 Platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
 }
 
-// testSSH tests SSH connection to GitHub
-func testSSH(cmd *cobra.Command, args []string) {
-	fmt.Printf("🔗 Testing SSH connection to GitHub...\n\n")
+// testSSH tests SSH connection to GitHub or the given host
+func testSSH(cmd *cobra.Command, args []string) error {
+	host := ""
+	if len(args) == 1 {
+		host = args[0]
+	}
+	target := "GitHub"
+	if host != "" {
+		target = host
+	}
+	fmt.Fprintf(out, "🔗 Testing SSH connection to %s...\n\n", target)
 
 	if !gitService.IsSSHAvailable() {
-		fmt.Printf("❌ SSH is not configured\n")
-		fmt.Printf("💡 Run 'uruflow ssh setup' for setup instructions\n")
-		return
+		fmt.Fprintf(out, "❌ SSH is not configured\n")
+		fmt.Fprintf(out, "💡 Run 'uruflow ssh setup' for setup instructions\n")
+		return fmt.Errorf("SSH is not configured")
 	}
 	logger.Info("Testing SSH connection...")
-	if err := gitService.TestSSHConnection(); err != nil {
+	test := gitService.TestSSHConnection
+	if host != "" {
+		test = func() error { return gitService.TestSSHHost(host) }
+	}
+	if err := test(); err != nil {
 		logger.Error("SSH connection test failed: %v", err)
-		fmt.Printf("❌ SSH connection test failed: %v\n", err)
-		return
+		fmt.Fprintf(out, "❌ SSH connection test failed: %v\n", err)
+		return fmt.Errorf("SSH connection test failed: %v", err)
 	}
 
 	logger.Success("SSH connection test passed")
-	fmt.Printf("✅ SSH connection to GitHub successful!\n")
+	fmt.Fprintf(out, "✅ SSH connection to %s successful!\n", target)
+	return nil
 }
 
 // showSSHSetup displays platform-aware SSH setup instructions
 func showSSHSetup(cmd *cobra.Command, args []string) {
-	fmt.Printf("📝 SSH Setup Instructions\n")
-	fmt.Printf("=========================\n\n")
+	fmt.Fprintf(out, "📝 SSH Setup Instructions\n")
+	fmt.Fprintf(out, "=========================\n\n")
 
 	// Get SSH directory for platform-specific paths
 	sshDir, err := getSSHDirectory()
 	if err != nil {
-		fmt.Printf("❌ Cannot determine SSH directory: %v\n", err)
+		fmt.Fprintf(out, "❌ Cannot determine SSH directory: %v\n", err)
 		return
 	}
 
-	fmt.Printf("1️⃣ Generate SSH Key:\n")
+	fmt.Fprintf(out, "1️⃣ Generate SSH Key:\n")
 	if runtime.GOOS == "windows" {
-		fmt.Printf("ssh-keygen -t ed25519 -C \"your-email@example.com\" -f \"%s\\id_ed25519\"\n", sshDir)
+		fmt.Fprintf(out, "ssh-keygen -t ed25519 -C \"your-email@example.com\" -f \"%s\\id_ed25519\"\n", sshDir)
 	} else {
-		fmt.Printf("ssh-keygen -t ed25519 -C \"your-email@example.com\" -f \"%s/id_ed25519\"\n", sshDir)
+		fmt.Fprintf(out, "ssh-keygen -t ed25519 -C \"your-email@example.com\" -f \"%s/id_ed25519\"\n", sshDir)
 	}
-	fmt.Printf("(Press Enter for no passphrase, or set one if you prefer)\n\n")
+	fmt.Fprintf(out, "(Press Enter for no passphrase, or set one if you prefer)\n\n")
 
-	fmt.Printf("2️⃣ Copy Public Key:\n")
+	fmt.Fprintf(out, "2️⃣ Copy Public Key:\n")
 	pubKeyPath := filepath.Join(sshDir, "id_ed25519.pub")
 	if runtime.GOOS == "windows" {
-		fmt.Printf("   type \"%s\"\n", pubKeyPath)
+		fmt.Fprintf(out, "   type \"%s\"\n", pubKeyPath)
 	} else {
-		fmt.Printf("   cat \"%s\"\n", pubKeyPath)
+		fmt.Fprintf(out, "   cat \"%s\"\n", pubKeyPath)
 	}
-	fmt.Printf("   (Copy the entire output)\n\n")
+	fmt.Fprintf(out, "   (Copy the entire output)\n\n")
 
-	fmt.Printf("3️⃣ Add to GitHub:\n")
-	fmt.Printf("   • Go to: https://github.com/settings/ssh/new\n")
-	fmt.Printf("   • Paste your public key\n")
-	fmt.Printf("   • Give it a title (e.g., 'UruFlow Server')\n")
-	fmt.Printf("   • Click 'Add SSH key'\n\n")
+	fmt.Fprintf(out, "3️⃣ Add to GitHub:\n")
+	fmt.Fprintf(out, "   • Go to: https://github.com/settings/ssh/new\n")
+	fmt.Fprintf(out, "   • Paste your public key\n")
+	fmt.Fprintf(out, "   • Give it a title (e.g., 'UruFlow Server')\n")
+	fmt.Fprintf(out, "   • Click 'Add SSH key'\n\n")
 
-	fmt.Printf("4️⃣ Test Connection:\n")
-	fmt.Printf("   uruflow ssh test\n\n")
+	fmt.Fprintf(out, "4️⃣ Test Connection:\n")
+	fmt.Fprintf(out, "   uruflow ssh test\n\n")
 
-	fmt.Printf("📊 Current Status:\n")
+	fmt.Fprintf(out, "📊 Current Status:\n")
 
 	// Check current SSH status
 	if fileExists(sshDir) {
-		fmt.Printf("   📁 SSH Directory: %s\n", sshDir)
+		fmt.Fprintf(out, "   📁 SSH Directory: %s\n", sshDir)
 
 		if keyPath, found := findExistingSSHKey(); found {
 			keyName := filepath.Base(keyPath)
-			fmt.Printf("   🔑 Existing Key: %s\n", keyName)
+			fmt.Fprintf(out, "   🔑 Existing Key: %s\n", keyName)
 
 			pubKeyPath := keyPath + ".pub"
 			if fileExists(pubKeyPath) {
-				fmt.Printf("   🔓 Public Key: %s\n", pubKeyPath)
-				fmt.Printf("   \n💡 You can copy your public key with:\n")
+				fmt.Fprintf(out, "   🔓 Public Key: %s\n", pubKeyPath)
+				fmt.Fprintf(out, "   \n💡 You can copy your public key with:\n")
 				if runtime.GOOS == "windows" {
-					fmt.Printf("      type \"%s\"\n", pubKeyPath)
+					fmt.Fprintf(out, "      type \"%s\"\n", pubKeyPath)
 				} else {
-					fmt.Printf("      cat \"%s\"\n", pubKeyPath)
+					fmt.Fprintf(out, "      cat \"%s\"\n", pubKeyPath)
 				}
 			} else {
-				fmt.Printf("   ❌ Public Key: Missing %s\n", pubKeyPath)
+				fmt.Fprintf(out, "   ❌ Public Key: Missing %s\n", pubKeyPath)
 			}
 		} else {
-			fmt.Printf("   ❌ SSH Keys: No keys found in %s\n", sshDir)
+			fmt.Fprintf(out, "   ❌ SSH Keys: No keys found in %s\n", sshDir)
 		}
 	} else {
-		fmt.Printf("   📁 SSH Directory: %s (will be created)\n", sshDir)
+		fmt.Fprintf(out, "   📁 SSH Directory: %s (will be created)\n", sshDir)
 	}
 }
@@ -23,8 +23,11 @@ package cli
 // Update: clean the status, remove unwanted process, Add emoji for status cli to make it modern
 
 import (
+	"encoding/json"
 	"fmt"
-	"strings"
+	"os"
+	"sort"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -32,19 +35,47 @@ import (
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "📊 Show system status",
-	Long:  `Show current deployment status and running containers.`,
-	Run:   showStatus,
+	Long: `Show current deployment status and running containers.
+
+Examples:
+	uruflow status
+	uruflow status --json | jq .repositories`,
+	RunE: showStatus,
+}
+
+// statusContainer is a container reported by status --json
+type statusContainer struct {
+	Name   string `json:"name"`
+	State  string `json:"state"`
+	Status string `json:"status"`
+	Image  string `json:"image"`
+	Ports  string `json:"ports,omitempty"`
+}
+
+// statusReport is the machine-readable form of the status command
+type statusReport struct {
+	Timestamp    int64                  `json:"timestamp"`
+	ActiveJobs   []string               `json:"active_jobs"`
+	Stats        map[string]interface{} `json:"stats"`
+	Repositories map[string]interface{} `json:"repositories"`
+	Containers   []statusContainer      `json:"containers"`
+	DockerError  string                 `json:"docker_error,omitempty"`
 }
 
 // Initialize status command
 func init() {
 	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().Bool("json", false, "Print active jobs, repository state, containers and stats as one JSON document")
 }
 
 // Show system status - simple and focused
-func showStatus(cmd *cobra.Command, args []string) {
-	fmt.Printf("📊 UruFlow Status\n")
-	fmt.Printf("==================\n\n")
+func showStatus(cmd *cobra.Command, args []string) error {
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		return showStatusJSON()
+	}
+
+	fmt.Fprintf(out, "📊 UruFlow Status\n")
+	fmt.Fprintf(out, "==================\n\n")
 
 	// Show active deployments (most important info)
 	showActiveDeployments()
@@ -54,6 +85,45 @@ func showStatus(cmd *cobra.Command, args []string) {
 
 	// Show quick repository summary
 	showRepositorySummary()
+	return nil
+}
+
+// showStatusJSON prints the status overview as a single JSON document for monitoring
+func showStatusJSON() error {
+	activeJobs := deploymentService.GetActiveJobs()
+	sort.Strings(activeJobs)
+
+	report := statusReport{
+		Timestamp:    time.Now().Unix(),
+		ActiveJobs:   activeJobs,
+		Stats:        deploymentService.GetDeploymentStats(),
+		Repositories: repositoryService.GetDeploymentState(),
+		Containers:   []statusContainer{},
+	}
+
+	// docker being unavailable is part of the status, not a failure of the command
+	containers, err := dockerService.GetStatusJSON()
+	if err != nil {
+		report.DockerError = err.Error()
+	}
+	for _, container := range containers {
+		report.Containers = append(report.Containers, statusContainer{
+			Name:   container.Name,
+			State:  container.State,
+			Status: container.Status,
+			Image:  container.Image,
+			Ports:  container.Ports,
+		})
+	}
+
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to encode status: %v\n", err)
+		return fmt.Errorf("failed to encode status: %v", err)
+	}
+	// bypass the plain output writer, which would drop non-ASCII characters from the document
+	fmt.Fprintln(os.Stdout, string(output))
+	return nil
 }
 
 // Show active deployments - the most critical information
@@ -61,57 +131,46 @@ func showActiveDeployments() {
 	activeJobs := deploymentService.GetActiveJobs()
 
 	if len(activeJobs) > 0 {
-		fmt.Printf("⚡ Active Deployments:\n")
+		fmt.Fprintf(out, "⚡ Active Deployments:\n")
 		for _, job := range activeJobs {
-			fmt.Printf("   🔄 %s\n", job)
+			fmt.Fprintf(out, "   🔄 %s\n", job)
 		}
-		fmt.Printf("\n")
+		fmt.Fprintf(out, "\n")
 	} else {
-		fmt.Printf("✅ No active deployments\n\n")
+		fmt.Fprintf(out, "✅ No active deployments\n\n")
 	}
 }
 
 // Show running containers - what's actually deployed and working
 func showRunningContainers() {
-	fmt.Printf("🐳 Running Containers:\n")
+	fmt.Fprintf(out, "🐳 Running Containers:\n")
 
-	status, err := dockerService.GetStatusOutput()
+	containers, err := dockerService.GetStatusJSON()
 	if err != nil {
-		fmt.Printf("   ❌ Docker not available: %v\n\n", err)
+		fmt.Fprintf(out, "   ❌ Docker not available: %v\n\n", err)
 		return
 	}
 
-	if status == "" {
-		fmt.Printf("   🔴 No containers running\n\n")
+	if len(containers) == 0 {
+		fmt.Fprintf(out, "   🔴 No containers running\n\n")
 		return
 	}
 
-	// Parse and show only running containers with clean formatting
-	lines := strings.Split(status, "\n")
 	runningCount := 0
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" && !strings.Contains(line, "CONTAINER ID") && !strings.Contains(line, "NAMES") {
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				containerName := parts[len(parts)-1] // Last field is the name
-				status := "🟢 Running"
-				if !strings.Contains(line, "Up") {
-					status = "🔴 Stopped"
-				}
-				fmt.Printf("   %s %s\n", status, containerName)
-				if strings.Contains(line, "Up") {
-					runningCount++
-				}
-			}
+	for _, container := range containers {
+		status := "🟢 Running"
+		if container.State != "running" {
+			status = "🔴 " + container.State
+		} else {
+			runningCount++
 		}
+		fmt.Fprintf(out, "   %s %s\n", status, container.Name)
 	}
 
 	if runningCount > 0 {
-		fmt.Printf("   📈 Total running: %d\n\n", runningCount)
+		fmt.Fprintf(out, "   📈 Total running: %d\n\n", runningCount)
 	} else {
-		fmt.Printf("   🔴 No containers running\n\n")
+		fmt.Fprintf(out, "   🔴 No containers running\n\n")
 	}
 }
 
@@ -120,11 +179,11 @@ func showRepositorySummary() {
 	repos := repositoryService.ListRepositories()
 
 	if len(repos) == 0 {
-		fmt.Printf("📁 Repositories: None configured\n")
+		fmt.Fprintf(out, "📁 Repositories: None configured\n")
 		return
 	}
 
-	fmt.Printf("📁 Repositories: %d configured\n", len(repos))
+	fmt.Fprintf(out, "📁 Repositories: %d configured\n", len(repos))
 	info := repositoryService.GetRepositoryInfo()
 	hasIssues := false
 	for _, repo := range repos {
@@ -134,11 +193,11 @@ func showRepositorySummary() {
 					for branch, branchStatus := range status {
 						if branchStatus != "ready" {
 							if !hasIssues {
-								fmt.Printf("   ⚠️ Issues found:\n")
+								fmt.Fprintf(out, "   ⚠️ Issues found:\n")
 								hasIssues = true
 							}
 							statusEmoji := getStatusEmoji(branchStatus)
-							fmt.Printf("      %s %s:%s (%s)\n", statusEmoji, repo.Name, branch, branchStatus)
+							fmt.Fprintf(out, "      %s %s:%s (%s)\n", statusEmoji, repo.Name, branch, branchStatus)
 						}
 					}
 				}
@@ -147,6 +206,6 @@ func showRepositorySummary() {
 	}
 
 	if !hasIssues {
-		fmt.Printf("   ✅ All repositories ready\n")
+		fmt.Fprintf(out, "   ✅ All repositories ready\n")
 	}
 }
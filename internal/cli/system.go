@@ -29,6 +29,7 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"uruflow.com/internal/services"
 )
 
 var systemCmd = &cobra.Command{
@@ -50,8 +51,8 @@ func init() {
 }
 
 func runSystemCheck(cmd *cobra.Command, args []string) {
-	fmt.Printf("🔧 Uruflow System Diagnostics\n")
-	fmt.Printf("===============================\n\n")
+	fmt.Fprintf(out, "🔧 Uruflow System Diagnostics\n")
+	fmt.Fprintf(out, "===============================\n\n")
 
 	checkCurrentUser()
 	checkDockerAccess()
@@ -61,122 +62,129 @@ func runSystemCheck(cmd *cobra.Command, args []string) {
 }
 
 func checkCurrentUser() {
-	fmt.Printf("👤 User Information:\n")
+	fmt.Fprintf(out, "👤 User Information:\n")
 
 	currentUser, err := user.Current()
 	if err != nil {
-		fmt.Printf("   ❌ Could not get current user: %v\n", err)
+		fmt.Fprintf(out, "   ❌ Could not get current user: %v\n", err)
 		return
 	}
 
-	fmt.Printf("   📝 Username: %s\n", currentUser.Username)
-	fmt.Printf("   🆔 UID: %s\n", currentUser.Uid)
-	fmt.Printf("   🏠 Home: %s\n", currentUser.HomeDir)
+	fmt.Fprintf(out, "   📝 Username: %s\n", currentUser.Username)
+	fmt.Fprintf(out, "   🆔 UID: %s\n", currentUser.Uid)
+	fmt.Fprintf(out, "   🏠 Home: %s\n", currentUser.HomeDir)
 
 	uid, _ := strconv.Atoi(currentUser.Uid)
 	if uid == 0 {
-		fmt.Printf("   🔴 Running as ROOT user\n")
+		fmt.Fprintf(out, "   🔴 Running as ROOT user\n")
 	} else {
-		fmt.Printf("   🟢 Running as non-root user\n")
+		fmt.Fprintf(out, "   🟢 Running as non-root user\n")
 	}
 
 	cmd := exec.Command("groups")
 	if output, err := cmd.Output(); err == nil {
 		groups := strings.TrimSpace(string(output))
-		fmt.Printf("   ⚙️ Groups: %s\n", groups)
+		fmt.Fprintf(out, "   ⚙️ Groups: %s\n", groups)
 
 		if strings.Contains(groups, "docker") {
-			fmt.Printf("   🟢 User is in docker group\n")
+			fmt.Fprintf(out, "   🟢 User is in docker group\n")
 		} else {
-			fmt.Printf("   🔴 User is NOT in docker group\n")
+			fmt.Fprintf(out, "   🔴 User is NOT in docker group\n")
 		}
 	} else {
-		fmt.Printf("   ❌ Could not check groups: %v\n", err)
+		fmt.Fprintf(out, "   ❌ Could not check groups: %v\n", err)
 	}
 
-	fmt.Printf("\n")
+	fmt.Fprintf(out, "\n")
 }
 
 func checkDockerAccess() {
-	fmt.Printf("📦 Docker Access:\n")
+	fmt.Fprintf(out, "📦 Docker Access:\n")
 
 	if _, err := exec.LookPath("docker"); err != nil {
-		fmt.Printf("   ❌ Docker command not found\n")
-		fmt.Printf("\n")
+		fmt.Fprintf(out, "   ❌ Docker command not found\n")
+		fmt.Fprintf(out, "\n")
 		return
 	}
 
 	cmd := exec.Command("docker", "version", "--format", "{{.Server.Version}}")
 	if output, err := cmd.Output(); err != nil {
-		fmt.Printf("   ❌ Cannot access Docker daemon: %v\n", err)
+		fmt.Fprintf(out, "   ❌ Cannot access Docker daemon: %v\n", err)
 	} else {
 		version := strings.TrimSpace(string(output))
-		fmt.Printf("   🟢 Docker access OK (Server: %s)\n", version)
+		fmt.Fprintf(out, "   🟢 Docker access OK (Server: %s)\n", version)
 	}
 	cmd = exec.Command("docker", "compose", "version", "--short")
 	if output, err := cmd.Output(); err != nil {
 		cmd = exec.Command("docker-compose", "version", "--short")
 		if output, err := cmd.Output(); err != nil {
-			fmt.Printf("   🔴 Docker Compose not available: %v\n", err)
+			fmt.Fprintf(out, "   🔴 Docker Compose not available: %v\n", err)
 		} else {
 			version := strings.TrimSpace(string(output))
-			fmt.Printf("   \033[33m🟠 docker-compose found (%s) - not recommended\033[0m\n", version)
-			fmt.Printf("   💡 Consider upgrading to 'docker compose' plugin\n")
+			fmt.Fprintf(out, "   \033[33m🟠 docker-compose found (%s) - not recommended\033[0m\n", version)
+			fmt.Fprintf(out, "   💡 Consider upgrading to 'docker compose' plugin\n")
 		}
 	} else {
 		version := strings.TrimSpace(string(output))
-		fmt.Printf("   🟢 Docker Compose available (%s)\n", version)
+		fmt.Fprintf(out, "   🟢 Docker Compose available (%s)\n", version)
 	}
 
-	fmt.Printf("\n")
+	fmt.Fprintf(out, "\n")
 }
 
 // Check git Configurations
 func checkGitConfiguration() {
-	fmt.Printf("🔧 Git Configuration:\n")
-	cmd := exec.Command("git", "config", "--global", "--get-all", "safe.directory")
-	if output, err := cmd.Output(); err != nil {
-		fmt.Printf("   🔴 No safe directories configured\n")
-	} else {
-		dirs := strings.Split(strings.TrimSpace(string(output)), "\n")
-		fmt.Printf("   🟢 Safe directories configured (%d):\n", len(dirs))
+	fmt.Fprintf(out, "🔧 Git Configuration:\n")
+
+	path, err := services.CheckGitBinary()
+	if err != nil {
+		fmt.Fprintf(out, "   ❌ Git command not found\n")
+		fmt.Fprintf(out, "   💡 %v\n", err)
+		fmt.Fprintf(out, "\n")
+		return
+	}
+	if output, err := exec.Command(path, "--version").Output(); err == nil {
+		fmt.Fprintf(out, "   🟢 %s (%s)\n", strings.TrimSpace(string(output)), path)
 	}
-	fmt.Printf("\n")
+
+	// the git service passes safe.directory to every git command it runs, so none is needed in the global config
+	fmt.Fprintf(out, "   🟢 Repositories are trusted per git command, the global git config is not changed\n")
+	fmt.Fprintf(out, "\n")
 }
 
 func checkWorkDirectoryPermissions() {
-	fmt.Printf("📁 Work Directory Permissions:\n")
+	fmt.Fprintf(out, "📁 Work Directory Permissions:\n")
 
 	workDir := cfg.Settings.WorkDir
-	fmt.Printf("   📂 Work directory: %s\n", workDir)
+	fmt.Fprintf(out, "   📂 Work directory: %s\n", workDir)
 
 	if info, err := os.Stat(workDir); err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("   ❌ Work directory does not exist\n")
+			fmt.Fprintf(out, "   ❌ Work directory does not exist\n")
 		} else {
-			fmt.Printf("   ❌ Cannot access work directory: %v\n", err)
+			fmt.Fprintf(out, "   ❌ Cannot access work directory: %v\n", err)
 		}
 	} else {
-		fmt.Printf("   🟢 Work directory exists\n")
-		fmt.Printf("   🔐 Permissions: %s\n", info.Mode().String())
+		fmt.Fprintf(out, "   🟢 Work directory exists\n")
+		fmt.Fprintf(out, "   🔐 Permissions: %s\n", info.Mode().String())
 	}
-	fmt.Printf("\n")
+	fmt.Fprintf(out, "\n")
 }
 
 func checkSSHSetup() {
-	fmt.Printf("🔐 SSH Configuration:\n")
+	fmt.Fprintf(out, "🔐 SSH Configuration:\n")
 
 	if gitService.IsSSHAvailable() {
-		fmt.Printf("   🟢 SSH service is available\n")
+		fmt.Fprintf(out, "   🟢 SSH service is available\n")
 
 		if err := gitService.TestSSHConnection(); err != nil {
-			fmt.Printf("   🔴 SSH connection test failed: %v\n", err)
+			fmt.Fprintf(out, "   🔴 SSH connection test failed: %v\n", err)
 		} else {
-			fmt.Printf("   🟢 SSH connection test passed\n")
+			fmt.Fprintf(out, "   🟢 SSH connection test passed\n")
 		}
 	} else {
-		fmt.Printf("   ❌ SSH service not configured\n")
-		fmt.Printf("   💡 Try: uruflow ssh setup\n")
+		fmt.Fprintf(out, "   ❌ SSH service not configured\n")
+		fmt.Fprintf(out, "   💡 Try: uruflow ssh setup\n")
 	}
-	fmt.Printf("\n")
+	fmt.Fprintf(out, "\n")
 }
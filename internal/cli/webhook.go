@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package cli
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "🪝 Webhook utilities",
+	Long:  `Utilities for working with the webhook endpoint.`,
+}
+
+var webhookTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "🧪 Send a sample webhook to the local server",
+	Long: `Replay a saved webhook payload against the running UruFlow server.
+The payload is signed the same way GitHub/GitLab would sign it and goes through
+the full validation, parsing and deployment path.
+
+Examples:
+	uruflow webhook test --file examples/github-push.json
+	uruflow webhook test --file examples/gitlab-push.json --provider gitlab
+	uruflow webhook test --file examples/github-pull-request.json --event merge`,
+	RunE: runWebhookTest,
+}
+
+func init() {
+	rootCmd.AddCommand(webhookCmd)
+	webhookCmd.AddCommand(webhookTestCmd)
+	webhookTestCmd.Flags().StringP("file", "f", "", "Path to the JSON payload file")
+	webhookTestCmd.Flags().StringP("secret", "s", "", "Secret used to sign the payload (defaults to the configured secret)")
+	webhookTestCmd.Flags().String("provider", "github", "Webhook provider to emulate (github|gitlab)")
+	webhookTestCmd.Flags().String("path", "", "Webhook path to post to (defaults to webhook.path)")
+	webhookTestCmd.Flags().String("event", "push", "Event type to emulate (push|merge)")
+	webhookTestCmd.MarkFlagRequired("file")
+}
+
+// runWebhookTest signs a payload file and posts it to the local webhook endpoint
+func runWebhookTest(cmd *cobra.Command, args []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	secret, _ := cmd.Flags().GetString("secret")
+	provider, _ := cmd.Flags().GetString("provider")
+	path, _ := cmd.Flags().GetString("path")
+	event, _ := cmd.Flags().GetString("event")
+
+	if secret == "" {
+		secret = cfg.Webhook.Secret
+	}
+
+	payload, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(out, "❌ Failed to read payload: %v\n", err)
+		return err
+	}
+	if !json.Valid(payload) {
+		fmt.Fprintf(out, "❌ Payload is not valid JSON: %s\n", file)
+		return fmt.Errorf("payload is not valid JSON: %s", file)
+	}
+
+	if path == "" {
+		path = cfg.Webhook.Path
+	}
+	url := localServerURL(path)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		fmt.Fprintf(out, "❌ Failed to create request: %v\n", err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if event != "push" && event != "merge" {
+		fmt.Fprintf(out, "❌ Unknown event '%s' (expected push or merge)\n", event)
+		return fmt.Errorf("unknown event '%s'", event)
+	}
+
+	switch provider {
+	case "github":
+		if event == "merge" {
+			req.Header.Set("X-GitHub-Event", "pull_request")
+		} else {
+			req.Header.Set("X-GitHub-Event", "push")
+		}
+		if secret != "" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(payload)
+			req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+	case "gitlab":
+		if event == "merge" {
+			req.Header.Set("X-Gitlab-Event", "Merge Request Hook")
+		} else {
+			req.Header.Set("X-Gitlab-Event", "Push Hook")
+		}
+		if secret != "" {
+			req.Header.Set("X-Gitlab-Token", secret)
+		}
+	default:
+		fmt.Fprintf(out, "❌ Unknown provider '%s' (expected github or gitlab)\n", provider)
+		return fmt.Errorf("unknown provider '%s'", provider)
+	}
+
+	fmt.Fprintf(out, "🧪 Sending %s %s payload %s to %s\n", provider, event, file, url)
+	if secret == "" {
+		fmt.Fprintf(out, "⚠️ No secret configured, sending unsigned request\n")
+	}
+
+	client := &http.Client{Timeout: 20 * time.Minute}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(out, "❌ Request failed: %v\n", err)
+		fmt.Fprintf(out, "💡 Is the server running? Start it with 'uruflow server'\n")
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	fmt.Fprintf(out, "📬 Response: %s\n\n", resp.Status)
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "", "  "); err == nil {
+		fmt.Fprintf(out, "%s\n", pretty.String())
+	} else {
+		fmt.Fprintf(out, "%s\n", string(body))
+	}
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// localServerURL returns the URL of an endpoint on the locally running server
+func localServerURL(path string) string {
+	return fmt.Sprintf("http://127.0.0.1:%s%s", cfg.Webhook.Port, path)
+}
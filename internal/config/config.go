@@ -22,60 +22,234 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"gopkg.in/yaml.v3"
 	"uruflow.com/env_manager"
+	"uruflow.com/helper"
 	"uruflow.com/internal/models"
+	"uruflow.com/internal/services"
 )
 
-// Load and reads the configuration file using envManager
-func Load(envManager *env_manager.EnvManager) (*models.Config, error) {
-	configPath := filepath.Join(envManager.ConfigDir, "config.json")
+// repositoryFilePatterns match the files of repositories.d
+var repositoryFilePatterns = []string{"*.json", "*.yaml", "*.yml"}
+
+// maxRemoteConfigSize caps the size of a configuration read from stdin or URUFLOW_CONFIG_URL
+const maxRemoteConfigSize = 10 << 20
 
-	file, err := os.ReadFile(configPath)
+// Load and reads the configuration using envManager: config.json and repositories.d from the config dir,
+// stdin when URUFLOW_CONFIG_DIR is "-", or URUFLOW_CONFIG_URL when it is set
+func Load(envManager *env_manager.EnvManager) (*models.Config, error) {
+	var file []byte
+	var err error
+	switch {
+	case envManager.ConfigURL != "":
+		file, err = fetchConfig(envManager.ConfigURL)
+	case envManager.ConfigDir == "-":
+		file, err = io.ReadAll(io.LimitReader(os.Stdin, maxRemoteConfigSize))
+	default:
+		file, err = os.ReadFile(GetConfigPath(envManager))
+	}
 	if err != nil {
 		return nil, err
 	}
+	return parse(envManager, file)
+}
 
+// parse decodes a JSON or YAML configuration, merges repositories.d and applies defaults and validation
+func parse(envManager *env_manager.EnvManager, file []byte) (*models.Config, error) {
 	var config models.Config
-	if err := json.Unmarshal(file, &config); err != nil {
-		return nil, err
+	if err := unmarshal(file, &config); err != nil {
+		return nil, fmt.Errorf("invalid configuration from %s: %v", ConfigSource(envManager), err)
+	}
+	if ReloadSupported(envManager) {
+		if err := loadRepositoriesDir(GetRepositoriesDir(envManager), &config); err != nil {
+			return nil, err
+		}
 	}
 	setDefaults(&config)
+	if err := validate(&config); err != nil {
+		return nil, err
+	}
 	return &config, nil
 }
 
+// unmarshal decodes JSON, or YAML when data does not start with a JSON object. YAML is converted to JSON
+// first so both formats share the json keys and the errors for unknown types.
+func unmarshal(data []byte, v interface{}) error {
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		return json.Unmarshal(data, v)
+	}
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	converted, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("unsupported YAML: %v", err)
+	}
+	return json.Unmarshal(converted, v)
+}
+
+// WebhookPaths returns every path the webhook handler is served on: the default path followed by any extra paths
+func WebhookPaths(config *models.Config) []string {
+	paths := []string{config.Webhook.Path}
+	seen := map[string]bool{config.Webhook.Path: true}
+	for _, path := range config.Webhook.Paths {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// fetchConfig downloads the configuration from an http(s) URL
+func fetchConfig(rawURL string) ([]byte, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid URUFLOW_CONFIG_URL: must be an http or https URL")
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch configuration from %s: %v", parsed.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch configuration from %s: %s", parsed.Redacted(), resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxRemoteConfigSize))
+}
+
+// ReloadSupported reports whether the configuration comes from files that can be watched and reloaded;
+// configuration read from stdin or URUFLOW_CONFIG_URL is loaded once at startup
+func ReloadSupported(envManager *env_manager.EnvManager) bool {
+	return envManager.ConfigURL == "" && envManager.ConfigDir != "-"
+}
+
+// ConfigSource describes where the configuration is read from, for logs
+func ConfigSource(envManager *env_manager.EnvManager) string {
+	switch {
+	case envManager.ConfigURL != "":
+		if parsed, err := url.Parse(envManager.ConfigURL); err == nil {
+			return parsed.Redacted()
+		}
+		return "URUFLOW_CONFIG_URL"
+	case envManager.ConfigDir == "-":
+		return "stdin"
+	default:
+		return GetConfigPath(envManager)
+	}
+}
+
 // GetConfigPath returns the configuration file path using envManager
 func GetConfigPath(envManager *env_manager.EnvManager) string {
 	return filepath.Join(envManager.ConfigDir, "config.json")
 }
 
-func WatchConfig(envManager *env_manager.EnvManager, callback func(*models.Config)) {
-	configPath := filepath.Join(envManager.ConfigDir, "config.json")
-	var lastModTime time.Time
+// GetRepositoriesDir returns the directory holding one JSON or YAML file per repository
+func GetRepositoriesDir(envManager *env_manager.EnvManager) string {
+	return filepath.Join(envManager.ConfigDir, "repositories.d")
+}
+
+// repositoryFiles lists the JSON and YAML files of dir in name order
+func repositoryFiles(dir string) ([]string, error) {
+	var files []string
+	for _, pattern := range repositoryFilePatterns {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadRepositoriesDir appends the repositories defined in dir (one *.json, *.yaml or *.yml file each) to the config.
+// A missing directory is not an error; duplicate names across config.json and the directory are.
+func loadRepositoriesDir(dir string, config *models.Config) error {
+	files, err := repositoryFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	sources := make(map[string]string)
+	for _, repo := range config.Repositories {
+		if _, exists := sources[repo.Name]; exists {
+			return fmt.Errorf("duplicate repository %q in config.json", repo.Name)
+		}
+		sources[repo.Name] = "config.json"
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		var repo models.Repository
+		if err := unmarshal(data, &repo); err != nil {
+			return fmt.Errorf("invalid repository file %s: %v", file, err)
+		}
+		if repo.Name == "" {
+			return fmt.Errorf("invalid repository file %s: missing name", file)
+		}
+		if previous, exists := sources[repo.Name]; exists {
+			return fmt.Errorf("duplicate repository %q in %s and %s", repo.Name, previous, filepath.Base(file))
+		}
+		sources[repo.Name] = filepath.Base(file)
+		config.Repositories = append(config.Repositories, repo)
+	}
+	return nil
+}
 
-	if fileInfo, err := os.Stat(configPath); err == nil {
-		lastModTime = fileInfo.ModTime()
+// configStamp fingerprints config.json and the repositories.d files so that edits, additions and removals are noticed
+func configStamp(envManager *env_manager.EnvManager) string {
+	var stamp strings.Builder
+	if fileInfo, err := os.Stat(GetConfigPath(envManager)); err == nil {
+		fmt.Fprintf(&stamp, "config.json:%d;", fileInfo.ModTime().UnixNano())
 	}
 
+	files, _ := repositoryFiles(GetRepositoriesDir(envManager))
+	for _, file := range files {
+		if fileInfo, err := os.Stat(file); err == nil {
+			fmt.Fprintf(&stamp, "%s:%d;", filepath.Base(file), fileInfo.ModTime().UnixNano())
+		}
+	}
+	return stamp.String()
+}
+
+func WatchConfig(envManager *env_manager.EnvManager, callback func(*models.Config)) {
+	lastStamp := configStamp(envManager)
+
 	go func() {
 		ticker := time.NewTicker(5 * time.Second)
 		defer ticker.Stop()
 
 		for range ticker.C {
-			fileInfo, err := os.Stat(configPath)
-			if err != nil {
+			stamp := configStamp(envManager)
+			if stamp == lastStamp {
 				continue
 			}
+			lastStamp = stamp
 
-			if fileInfo.ModTime().After(lastModTime) {
-				lastModTime = fileInfo.ModTime()
-
-				if newConfig, err := Load(envManager); err == nil {
-					callback(newConfig)
-				}
+			if newConfig, err := Load(envManager); err == nil {
+				callback(newConfig)
 			}
 		}
 	}()
@@ -89,17 +263,154 @@ func setDefaults(config *models.Config) {
 	if config.Settings.MaxConcurrent == 0 {
 		config.Settings.MaxConcurrent = 3
 	}
+	if config.Settings.ConflictRetries == 0 {
+		config.Settings.ConflictRetries = 3
+	}
+	if config.Settings.ConflictRetryDelay == 0 {
+		config.Settings.ConflictRetryDelay = 3
+	}
+	if config.Settings.CleanupWorkers == 0 {
+		config.Settings.CleanupWorkers = 4
+	}
+	if config.Settings.DeployTimeout == 0 {
+		config.Settings.DeployTimeout = 900
+	}
+	if config.Settings.BuildTimeout == 0 {
+		config.Settings.BuildTimeout = 1800
+	}
+	if config.Settings.MaxJobDuration == 0 {
+		config.Settings.MaxJobDuration = 7200
+	}
+	if config.Settings.CircuitBreakerCooldown == 0 {
+		config.Settings.CircuitBreakerCooldown = 600
+	}
 	if config.Webhook.Port == "" {
 		config.Webhook.Port = "8080"
 	}
 	if config.Webhook.Path == "" {
 		config.Webhook.Path = "/webhook"
 	}
+	// an empty compose_file is resolved per checkout (see services.ResolveComposeFile)
 	for i := range config.Repositories {
-		if config.Repositories[i].ComposeFile == "" {
-			config.Repositories[i].ComposeFile = "docker-compose.yml"
-		}
 		config.Repositories[i].AutoDeploy = true
 		config.Repositories[i].Enabled = true
+		// repositories without branches follow the remote default branch, resolved when first needed
+		if config.Settings.DefaultBranchFallback && len(config.Repositories[i].Branches) == 0 {
+			config.Repositories[i].DeployDefaultBranch = true
+		}
+	}
+}
+
+// validate checks configuration values that cannot be fixed by defaults
+func validate(config *models.Config) error {
+	if config.Settings.ConflictRetries < 0 {
+		return fmt.Errorf("conflict_retries must not be negative")
+	}
+	if config.Settings.ConflictRetryDelay < 0 {
+		return fmt.Errorf("conflict_retry_delay must not be negative")
+	}
+	if config.Settings.CleanupWorkers < 0 {
+		return fmt.Errorf("cleanup_workers must not be negative")
+	}
+	if _, err := services.ParseNotificationTemplate(config.Settings.NotificationTemplate); err != nil {
+		return fmt.Errorf("invalid notification_template: %v", err)
+	}
+	for _, channel := range config.Settings.NotificationChannels {
+		if channel.URL == "" {
+			return fmt.Errorf("notification_channels entries require a url")
+		}
+		if channel.Retries != nil && *channel.Retries < 0 {
+			return fmt.Errorf("retries must not be negative for notification channel %s", channel.URL)
+		}
+		if _, err := services.ParseNotificationTemplate(channel.Template); err != nil {
+			return fmt.Errorf("invalid template for notification channel %s: %v", channel.URL, err)
+		}
+	}
+	if err := services.ValidateRegistries(config.Settings.Registries); err != nil {
+		return err
+	}
+	if err := helper.ValidateSSHHosts(config.Settings.SSHHosts); err != nil {
+		return err
+	}
+	if err := services.ValidateProjectNames(config.Repositories); err != nil {
+		return err
+	}
+	if config.Settings.MinFreeDiskMB < 0 {
+		return fmt.Errorf("min_free_disk_mb must not be negative")
+	}
+	if config.Settings.CircuitBreakerThreshold < 0 {
+		return fmt.Errorf("circuit_breaker_threshold must not be negative")
+	}
+	if config.Settings.CircuitBreakerCooldown < 0 {
+		return fmt.Errorf("circuit_breaker_cooldown must not be negative")
+	}
+	if config.Settings.DeployTimeout < 0 {
+		return fmt.Errorf("deploy_timeout must not be negative")
+	}
+	if config.Settings.BuildTimeout < 0 {
+		return fmt.Errorf("build_timeout must not be negative")
+	}
+	if config.Settings.MaxJobDuration < 0 {
+		return fmt.Errorf("max_job_duration must not be negative")
+	}
+	if config.Settings.SelfHealInterval < 0 {
+		return fmt.Errorf("self_heal_interval must not be negative")
+	}
+	for _, repo := range config.Repositories {
+		if repo.MaxConcurrent < 0 {
+			return fmt.Errorf("max_concurrent must not be negative for repository %s", repo.Name)
+		}
+		for _, pattern := range repo.ExcludeBranches {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid exclude_branches pattern %q for repository %s: %v", pattern, repo.Name, err)
+			}
+		}
+		if repo.CloneDepth != nil && *repo.CloneDepth < 0 {
+			return fmt.Errorf("clone_depth must not be negative for repository %s", repo.Name)
+		}
+		if repo.TeardownDelay < 0 {
+			return fmt.Errorf("teardown_delay must not be negative for repository %s", repo.Name)
+		}
+		if repo.KeepImages < 0 {
+			return fmt.Errorf("keep_images must not be negative for repository %s", repo.Name)
+		}
+		if repo.ComposeLint != "" && repo.ComposeLint != "warn" && repo.ComposeLint != "strict" {
+			return fmt.Errorf("invalid compose_lint %q for repository %s: must be warn or strict", repo.ComposeLint, repo.Name)
+		}
+		if repo.DeployOn != "" && repo.DeployOn != "push" && repo.DeployOn != "merge" && repo.DeployOn != "both" {
+			return fmt.Errorf("invalid deploy_on %q for repository %s: must be push, merge or both", repo.DeployOn, repo.Name)
+		}
+		if err := services.ValidateHosts(repo); err != nil {
+			return err
+		}
+		if err := services.ValidateCommitStatus(repo); err != nil {
+			return err
+		}
+		for branch, branchConfig := range repo.BranchConfig {
+			names := make(map[string]bool)
+			for _, unit := range branchConfig.Units {
+				if unit.Name == "" {
+					return fmt.Errorf("compose unit without name in %s:%s", repo.Name, branch)
+				}
+				if names[unit.Name] {
+					return fmt.Errorf("duplicate compose unit %q in %s:%s", unit.Name, repo.Name, branch)
+				}
+				names[unit.Name] = true
+			}
+		}
+	}
+	for _, path := range WebhookPaths(config) {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("invalid webhook path %q: must start with /", path)
+		}
+		if path == "/health" || path == "/status" || path == "/drift" || path == "/metrics" || path == "/events" || strings.HasPrefix(path, "/deployments/") {
+			return fmt.Errorf("invalid webhook path %q: reserved by the server", path)
+		}
+	}
+	for _, proxy := range config.Webhook.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("invalid trusted proxy %q: must be an IP address or CIDR", proxy)
+		}
 	}
+	return nil
 }
@@ -0,0 +1,244 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"uruflow.com/env_manager"
+	"uruflow.com/internal/models"
+)
+
+func TestValidateWebhookPaths(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"/webhook", ""},
+		{"/hooks/github", ""},
+		{"webhook", "must start with /"},
+		{"/health", "reserved by the server"},
+		{"/status", "reserved by the server"},
+		{"/drift", "reserved by the server"},
+		{"/metrics", "reserved by the server"},
+		{"/events", "reserved by the server"},
+		{"/deployments/api/main/deploy", "reserved by the server"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			config := &models.Config{Webhook: models.WebhookConfig{Path: "/webhook", Paths: []string{tt.path}}}
+			err := validate(config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalYAML(t *testing.T) {
+	var repo models.Repository
+	err := unmarshal([]byte("name: api\ngit_url: https://example.com/api.git\nbranches:\n  - main\n  - dev\nkeep_images: 3\n"), &repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Name != "api" || repo.GitURL != "https://example.com/api.git" || !slices.Equal(repo.Branches, []string{"main", "dev"}) || repo.KeepImages != 3 {
+		t.Fatalf("unexpected repository %+v", repo)
+	}
+
+	if err := unmarshal([]byte("name: [api\n"), &repo); err == nil {
+		t.Fatal("invalid YAML was accepted")
+	}
+	if err := unmarshal([]byte("keep_images: many\n"), &repo); err == nil {
+		t.Fatal("a string was accepted for keep_images")
+	}
+}
+
+func TestLoadRepositoriesDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    []string
+		wantErr string
+	}{
+		{
+			"json and yaml",
+			map[string]string{
+				"api.json":  `{"name": "api", "branches": ["main"]}`,
+				"shop.yaml": "name: shop\nbranches: [main]\n",
+				"web.yml":   "name: web\nbranches: [main]\n",
+				"notes.txt": "not a repository",
+			},
+			[]string{"inline", "api", "shop", "web"},
+			"",
+		},
+		{
+			"duplicate across formats",
+			map[string]string{"a.json": `{"name": "api"}`, "b.yaml": "name: api\n"},
+			nil,
+			`duplicate repository "api" in a.json and b.yaml`,
+		},
+		{
+			"missing name",
+			map[string]string{"api.yml": "branches: [main]\n"},
+			nil,
+			"missing name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			config := &models.Config{Repositories: []models.Repository{{Name: "inline"}}}
+			err := loadRepositoriesDir(dir, config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, repo := range config.Repositories {
+				names = append(names, repo.Name)
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Fatalf("expected repositories %v, got %v", tt.want, names)
+			}
+		})
+	}
+}
+
+// yamlConfig is a configuration in YAML as it is read from stdin or URUFLOW_CONFIG_URL
+const yamlConfig = `repositories:
+  - name: my-app
+    git_url: git@github.com:username/my-app.git
+    branches: [main]
+    enabled: true
+settings:
+  work_dir: /var/uruflow/repositories
+  max_concurrent: 2
+webhook:
+  port: "8080"
+  path: /webhook
+`
+
+func TestLoadYAML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(yamlConfig))
+	}))
+	defer server.Close()
+
+	stdin := filepath.Join(t.TempDir(), "stdin")
+	if err := os.WriteFile(stdin, []byte(yamlConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		envManager *env_manager.EnvManager
+	}{
+		{"stdin", &env_manager.EnvManager{ConfigDir: "-"}},
+		{"URUFLOW_CONFIG_URL", &env_manager.EnvManager{ConfigURL: server.URL}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := os.Open(stdin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			realStdin := os.Stdin
+			os.Stdin = file
+			defer func() { os.Stdin = realStdin }()
+
+			config, err := Load(tt.envManager)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(config.Repositories) != 1 || config.Repositories[0].Name != "my-app" || !slices.Equal(config.Repositories[0].Branches, []string{"main"}) {
+				t.Fatalf("unexpected repositories %+v", config.Repositories)
+			}
+			if config.Settings.MaxConcurrent != 2 || config.Webhook.Port != "8080" {
+				t.Fatalf("unexpected settings %+v, webhook %+v", config.Settings, config.Webhook)
+			}
+		})
+	}
+}
+
+func TestSchemaRequired(t *testing.T) {
+	schema := Schema()
+	if required, _ := schema["required"].([]string); slices.Contains(required, "repositories") {
+		t.Fatalf("repositories is required: %v", required)
+	}
+
+	repositories := schema["properties"].(map[string]interface{})["repositories"].(map[string]interface{})
+	repository := repositories["items"].(map[string]interface{})
+	required, _ := repository["required"].([]string)
+	if want := []string{"name", "git_url"}; !slices.Equal(required, want) {
+		t.Fatalf("expected the required repository keys %v, got %v", want, required)
+	}
+}
+
+func TestValidateNotificationRetries(t *testing.T) {
+	zero, negative := 0, -1
+	tests := []struct {
+		name    string
+		retries *int
+		wantErr string
+	}{
+		{"unset", nil, ""},
+		{"no retry", &zero, ""},
+		{"negative", &negative, "retries must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &models.Config{Webhook: models.WebhookConfig{Path: "/webhook"}}
+			config.Settings.NotificationChannels = []models.NotificationChannel{{URL: "https://hooks.example.com/uruflow", Retries: tt.retries}}
+			err := validate(config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
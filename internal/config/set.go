@@ -0,0 +1,208 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"uruflow.com/env_manager"
+	"uruflow.com/internal/models"
+)
+
+// settableKeys are the scalar settings Set may change, mapped to the kind of value they take
+var settableKeys = map[string]string{
+	"settings.max_concurrent":            "int",
+	"settings.cleanup_enabled":           "bool",
+	"settings.auto_clone":                "bool",
+	"settings.fail_on_init_error":        "bool",
+	"settings.proactive_cleanup":         "bool",
+	"settings.conflict_retries":          "int",
+	"settings.conflict_retry_delay":      "int",
+	"settings.label_scoped_cleanup_only": "bool",
+	"settings.self_heal_interval":        "int",
+	"settings.default_branch_fallback":   "bool",
+	"settings.cleanup_workers":           "int",
+	"settings.circuit_breaker_threshold": "int",
+	"settings.circuit_breaker_cooldown":  "int",
+	"settings.min_free_disk_mb":          "int",
+	"settings.prune_on_low_disk":         "bool",
+	"settings.prepull":                   "bool",
+	"settings.deploy_timeout":            "int",
+	"settings.build_timeout":             "int",
+	"settings.max_job_duration":          "int",
+	"settings.require_services":          "bool",
+	"webhook.port":                       "port",
+	"webhook.path":                       "path",
+	"webhook.require_sha256":             "bool",
+	"webhook.allow_unsigned_localhost":   "bool",
+	"webhook.respond_immediately":        "bool",
+}
+
+// restartKeys are the settable keys a running server only applies after a restart
+var restartKeys = map[string]bool{
+	"webhook.port": true,
+	"webhook.path": true,
+}
+
+// SettableKeys returns the keys accepted by Set, sorted
+func SettableKeys() []string {
+	keys := make([]string, 0, len(settableKeys))
+	for key := range settableKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RequiresRestart reports whether a running server only picks up a change of key after a restart
+func RequiresRestart(key string) bool {
+	return restartKeys[key]
+}
+
+// Set changes a single scalar setting of config.json such as settings.max_concurrent. The value and the
+// resulting configuration are validated before the file is replaced atomically; the new configuration is returned.
+func Set(envManager *env_manager.EnvManager, key, value string) (*models.Config, error) {
+	if !ReloadSupported(envManager) {
+		return nil, fmt.Errorf("configuration from %s cannot be edited", ConfigSource(envManager))
+	}
+	configPath := GetConfigPath(envManager)
+	kind, ok := settableKeys[key]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key %q: only the scalar settings listed by 'uruflow config set --help' can be set, edit %s for other changes", key, configPath)
+	}
+	parsed, err := parseSettingValue(kind, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %v", key, err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// numbers are kept as written instead of being converted to floats
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid configuration in %s: %v", configPath, err)
+	}
+	if document == nil {
+		document = make(map[string]interface{})
+	}
+
+	section, field, _ := strings.Cut(key, ".")
+	object, ok := document[section].(map[string]interface{})
+	if document[section] == nil {
+		object = make(map[string]interface{})
+		document[section] = object
+	} else if !ok {
+		return nil, fmt.Errorf("invalid configuration in %s: %s is not an object", configPath, section)
+	}
+	object[field] = parsed
+
+	var updated bytes.Buffer
+	encoder := json.NewEncoder(&updated)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %v", err)
+	}
+
+	newConfig, err := parse(envManager, updated.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(configPath, updated.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %v", configPath, err)
+	}
+	return newConfig, nil
+}
+
+// parseSettingValue converts a command line value to the JSON value of a setting kind
+func parseSettingValue(kind, value string) (interface{}, error) {
+	switch kind {
+	case "bool":
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expected true or false, got %q", value)
+		}
+		return parsed, nil
+	case "int":
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return nil, fmt.Errorf("expected a non-negative integer, got %q", value)
+		}
+		return parsed, nil
+	case "port":
+		// the port is stored as a string like in config.json
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 65535 || strconv.Itoa(parsed) != value {
+			return nil, fmt.Errorf("expected a port between 1 and 65535, got %q", value)
+		}
+		return value, nil
+	case "path":
+		if !strings.HasPrefix(value, "/") || strings.ContainsAny(value, " \t\r\n") {
+			return nil, fmt.Errorf("expected a path starting with /, got %q", value)
+		}
+		return value, nil
+	default:
+		return nil, fmt.Errorf("unsupported setting kind %s", kind)
+	}
+}
+
+// writeFileAtomic replaces a file through a temporary file in the same directory, keeping its permissions,
+// so readers such as the config watcher never see a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"uruflow.com/internal/models"
+)
+
+// Webhook event types a repository can deploy on, see deploy_on
+const (
+	eventPush  = "push"
+	eventMerge = "merge"
+)
+
+// errNotMerged is returned for pull and merge request events that do not merge the request
+var errNotMerged = errors.New("request not merged")
+
+// webhookProvider detects the provider of a webhook from its event headers, falling back to the
+// GitLab object_kind field for requests relayed without them
+func webhookProvider(r *http.Request, body []byte) string {
+	if r.Header.Get("X-Gitlab-Event") != "" {
+		return "gitlab"
+	}
+	if r.Header.Get("X-GitHub-Event") != "" {
+		return "github"
+	}
+	var probe struct {
+		ObjectKind string `json:"object_kind"`
+	}
+	if json.Unmarshal(body, &probe) == nil && probe.ObjectKind != "" {
+		return "gitlab"
+	}
+	return "github"
+}
+
+// normalizeGitLabWebhook converts a GitLab push payload into the representation the webhook handler works on
+func normalizeGitLabWebhook(gitlab *models.GitLabWebhook) *models.GitHubWebhook {
+	webhook := &models.GitHubWebhook{
+		Ref:    gitlab.Ref,
+		Before: gitlab.Before,
+		After:  gitlab.After,
+	}
+
+	webhook.Repository.Name = gitlab.Repository.Name
+	if webhook.Repository.Name == "" {
+		webhook.Repository.Name = gitlab.Project.Name
+	}
+	webhook.Repository.GitHTTPURL = gitlab.Repository.GitHTTPURL
+	webhook.Repository.GitSSHURL = gitlab.Repository.GitSSHURL
+	webhook.Repository.Homepage = gitlab.Repository.Homepage
+	webhook.Project.PathWithNamespace = gitlab.Project.PathWithNamespace
+	webhook.Project.DefaultBranch = gitlab.Project.DefaultBranch
+	webhook.Project.GitHTTPURL = gitlab.Project.GitHTTPURL
+	webhook.Project.GitSSHURL = gitlab.Project.GitSSHURL
+	webhook.Project.WebURL = gitlab.Project.WebURL
+
+	webhook.Pusher.Name = gitlab.UserUsername
+	if webhook.Pusher.Name == "" {
+		webhook.Pusher.Name = gitlab.UserName
+	}
+	webhook.Pusher.Email = gitlab.UserEmail
+
+	for _, gitlabCommit := range gitlab.Commits {
+		var commit models.WebhookCommit
+		commit.ID = gitlabCommit.ID
+		commit.Message = gitlabCommit.Message
+		commit.Timestamp = gitlabCommit.Timestamp
+		commit.URL = gitlabCommit.URL
+		commit.Author.Name = gitlabCommit.Author.Name
+		commit.Author.Email = gitlabCommit.Author.Email
+		commit.Added = gitlabCommit.Added
+		commit.Modified = gitlabCommit.Modified
+		commit.Removed = gitlabCommit.Removed
+		webhook.Commits = append(webhook.Commits, commit)
+	}
+
+	// checkout_sha is the head of the pushed ref, null when the push deletes the branch
+	webhook.HeadCommit.ID = gitlab.CheckoutSHA
+	for _, commit := range webhook.Commits {
+		if commit.ID == gitlab.CheckoutSHA {
+			webhook.HeadCommit = commit
+		}
+	}
+	return webhook
+}
+
+// webhookEvent returns eventMerge for GitHub pull_request and GitLab merge_request deliveries and eventPush
+// for everything else, which then goes through the push validation as before
+func webhookEvent(r *http.Request, body []byte, provider string) string {
+	if provider == "gitlab" {
+		if r.Header.Get("X-Gitlab-Event") == "Merge Request Hook" {
+			return eventMerge
+		}
+		var probe struct {
+			ObjectKind string `json:"object_kind"`
+		}
+		if json.Unmarshal(body, &probe) == nil && probe.ObjectKind == "merge_request" {
+			return eventMerge
+		}
+		return eventPush
+	}
+	if r.Header.Get("X-GitHub-Event") == "pull_request" {
+		return eventMerge
+	}
+	return eventPush
+}
+
+// applyGitHubPullRequest turns a parsed pull_request payload into a push of its merge commit to the base branch,
+// pull requests closed without merging and other actions return errNotMerged
+func applyGitHubPullRequest(webhook *models.GitHubWebhook, pr *models.GitHubPullRequestWebhook) error {
+	if pr.Action != "closed" {
+		return fmt.Errorf("%w: pull request #%d %s", errNotMerged, pr.Number, pr.Action)
+	}
+	if !pr.PullRequest.Merged {
+		return fmt.Errorf("%w: pull request #%d closed without merging", errNotMerged, pr.Number)
+	}
+
+	webhook.Ref = "refs/heads/" + pr.PullRequest.Base.Ref
+	webhook.After = pr.PullRequest.MergeCommitSHA
+	webhook.Pusher.Name = pr.PullRequest.MergedBy.Login
+	// the payload carries no commit details, the title describes the merged change
+	webhook.HeadCommit.ID = pr.PullRequest.MergeCommitSHA
+	webhook.HeadCommit.Message = pr.PullRequest.Title
+	webhook.HeadCommit.URL = pr.PullRequest.HTMLURL
+	webhook.HeadCommit.Author.Name = pr.PullRequest.MergedBy.Login
+	return nil
+}
+
+// applyGitLabMergeRequest turns a parsed merge_request payload into a push of the merge to the target branch,
+// events other than the merge itself return errNotMerged
+func applyGitLabMergeRequest(webhook *models.GitHubWebhook, mr *models.GitLabMergeRequestWebhook) error {
+	attributes := mr.ObjectAttributes
+	if attributes.State != "merged" || (attributes.Action != "" && attributes.Action != "merge") {
+		return fmt.Errorf("%w: merge request !%d %s (state %s)", errNotMerged, attributes.IID, attributes.Action, attributes.State)
+	}
+
+	// fast-forward merges create no merge commit, the last commit of the request is then the new head
+	commitID := attributes.MergeCommitSHA
+	if commitID == "" {
+		commitID = attributes.LastCommit.ID
+	}
+
+	webhook.Ref = "refs/heads/" + attributes.TargetBranch
+	webhook.After = commitID
+	webhook.Pusher.Name = mr.User.Username
+	if webhook.Pusher.Name == "" {
+		webhook.Pusher.Name = mr.User.Name
+	}
+	webhook.Pusher.Email = mr.User.Email
+	webhook.HeadCommit.ID = commitID
+	webhook.HeadCommit.Message = attributes.Title
+	webhook.HeadCommit.URL = attributes.URL
+	webhook.HeadCommit.Author.Name = mr.User.Name
+	webhook.HeadCommit.Author.Email = mr.User.Email
+	return nil
+}
+
+// deploysOnEvent reports whether the deploy_on option of a repository includes the event
+func deploysOnEvent(repo models.Repository, event string) bool {
+	switch repo.DeployOn {
+	case "both":
+		return true
+	case eventMerge:
+		return event == eventMerge
+	default:
+		return event == eventPush
+	}
+}
+
+// mergeDedupWindow is how long the commit one event type deployed for a deploy_on both repository keeps the
+// other event type from deploying it again
+const mergeDedupWindow = 10 * time.Minute
+
+// claimedCommit is the commit last deployed for a branch and the event type that deployed it
+type claimedCommit struct {
+	commit string
+	event  string
+	at     time.Time
+}
+
+// claimCommit records that event deploys commit on a branch of a deploy_on both repository. It returns false
+// when the other event type deployed the same commit within mergeDedupWindow, as a merged pull request and the
+// push of its merge commit do; repeated events of one type are not suppressed.
+func (h *WebhookHandler) claimCommit(repo models.Repository, branch, commit, event string) bool {
+	if repo.DeployOn != "both" || commit == "" {
+		return true
+	}
+
+	h.claimsMu.Lock()
+	defer h.claimsMu.Unlock()
+
+	key := fmt.Sprintf("%s:%s", repo.Name, branch)
+	now := time.Now()
+	if last, ok := h.claims[key]; ok && last.commit == commit && last.event != event && now.Sub(last.at) < mergeDedupWindow {
+		return false
+	}
+	for key, last := range h.claims {
+		if now.Sub(last.at) >= mergeDedupWindow {
+			delete(h.claims, key)
+		}
+	}
+	h.claims[key] = claimedCommit{commit: commit, event: event, at: now}
+	return true
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package handlers
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"uruflow.com/internal/models"
+	"uruflow.com/internal/utils"
+)
+
+// TestParsePushPayloads parses push, tag and branch deletion deliveries in the shape GitHub and GitLab send them
+func TestParsePushPayloads(t *testing.T) {
+	t.Setenv("URUFLOW_LOG_DIR", t.TempDir())
+	reqLog := utils.NewLogger("[TEST] ")
+	defer reqLog.Close()
+	h := &WebhookHandler{}
+
+	tests := []struct {
+		file        string
+		header      string
+		event       string
+		provider    string
+		repository  string
+		ref         string
+		head        string
+		message     string
+		pusher      string
+		created     bool
+		deleted     bool
+		validateErr string
+	}{
+		{"github-push.json", "X-GitHub-Event", "push", "github", "Hello-World", "refs/heads/feature/login",
+			"a10867b14bb761a232cd80139fbd4c0d33264240", "Validate login input\n\nRejects empty passwords.", "octocat", true, false, ""},
+		{"github-tag.json", "X-GitHub-Event", "push", "github", "Hello-World", "refs/tags/v1.0.0",
+			"a10867b14bb761a232cd80139fbd4c0d33264240", "Validate login input\n\nRejects empty passwords.", "octocat", true, false, "non-branch ref"},
+		{"github-delete.json", "X-GitHub-Event", "push", "github", "Hello-World", "refs/heads/feature/login",
+			"", "", "octocat", false, true, "no commits in push"},
+		{"gitlab-push.json", "X-Gitlab-Event", "Push Hook", "gitlab", "Diaspora", "refs/heads/feature/login",
+			"da1560886d4f094c3e6c9ef40349f7d38b5d27d7", "fixed readme", "jsmith", false, false, ""},
+		{"gitlab-tag.json", "X-Gitlab-Event", "Tag Push Hook", "gitlab", "Example", "refs/tags/v1.0.0",
+			"82b3d5ae55f7080f1e6022629cdb57bfae7cccc7", "", "jsmith", true, false, "non-branch ref"},
+		{"gitlab-delete.json", "X-Gitlab-Event", "Push Hook", "gitlab", "Diaspora", "refs/heads/feature/login",
+			"", "", "jsmith", false, true, "no commits in push"},
+		// relays that drop the event headers, the provider comes from object_kind
+		{"gitlab-push.json", "", "", "gitlab", "Diaspora", "refs/heads/feature/login",
+			"da1560886d4f094c3e6c9ef40349f7d38b5d27d7", "fixed readme", "jsmith", false, false, ""},
+		{"github-push.json", "", "", "github", "Hello-World", "refs/heads/feature/login",
+			"a10867b14bb761a232cd80139fbd4c0d33264240", "Validate login input\n\nRejects empty passwords.", "octocat", true, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file+"/"+tt.event, func(t *testing.T) {
+			body, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest("POST", "/webhook", strings.NewReader(string(body)))
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.event)
+			}
+
+			provider := webhookProvider(r, body)
+			if provider != tt.provider {
+				t.Fatalf("provider %q, want %q", provider, tt.provider)
+			}
+			event := webhookEvent(r, body, provider)
+			if event != eventPush {
+				t.Fatalf("event %q, want %q", event, eventPush)
+			}
+			webhook, err := h.parseWebhook(body, provider, event, reqLog)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if webhook.Repository.Name != tt.repository {
+				t.Errorf("repository %q, want %q", webhook.Repository.Name, tt.repository)
+			}
+			if webhook.Ref != tt.ref {
+				t.Errorf("ref %q, want %q", webhook.Ref, tt.ref)
+			}
+			if webhook.HeadCommit.ID != tt.head {
+				t.Errorf("head commit %q, want %q", webhook.HeadCommit.ID, tt.head)
+			}
+			if webhook.HeadCommit.Message != tt.message {
+				t.Errorf("head commit message %q, want %q", webhook.HeadCommit.Message, tt.message)
+			}
+			if pusher := h.getPusherInfo(webhook); pusher != tt.pusher {
+				t.Errorf("pusher %q, want %q", pusher, tt.pusher)
+			}
+			if got := isBranchCreation(webhook); got != tt.created {
+				t.Errorf("isBranchCreation = %t, want %t", got, tt.created)
+			}
+			if got := isBranchDeletion(webhook); got != tt.deleted {
+				t.Errorf("isBranchDeletion = %t, want %t", got, tt.deleted)
+			}
+
+			branch := strings.TrimPrefix(webhook.Ref, "refs/heads/")
+			err = h.validateWebhook(webhook, branch, reqLog)
+			if tt.validateErr == "" && err != nil {
+				t.Errorf("validateWebhook: %v", err)
+			}
+			if tt.validateErr != "" && (err == nil || !strings.Contains(err.Error(), tt.validateErr)) {
+				t.Errorf("validateWebhook error %v, want %q", err, tt.validateErr)
+			}
+		})
+	}
+}
+
+func TestClaimCommit(t *testing.T) {
+	both := models.Repository{Name: "api", DeployOn: "both"}
+	push := models.Repository{Name: "api"}
+
+	tests := []struct {
+		name   string
+		repo   models.Repository
+		events [][2]string // commit, event
+		want   []bool
+	}{
+		{"merge then push of the merge commit", both, [][2]string{{"abc", eventMerge}, {"abc", eventPush}}, []bool{true, false}},
+		{"push then merge event", both, [][2]string{{"abc", eventPush}, {"abc", eventMerge}}, []bool{true, false}},
+		{"redelivered push", both, [][2]string{{"abc", eventPush}, {"abc", eventPush}}, []bool{true, true}},
+		{"different commits", both, [][2]string{{"abc", eventMerge}, {"def", eventPush}}, []bool{true, true}},
+		{"merge after a newer push", both, [][2]string{{"abc", eventMerge}, {"def", eventPush}, {"abc", eventPush}}, []bool{true, true, true}},
+		{"deploy_on push", push, [][2]string{{"abc", eventPush}, {"abc", eventPush}}, []bool{true, true}},
+		{"empty commit", both, [][2]string{{"", eventMerge}, {"", eventPush}}, []bool{true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &WebhookHandler{claims: make(map[string]claimedCommit)}
+			for i, event := range tt.events {
+				if got := h.claimCommit(tt.repo, "main", event[0], event[1]); got != tt.want[i] {
+					t.Errorf("event %d (%s %s): claimCommit = %t, want %t", i, event[1], event[0], got, tt.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("window expired", func(t *testing.T) {
+		h := &WebhookHandler{claims: make(map[string]claimedCommit)}
+		h.claims["api:main"] = claimedCommit{commit: "abc", event: eventMerge, at: time.Now().Add(-mergeDedupWindow)}
+		if !h.claimCommit(both, "main", "abc", eventPush) {
+			t.Error("push suppressed after the dedup window")
+		}
+	})
+	t.Run("other branch", func(t *testing.T) {
+		h := &WebhookHandler{claims: make(map[string]claimedCommit)}
+		h.claimCommit(both, "main", "abc", eventMerge)
+		if !h.claimCommit(both, "release", "abc", eventPush) {
+			t.Error("push to another branch suppressed")
+		}
+	})
+}
+
+func TestGenerateRequestID(t *testing.T) {
+	h := &WebhookHandler{}
+	const count = 10000
+	seen := make(map[string]bool, count)
+	for i := 0; i < count; i++ {
+		id := h.generateRequestID()
+		if seen[id] {
+			t.Fatalf("duplicate request ID %s after %d IDs", id, i)
+		}
+		seen[id] = true
+
+		_, suffix, ok := strings.Cut(id, "-")
+		if !ok || len(suffix) != requestIDSuffixLength {
+			t.Fatalf("request ID %q has no %d character suffix", id, requestIDSuffixLength)
+		}
+		if strings.Count(suffix, suffix[:1]) == len(suffix) {
+			t.Errorf("request ID %q repeats one character", id)
+		}
+		for _, c := range suffix {
+			if !strings.ContainsRune("abcdefghijklmnopqrstuvwxyz0123456789", c) {
+				t.Fatalf("request ID %q has character %q outside [a-z0-9]", id, c)
+			}
+		}
+	}
+}
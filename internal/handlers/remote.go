@@ -0,0 +1,148 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package handlers
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"uruflow.com/internal/models"
+	"uruflow.com/internal/services"
+)
+
+// webhookRemoteURLs returns the clone URLs a push payload announces, GitHub on the repository
+// and GitLab on both the repository and the project
+func webhookRemoteURLs(webhook *models.GitHubWebhook) []string {
+	candidates := []string{
+		webhook.Repository.CloneURL,
+		webhook.Repository.SSHURL,
+		webhook.Repository.HTMLURL,
+		webhook.Repository.GitHTTPURL,
+		webhook.Repository.GitSSHURL,
+		webhook.Repository.Homepage,
+		webhook.Project.GitHTTPURL,
+		webhook.Project.GitSSHURL,
+		webhook.Project.WebURL,
+	}
+
+	var urls []string
+	for _, candidate := range candidates {
+		if candidate != "" {
+			urls = append(urls, candidate)
+		}
+	}
+	return urls
+}
+
+// remoteURLMatches reports whether one of the announced URLs points to the same repository as gitURL
+func remoteURLMatches(gitURL string, announced []string) bool {
+	want := normalizeGitURL(gitURL)
+	for _, candidate := range announced {
+		if normalizeGitURL(candidate) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeGitURL reduces https, ssh:// and scp-like (git@host:owner/repo) URLs to host/owner/repo
+// so the different clone URLs of one repository compare equal
+func normalizeGitURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+
+	var host, repoPath string
+	if strings.Contains(rawURL, "://") {
+		parsed, err := url.Parse(rawURL)
+		if err != nil {
+			return strings.ToLower(rawURL)
+		}
+		host, repoPath = parsed.Hostname(), parsed.Path
+	} else if at := strings.Index(rawURL, "@"); at >= 0 && strings.Contains(rawURL[at:], ":") {
+		hostAndPath := rawURL[at+1:]
+		colon := strings.Index(hostAndPath, ":")
+		host, repoPath = hostAndPath[:colon], hostAndPath[colon+1:]
+	} else {
+		return strings.ToLower(rawURL)
+	}
+
+	repoPath = strings.Trim(repoPath, "/")
+	repoPath = strings.TrimSuffix(repoPath, ".git")
+	return strings.ToLower(host + "/" + repoPath)
+}
+
+// gitLabRepositoryName picks the configured repository of a GitLab push from its path_with_namespace.
+// Repositories with gitlab_namespace match when git_url points to that project path; otherwise the
+// project name is used, rejected when the repository expects a different namespace.
+func gitLabRepositoryName(repos []models.Repository, pathWithNamespace, name string) (string, bool) {
+	pathWithNamespace = strings.ToLower(strings.Trim(pathWithNamespace, "/"))
+	namespace := path.Dir(pathWithNamespace)
+
+	for _, repo := range repos {
+		if repo.GitLabNamespace == "" || !strings.EqualFold(strings.Trim(repo.GitLabNamespace, "/"), namespace) {
+			continue
+		}
+		if _, repoPath, found := strings.Cut(normalizeGitURL(repo.GitURL), "/"); found && repoPath == pathWithNamespace {
+			return repo.Name, true
+		}
+	}
+
+	for _, repo := range repos {
+		if repo.Name != name {
+			continue
+		}
+		if repo.GitLabNamespace != "" && !strings.EqualFold(strings.Trim(repo.GitLabNamespace, "/"), namespace) {
+			return "", false
+		}
+		return repo.Name, true
+	}
+	return name, true
+}
+
+// commitStatusTarget returns the commit a webhook deploy reports its status to, nil when the repository has no
+// commit_status. The API host is taken from git_url, never from the payload, so the token only goes to that host.
+func commitStatusTarget(repo models.Repository, webhook *models.GitHubWebhook) *services.CommitStatusTarget {
+	if repo.CommitStatus == nil {
+		return nil
+	}
+	commit := webhook.After
+	if commit == "" {
+		commit = webhook.HeadCommit.ID
+	}
+	if commit == "" {
+		return nil
+	}
+
+	host, repoPath, _ := strings.Cut(normalizeGitURL(repo.GitURL), "/")
+	target := &services.CommitStatusTarget{Provider: "github", Project: webhook.Repository.FullName, Commit: commit}
+	if webhook.Project.PathWithNamespace != "" {
+		target.Provider = "gitlab"
+		target.Project = webhook.Project.PathWithNamespace
+	}
+	if target.Project == "" {
+		target.Project = repoPath
+	}
+	target.APIURL = repo.CommitStatus.APIURL
+	if target.APIURL == "" {
+		target.APIURL = services.CommitStatusAPIURL(target.Provider, host)
+	}
+	return target
+}
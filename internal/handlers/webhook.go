@@ -27,17 +27,20 @@ package handlers
 import (
 	"context"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha1"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"uruflow.com/internal/models"
@@ -45,6 +48,9 @@ import (
 	"uruflow.com/internal/utils"
 )
 
+// errBranchExcluded is returned for branches matching exclude_branches
+var errBranchExcluded = errors.New("branch excluded from deployment")
+
 // WebhookResponse represents a standardized webhook response
 type WebhookResponse struct {
 	Status    string                 `json:"status"`
@@ -58,11 +64,15 @@ type WebhookResponse struct {
 // WebhookHandler handles GitHub/GitLab webhook requests
 type WebhookHandler struct {
 	config            *models.Config
+	configMu          sync.RWMutex
 	repositoryService *services.RepositoryService
 	deploymentService *services.DeploymentService
 	gitService        *services.GitService
 	dockerService     *services.DockerService
+	teardownService   *services.TeardownService
 	logger            *utils.Logger
+	claims            map[string]claimedCommit
+	claimsMu          sync.Mutex
 }
 
 // NewWebhookHandler creates a new webhook handler
@@ -81,13 +91,34 @@ func NewWebhookHandler(
 		gitService:        gitService,
 		dockerService:     dockerService,
 		logger:            logger,
+		claims:            make(map[string]claimedCommit),
 	}
 }
 
+// UpdateConfig updates the configuration reference used by requests received afterwards
+func (h *WebhookHandler) UpdateConfig(config *models.Config) {
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+	h.config = config
+}
+
+// currentConfig returns the configuration in use; a reload swaps the pointer, the returned config is never modified
+func (h *WebhookHandler) currentConfig() *models.Config {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return h.config
+}
+
+// SetTeardownService enables teardown of deleted branches through the given service
+func (h *WebhookHandler) SetTeardownService(teardownService *services.TeardownService) {
+	h.teardownService = teardownService
+}
+
 // HandleWebhook processes incoming webhook requests with improved error handling
 func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
 	requestID := h.generateRequestID()
+	reqLog := h.logger.With(requestID)
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("X-Request-ID", requestID)
@@ -98,7 +129,7 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 
 	defer func() {
 		if rec := recover(); rec != nil {
-			h.logger.Error("[%s] Error with webhook handler: %v", requestID, rec)
+			reqLog.Error("Error with webhook handler: %v", rec)
 			response.Status = "error"
 			response.Error = "Internal server error"
 			response.Message = "An unexpected error occurred"
@@ -106,14 +137,13 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		}
 
 		duration := time.Since(startTime)
-		h.logger.Info("[%s] === WEBHOOK REQUEST END (Duration: %v, Status: %s) ===",
-			requestID, duration.Round(time.Millisecond), response.Status)
+		reqLog.Info("=== WEBHOOK REQUEST END (Duration: %v, Status: %s) ===", duration.Round(time.Millisecond), response.Status)
 	}()
 
-	h.logger.Info("[%s] === WEBHOOK REQUEST START ===", requestID)
-	h.logger.Info("[%s] Webhook request from %s", requestID, r.RemoteAddr)
+	reqLog.Info("=== WEBHOOK REQUEST START ===")
+	reqLog.Info("Webhook request from %s", ClientIP(r, h.currentConfig().Webhook.TrustedProxies))
 	if r.Method != http.MethodPost {
-		h.logger.Warning("[%s] Invalid method: %s (expected POST)", requestID, r.Method)
+		reqLog.Warning("Invalid method: %s (expected POST)", r.Method)
 		response.Status = "failed"
 		response.Error = "Method not allowed"
 		response.Message = fmt.Sprintf("Invalid HTTP method: %s", r.Method)
@@ -121,7 +151,7 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body, err := h.readRequestBody(r, requestID)
+	body, err := h.readRequestBody(r, reqLog)
 	if err != nil {
 		response.Status = "failed"
 		response.Error = "Bad request"
@@ -130,7 +160,7 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.validateWebhookSecret(r, body, requestID); err != nil {
+	if err := h.validateWebhookSecret(r, body, reqLog); err != nil {
 		response.Status = "failed"
 		response.Error = "Unauthorized"
 		response.Message = "Webhook signature validation failed"
@@ -138,7 +168,19 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	webhook, err := h.parseWebhook(body, requestID)
+	provider := webhookProvider(r, body)
+	webhook, err := h.parseWebhook(body, provider, webhookEvent(r, body, provider), reqLog)
+	if errors.Is(err, errNotMerged) {
+		response.Status = "ignored"
+		response.Message = err.Error()
+		response.Details = map[string]interface{}{
+			"repository": webhook.Repository.Name,
+			"event":      webhook.Event,
+			"reason":     "not_merged",
+		}
+		h.sendResponse(w, http.StatusOK, response)
+		return
+	}
 	if err != nil {
 		response.Status = "failed"
 		response.Error = "Invalid payload"
@@ -149,7 +191,37 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 
 	branch := strings.TrimPrefix(webhook.Ref, "refs/heads/")
 
-	if err := h.validateWebhook(webhook, branch, requestID); err != nil {
+	if project := webhook.Project.PathWithNamespace; project != "" {
+		name, ok := gitLabRepositoryName(h.repositoryService.ListRepositories(), project, webhook.Repository.Name)
+		if !ok {
+			reqLog.Warning("GitLab project %s is not in the gitlab_namespace of repository '%s'", project, webhook.Repository.Name)
+			response.Status = "failed"
+			response.Error = "Configuration error"
+			response.Message = fmt.Sprintf("GitLab project %s does not match the gitlab_namespace of repository '%s'", project, webhook.Repository.Name)
+			h.sendResponse(w, http.StatusNotFound, response)
+			return
+		}
+		if name != webhook.Repository.Name {
+			reqLog.Info("GitLab project %s matched repository '%s'", project, name)
+			webhook.Repository.Name = name
+		}
+	}
+
+	if h.teardownService != nil && webhook.Event == eventPush && strings.HasPrefix(webhook.Ref, "refs/heads/") {
+		if isBranchDeletion(webhook) {
+			if details, ok := h.scheduleTeardown(webhook.Repository.Name, branch, reqLog); ok {
+				response.Status = "teardown_scheduled"
+				response.Message = "Branch deleted, teardown scheduled"
+				response.Details = details
+				h.sendResponse(w, http.StatusOK, response)
+				return
+			}
+		} else {
+			h.teardownService.Cancel(webhook.Repository.Name, branch)
+		}
+	}
+
+	if err := h.validateWebhook(webhook, branch, reqLog); err != nil {
 		response.Status = "ignored"
 		response.Message = err.Error()
 		response.Details = map[string]interface{}{
@@ -161,12 +233,29 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 
 	pusherInfo := h.getPusherInfo(webhook)
-	h.logger.Webhook("[%s] Processing: %s:%s (commit: %s, pusher: %s)",
-		requestID, webhook.Repository.Name, branch,
+	reqLog.Webhook("Processing: %s:%s (commit: %s, pusher: %s)",
+		webhook.Repository.Name, branch,
 		h.getShortCommitID(webhook.HeadCommit.ID),
 		pusherInfo)
+	if webhook.Event == eventMerge {
+		reqLog.Webhook("Merge into %s: %s", branch, webhook.HeadCommit.Message)
+	} else if isBranchCreation(webhook) {
+		// the deploy clones the branch, or checks it out fresh in an existing checkout
+		reqLog.Webhook("Push creates branch %s, it is checked out fresh from the remote", branch)
+	}
 
-	repo, err := h.validateRepository(webhook.Repository.Name, branch, requestID)
+	repo, err := h.validateRepository(webhook.Repository.Name, branch, h.getDefaultBranch(webhook), reqLog)
+	if errors.Is(err, errBranchExcluded) {
+		response.Status = "ignored"
+		response.Message = err.Error()
+		response.Details = map[string]interface{}{
+			"repository": webhook.Repository.Name,
+			"branch":     branch,
+			"reason":     "branch_excluded",
+		}
+		h.sendResponse(w, http.StatusOK, response)
+		return
+	}
 	if err != nil {
 		response.Status = "failed"
 		response.Error = "Configuration error"
@@ -181,8 +270,32 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !h.gitService.IsSSHAvailable() {
-		h.logger.Error("[%s] SSH authentication not available", requestID)
+	if !deploysOnEvent(*repo, webhook.Event) {
+		reqLog.Info("Ignoring %s event for %s:%s, deploy_on is %q", webhook.Event, repo.Name, branch, repo.DeployOn)
+		response.Status = "ignored"
+		response.Message = fmt.Sprintf("%s events do not deploy repository %s", webhook.Event, repo.Name)
+		response.Details = map[string]interface{}{
+			"repository": repo.Name,
+			"branch":     branch,
+			"event":      webhook.Event,
+			"reason":     "event_not_enabled",
+		}
+		h.sendResponse(w, http.StatusOK, response)
+		return
+	}
+
+	if repo.VerifyRemoteURL && !remoteURLMatches(repo.GitURL, webhookRemoteURLs(webhook)) {
+		reqLog.Security("Rejected webhook for %s: repository URLs [%s] do not match git_url %s",
+			repo.Name, strings.Join(webhookRemoteURLs(webhook), ", "), repo.GitURL)
+		response.Status = "failed"
+		response.Error = "Repository mismatch"
+		response.Message = fmt.Sprintf("pushed repository does not match the configured git_url of %s", repo.Name)
+		h.sendResponse(w, http.StatusForbidden, response)
+		return
+	}
+
+	if h.gitService.RequiresSSH(repo.GitURL) && !h.gitService.IsSSHAvailable() {
+		reqLog.Error("SSH authentication not available for %s", repo.GitURL)
 		response.Status = "failed"
 		response.Error = "Configuration error"
 		response.Message = "SSH authentication not configured"
@@ -190,7 +303,69 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	deploymentDetails, err := h.executeDeployment(repo, branch, webhook, requestID)
+	if openUntil, open := h.deploymentService.CircuitOpenUntil(repo.Name, branch); open {
+		reqLog.Warning("Circuit breaker open for %s:%s until %s, deployment skipped", repo.Name, branch, openUntil.Format(time.RFC3339))
+		response.Status = "circuit_open"
+		response.Message = "Deployments paused after repeated failures"
+		response.Details = map[string]interface{}{
+			"repository": repo.Name,
+			"branch":     branch,
+			"reason":     "circuit_open",
+			"retry_at":   openUntil.Unix(),
+		}
+		h.sendResponse(w, http.StatusServiceUnavailable, response)
+		return
+	}
+
+	if repo.SkipIfUnchanged && h.isAlreadyDeployed(repo, branch, webhook.HeadCommit.ID, reqLog) {
+		response.Status = "skipped"
+		response.Message = "Commit is already deployed"
+		response.Details = map[string]interface{}{
+			"repository": repo.Name,
+			"branch":     branch,
+			"commit":     h.getShortCommitID(webhook.HeadCommit.ID),
+			"reason":     "already_deployed",
+		}
+		h.sendResponse(w, http.StatusOK, response)
+		return
+	}
+
+	if !h.claimCommit(*repo, branch, webhook.HeadCommit.ID, webhook.Event) {
+		reqLog.Info("Commit %s of %s:%s was already deployed by the other event type, deploy_on is both", h.getShortCommitID(webhook.HeadCommit.ID), repo.Name, branch)
+		response.Status = "ignored"
+		response.Message = "Commit was already deployed by the other event type"
+		response.Details = map[string]interface{}{
+			"repository": repo.Name,
+			"branch":     branch,
+			"commit":     h.getShortCommitID(webhook.HeadCommit.ID),
+			"event":      webhook.Event,
+			"reason":     "duplicate_commit",
+		}
+		h.sendResponse(w, http.StatusOK, response)
+		return
+	}
+
+	trigger := models.DeployTrigger{Source: "webhook", Actor: pusherInfo, RequestID: requestID}
+	if h.currentConfig().Webhook.RespondImmediately {
+		h.acceptDeployment(w, response, *repo, branch, webhook, trigger, reqLog)
+		return
+	}
+	deploymentDetails, err := h.executeDeployment(repo, branch, webhook, trigger, reqLog)
+	if errors.Is(err, services.ErrDeploySkipped) {
+		response.Status = "skipped"
+		response.Message = "Commit is already deployed"
+		deploymentDetails["reason"] = "already_deployed"
+		response.Details = deploymentDetails
+		h.sendResponse(w, http.StatusOK, response)
+		return
+	}
+	if errors.Is(err, services.ErrDeploymentCancelled) {
+		response.Status = "cancelled"
+		response.Message = err.Error()
+		response.Details = deploymentDetails
+		h.sendResponse(w, http.StatusOK, response)
+		return
+	}
 	if err != nil {
 		response.Status = "failed"
 		response.Error = "Deployment failed"
@@ -207,10 +382,15 @@ func (h *WebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 }
 
 // validateWebhookSecret validates the webhook secret for both GitHub and GitLab
-func (h *WebhookHandler) validateWebhookSecret(r *http.Request, body []byte, requestID string) error {
-	secretKey := h.config.Webhook.Secret
+func (h *WebhookHandler) validateWebhookSecret(r *http.Request, body []byte, reqLog *utils.Logger) error {
+	secretKey := h.currentConfig().Webhook.Secret
 	if secretKey == "" {
-		h.logger.Warning("[%s] No webhook secret configured - skipping validation", requestID)
+		reqLog.Warning("No webhook secret configured - skipping validation")
+		return nil
+	}
+
+	if h.currentConfig().Webhook.AllowUnsignedLocalhost && isDirectLoopbackRequest(r) {
+		reqLog.Security("SIGNATURE VALIDATION BYPASSED for loopback request from %s (allow_unsigned_localhost is enabled, never use this in production)", r.RemoteAddr)
 		return nil
 	}
 
@@ -221,89 +401,133 @@ func (h *WebhookHandler) validateWebhookSecret(r *http.Request, body []byte, req
 
 	gitlabSignature := r.Header.Get("X-Gitlab-Token")
 	if githubSignature != "" {
-		return h.validateGitHubSignature(githubSignature, body, secretKey, requestID)
+		return h.validateGitHubSignature(githubSignature, body, secretKey, reqLog)
 	} else if gitlabSignature != "" {
-		return h.validateGitLabSignature(gitlabSignature, secretKey, requestID)
+		return h.validateGitLabSignature(gitlabSignature, secretKey, reqLog)
 	}
 
-	h.logger.Error("[%s] No signature header found in webhook request", requestID)
+	reqLog.Error("No signature header found in webhook request")
 	return fmt.Errorf("missing webhook signature")
 }
 
 // validateGitHubSignature validates GitHub webhook signature
-func (h *WebhookHandler) validateGitHubSignature(signature string, body []byte, secret string, requestID string) error {
+func (h *WebhookHandler) validateGitHubSignature(signature string, body []byte, secret string, reqLog *utils.Logger) error {
 	var expectedSignature string
 
 	if strings.HasPrefix(signature, "sha256=") {
-		h.logger.Debug("[%s] Validating GitHub SHA256 signature", requestID)
+		reqLog.Debug("Validating GitHub SHA256 signature")
 		mac := hmac.New(sha256.New, []byte(secret))
 		mac.Write(body)
 		expectedSignature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
 	} else if strings.HasPrefix(signature, "sha1=") {
-		h.logger.Debug("[%s] Validating GitHub SHA1 signature (legacy)", requestID)
+		if h.currentConfig().Webhook.RequireSHA256 {
+			reqLog.Error("Rejected GitHub SHA1 signature, require_sha256 is enabled")
+			return fmt.Errorf("SHA1 signatures are not accepted, sign with X-Hub-Signature-256")
+		}
+		reqLog.Debug("Validating GitHub SHA1 signature (legacy)")
 		mac := hmac.New(sha1.New, []byte(secret))
 		mac.Write(body)
 		expectedSignature = "sha1=" + hex.EncodeToString(mac.Sum(nil))
 	} else {
-		h.logger.Error("[%s] Invalid GitHub signature format: %s", requestID, signature)
+		reqLog.Error("Invalid GitHub signature format: %s", signature)
 		return fmt.Errorf("invalid signature format")
 	}
 
 	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
-		h.logger.Error("[%s] GitHub signature validation failed", requestID)
-		h.logger.Debug("[%s] Expected: %s, Got: %s", requestID, expectedSignature, signature)
+		reqLog.Error("GitHub signature validation failed")
+		reqLog.Debug("Expected: %s, Got: %s", expectedSignature, signature)
+		reqLog.Debug("Signed body was %d bytes, %s; if the secret is correct, compare it with the payload the provider sent, a proxy may have altered the body",
+			len(body), bodyChecksum(body))
 		return fmt.Errorf("invalid webhook signature")
 	}
 
-	h.logger.Success("[%s] GitHub signature validation passed", requestID)
+	reqLog.Success("GitHub signature validation passed")
 	return nil
 }
 
 // validateGitLabSignature validates GitLab webhook signature
-func (h *WebhookHandler) validateGitLabSignature(signature string, secret string, requestID string) error {
-	h.logger.Debug("[%s] Validating GitLab token signature", requestID)
+func (h *WebhookHandler) validateGitLabSignature(signature string, secret string, reqLog *utils.Logger) error {
+	reqLog.Debug("Validating GitLab token signature")
 
 	if signature != secret {
-		h.logger.Error("[%s] GitLab token validation failed", requestID)
+		reqLog.Error("GitLab token validation failed")
 		return fmt.Errorf("invalid webhook token")
 	}
 
-	h.logger.Success("[%s] GitLab token validation passed", requestID)
+	reqLog.Success("GitLab token validation passed")
 	return nil
 }
 
 // readRequestBody reads and validates the request body
-func (h *WebhookHandler) readRequestBody(r *http.Request, requestID string) ([]byte, error) {
+func (h *WebhookHandler) readRequestBody(r *http.Request, reqLog *utils.Logger) ([]byte, error) {
 	r.Body = http.MaxBytesReader(nil, r.Body, 10<<20)
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		h.logger.Error("[%s] Error reading request body: %v", requestID, err)
+		reqLog.Error("Error reading request body: %v", err)
 		return nil, fmt.Errorf("failed to read request body")
 	}
 	defer r.Body.Close()
 
 	if len(body) == 0 {
-		h.logger.Error("[%s] Empty request body", requestID)
+		reqLog.Error("Empty request body")
 		return nil, fmt.Errorf("empty request body")
 	}
 
-	h.logger.Debug("[%s] Request body size: %d bytes", requestID, len(body))
+	// lets a body re-encoded by a proxy be told apart from a wrong secret, the body itself is never logged here
+	reqLog.Debug("Request body: %d bytes, %s (Content-Length: %s, Content-Type: %s, Content-Encoding: %s)",
+		len(body), bodyChecksum(body), r.Header.Get("Content-Length"), r.Header.Get("Content-Type"), r.Header.Get("Content-Encoding"))
 	return body, nil
 }
 
-// parseWebhook parses the webhook JSON payload
-func (h *WebhookHandler) parseWebhook(body []byte, requestID string) (*models.GitHubWebhook, error) {
-	var webhook models.GitHubWebhook
-	if err := json.Unmarshal(body, &webhook); err != nil {
-		h.logger.Error("[%s] Error parsing webhook JSON: %v", requestID, err)
+// bodyChecksum returns the SHA256 checksum of a request body
+func bodyChecksum(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// parseWebhook parses the webhook JSON payload of the given provider, GitLab payloads are normalized into the GitHub shape
+func (h *WebhookHandler) parseWebhook(body []byte, provider, event string, reqLog *utils.Logger) (*models.GitHubWebhook, error) {
+	webhook := &models.GitHubWebhook{}
+	var err error
+	if provider == "gitlab" {
+		var gitlab models.GitLabWebhook
+		if err = json.Unmarshal(body, &gitlab); err == nil {
+			webhook = normalizeGitLabWebhook(&gitlab)
+		}
+	} else {
+		err = json.Unmarshal(body, webhook)
+	}
+	// pull and merge request payloads are read a second time for the request fields
+	var mergeErr error
+	if err == nil && event == eventMerge {
+		if provider == "gitlab" {
+			var mr models.GitLabMergeRequestWebhook
+			if err = json.Unmarshal(body, &mr); err == nil {
+				mergeErr = applyGitLabMergeRequest(webhook, &mr)
+			}
+		} else {
+			var pr models.GitHubPullRequestWebhook
+			if err = json.Unmarshal(body, &pr); err == nil {
+				mergeErr = applyGitHubPullRequest(webhook, &pr)
+			}
+		}
+	}
+	webhook.Event = event
+	if err != nil {
+		reqLog.Error("Error parsing %s webhook JSON: %v", provider, err)
 		sample := string(body)
 		if len(sample) > 200 {
 			sample = sample[:200] + "..."
 		}
-		h.logger.Debug("[%s] Body sample: %s", requestID, sample)
+		reqLog.Debug("Body sample: %s", sample)
 		return nil, fmt.Errorf("invalid JSON format")
 	}
+	reqLog.Debug("Parsed %s %s payload", provider, event)
+	if mergeErr != nil {
+		reqLog.Info("Ignoring %s event: %v", event, mergeErr)
+		return webhook, mergeErr
+	}
 
 	if webhook.Repository.Name == "" {
 		return nil, fmt.Errorf("missing repository name")
@@ -312,66 +536,159 @@ func (h *WebhookHandler) parseWebhook(body []byte, requestID string) (*models.Gi
 		return nil, fmt.Errorf("missing ref")
 	}
 
-	return &webhook, nil
+	return webhook, nil
 }
 
 // validateWebhook validates the webhook data
-func (h *WebhookHandler) validateWebhook(webhook *models.GitHubWebhook, branch string, requestID string) error {
+func (h *WebhookHandler) validateWebhook(webhook *models.GitHubWebhook, branch string, reqLog *utils.Logger) error {
 	if !strings.HasPrefix(webhook.Ref, "refs/heads/") {
-		h.logger.Info("[%s] Ignoring non-branch ref: %s", requestID, webhook.Ref)
+		reqLog.Info("Ignoring non-branch ref: %s", webhook.Ref)
 		return fmt.Errorf("non-branch ref: %s", webhook.Ref)
 	}
 
 	if webhook.HeadCommit.ID == "" {
-		h.logger.Info("[%s] Ignoring webhook without commits", requestID)
+		reqLog.Info("Ignoring webhook without commits")
 		return fmt.Errorf("no commits in push")
 	}
 
+	if err := services.ValidateRepositoryName(webhook.Repository.Name); err != nil {
+		reqLog.Security("Rejected webhook: %v", err)
+		return err
+	}
+	if err := services.ValidateBranchName(branch); err != nil {
+		reqLog.Security("Rejected webhook: %v", err)
+		return err
+	}
+
 	return nil
 }
 
+// isBranchDeletion reports whether a push deletes its branch; GitLab only signals it with an all zero after SHA
+func isBranchDeletion(webhook *models.GitHubWebhook) bool {
+	return webhook.Deleted || (webhook.After != "" && strings.Trim(webhook.After, "0") == "")
+}
+
+// isBranchCreation reports whether a push creates its branch; GitLab only signals it with an all zero before SHA
+func isBranchCreation(webhook *models.GitHubWebhook) bool {
+	return webhook.Created || (webhook.Before != "" && strings.Trim(webhook.Before, "0") == "")
+}
+
+// scheduleTeardown schedules the teardown of a deleted branch when its repository has teardown_on_delete enabled
+func (h *WebhookHandler) scheduleTeardown(repoName, branch string, reqLog *utils.Logger) (map[string]interface{}, bool) {
+	repo := h.repositoryService.GetRepository(repoName)
+	if repo == nil || !repo.TeardownOnDelete || !h.repositoryService.IsBranchConfigured(repo, branch) {
+		return nil, false
+	}
+
+	due := h.teardownService.Schedule(*repo, branch)
+	reqLog.Deploy("Branch %s:%s deleted, teardown scheduled at %s", repo.Name, branch, due.Format("15:04:05"))
+	return map[string]interface{}{
+		"repository":  repo.Name,
+		"branch":      branch,
+		"teardown_at": due.Unix(),
+	}, true
+}
+
 // validateRepository validates repository and branch configuration
-func (h *WebhookHandler) validateRepository(repoName, branch, requestID string) (*models.Repository, error) {
+func (h *WebhookHandler) validateRepository(repoName, branch, defaultBranch string, reqLog *utils.Logger) (*models.Repository, error) {
 	repo := h.repositoryService.GetRepository(repoName)
 	if repo == nil {
-		h.logger.Error("[%s] Repository '%s' not found in configuration", requestID, repoName)
+		reqLog.Error("Repository '%s' not found in configuration", repoName)
 		return nil, fmt.Errorf("repository '%s' not configured", repoName)
 	}
 
-	if !repo.AutoDeploy {
-		h.logger.Info("[%s] Auto-deploy disabled for repository %s", requestID, repo.Name)
-		return nil, fmt.Errorf("auto-deploy disabled for repository %s", repo.Name)
+	if repo.DeployDefaultBranch && defaultBranch != "" {
+		h.repositoryService.SetDefaultBranch(repo.Name, defaultBranch)
 	}
 
 	if !h.repositoryService.IsBranchConfigured(repo, branch) {
-		h.logger.Info("[%s] Branch '%s' not configured for deployment in repository '%s'",
-			requestID, branch, repo.Name)
+		if h.repositoryService.IsBranchExcluded(repo, branch) {
+			reqLog.Info("Branch '%s' is excluded from deployment in repository '%s'", branch, repo.Name)
+			return nil, fmt.Errorf("%w: %s", errBranchExcluded, branch)
+		}
+		reqLog.Info("Branch '%s' not configured for deployment in repository '%s'", branch, repo.Name)
 		return nil, fmt.Errorf("branch '%s' not configured for deployment", branch)
 	}
 
+	if !h.repositoryService.IsAutoDeployEnabled(repo, branch) {
+		reqLog.Info("Auto-deploy disabled for %s:%s", repo.Name, branch)
+		return nil, fmt.Errorf("auto-deploy disabled for %s:%s", repo.Name, branch)
+	}
+
 	return repo, nil
 }
 
+// acceptDeployment enqueues the deployment and answers 202 Accepted with its job ID; the outcome is
+// reported through the deployment events, notifications and the job status endpoint
+func (h *WebhookHandler) acceptDeployment(w http.ResponseWriter, response *WebhookResponse, repo models.Repository, branch string, webhook *models.GitHubWebhook, trigger models.DeployTrigger, reqLog *utils.Logger) {
+	details := map[string]interface{}{
+		"repository": repo.Name,
+		"branch":     branch,
+		"commit":     h.getShortCommitID(webhook.HeadCommit.ID),
+	}
+
+	if h.gitService.RequiresSSH(repo.GitURL) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		err := h.testSSHConnection(ctx, reqLog)
+		cancel()
+		if err != nil {
+			response.Status = "failed"
+			response.Error = "SSH connection failed"
+			response.Message = err.Error()
+			response.Details = details
+			h.sendResponse(w, http.StatusServiceUnavailable, response)
+			return
+		}
+	}
+	if err := h.applyGitSafetyFixes(repo.Name, branch, reqLog); err != nil {
+		reqLog.Warning("Failed to apply Git safety fixes: %v", err)
+	}
+
+	opts := services.DeployOptions{Trigger: trigger, CommitStatus: commitStatusTarget(repo, webhook)}
+	jobID, err := h.deploymentService.Enqueue(context.Background(), repo, branch, opts)
+	if err != nil {
+		reqLog.Warning("Deployment of %s:%s not queued: %v", repo.Name, branch, err)
+		response.Status = "failed"
+		response.Error = "Deployment not queued"
+		response.Message = err.Error()
+		response.Details = details
+		statusCode := http.StatusConflict
+		if errors.Is(err, services.ErrCircuitOpen) {
+			statusCode = http.StatusServiceUnavailable
+		}
+		h.sendResponse(w, statusCode, response)
+		return
+	}
+	details["job_id"] = jobID
+
+	reqLog.Webhook("Deployment of %s:%s accepted as job %s, running in the background", repo.Name, branch, jobID)
+	response.Status = "accepted"
+	response.Message = "Deployment queued, the result is reported through events and notifications"
+	response.Details = details
+	h.sendResponse(w, http.StatusAccepted, response)
+}
+
 // executeDeployment performs the actual deployment
-func (h *WebhookHandler) executeDeployment(repo *models.Repository, branch string, webhook *models.GitHubWebhook, requestID string) (map[string]interface{}, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
-	defer cancel()
+func (h *WebhookHandler) executeDeployment(repo *models.Repository, branch string, webhook *models.GitHubWebhook, trigger models.DeployTrigger, reqLog *utils.Logger) (map[string]interface{}, error) {
+	// deploy_timeout and build_timeout are applied by the deployment service
+	ctx := context.Background()
 
 	startTime := time.Now()
-	h.logger.Webhook("[%s] Starting deployment for %s:%s", requestID, repo.Name, branch)
-	if err := h.testSSHConnection(ctx, requestID); err != nil {
-		return map[string]interface{}{
-			"duration": time.Since(startTime).String(),
-			"stage":    "ssh_test",
-		}, err
+	reqLog.Webhook("Starting deployment for %s:%s", repo.Name, branch)
+	if h.gitService.RequiresSSH(repo.GitURL) {
+		if err := h.testSSHConnection(ctx, reqLog); err != nil {
+			return map[string]interface{}{
+				"duration": time.Since(startTime).String(),
+				"stage":    "ssh_test",
+			}, err
+		}
 	}
 
-	repoPath := filepath.Join(h.config.Settings.WorkDir, repo.Name, branch)
-	if err := h.applyGitSafetyFixes(repoPath, requestID); err != nil {
-		h.logger.Warning("[%s] Failed to apply Git safety fixes: %v", requestID, err)
+	if err := h.applyGitSafetyFixes(repo.Name, branch, reqLog); err != nil {
+		reqLog.Warning("Failed to apply Git safety fixes: %v", err)
 	}
 
-	err := h.deployWithContext(ctx, *repo, branch, requestID)
+	err := h.deployWithContext(ctx, *repo, branch, trigger, commitStatusTarget(*repo, webhook), reqLog)
 	duration := time.Since(startTime)
 
 	details := map[string]interface{}{
@@ -396,18 +713,65 @@ func (h *WebhookHandler) executeDeployment(repo *models.Repository, branch strin
 		details["commit_message"] = h.truncateString(webhook.HeadCommit.Message, 100)
 	}
 
+	if repo.ComposeLint != "" {
+		if warnings := h.deploymentService.GetComposeWarnings(repo.Name, branch); len(warnings) > 0 {
+			details["compose_warnings"] = warnings
+		}
+	}
+
+	if errors.Is(err, services.ErrDeploySkipped) {
+		reqLog.Info("Deployment skipped after %v: %v", duration.Round(time.Second), err)
+		return details, err
+	}
 	if err != nil {
-		h.logger.Error("[%s] Deployment failed after %v: %v", requestID, duration.Round(time.Second), err)
+		reqLog.Error("Deployment failed after %v: %v", duration.Round(time.Second), err)
 		details["stage"] = "deployment"
 		return details, err
 	}
 
-	h.logger.Success("[%s] Deployment completed successfully in %v", requestID, duration.Round(time.Second))
+	reqLog.Success("Deployment completed successfully in %v", duration.Round(time.Second))
 	return details, nil
 }
 
+// isAlreadyDeployed reports whether the checkout is at commitID and the branch containers are running
+func (h *WebhookHandler) isAlreadyDeployed(repo *models.Repository, branch, commitID string, reqLog *utils.Logger) bool {
+	if commitID == "" {
+		return false
+	}
+
+	repoPath, err := services.RepositoryPath(h.currentConfig().Settings.WorkDir, repo.Name, branch)
+	if err != nil {
+		return false
+	}
+	info, err := h.gitService.GetRepositoryInfo(repoPath)
+	if err != nil || info["commit_hash"] != commitID {
+		return false
+	}
+
+	var containers []string
+	for _, daemon := range h.dockerService.Daemons(*repo) {
+		running, err := daemon.Docker.GetRunningProjectContainers(*repo, branch)
+		if err != nil {
+			reqLog.Warning("Failed to check running containers for %s:%s: %v", repo.Name, branch, err)
+			return false
+		}
+		if len(running) == 0 {
+			if daemon.Host != "" {
+				reqLog.Info("Commit %s is checked out but no containers are running on %s, redeploying", h.getShortCommitID(commitID), daemon.Host)
+			} else {
+				reqLog.Info("Commit %s is checked out but no containers are running, redeploying", h.getShortCommitID(commitID))
+			}
+			return false
+		}
+		containers = append(containers, running...)
+	}
+
+	reqLog.Info("Commit %s already deployed for %s:%s with %d running containers, skipping", h.getShortCommitID(commitID), repo.Name, branch, len(containers))
+	return true
+}
+
 // testSSHConnection tests SSH connection with retries
-func (h *WebhookHandler) testSSHConnection(ctx context.Context, requestID string) error {
+func (h *WebhookHandler) testSSHConnection(ctx context.Context, reqLog *utils.Logger) error {
 	maxRetries := 3
 	baseDelay := time.Second
 
@@ -418,13 +782,13 @@ func (h *WebhookHandler) testSSHConnection(ctx context.Context, requestID string
 		default:
 		}
 
-		h.logger.Debug("[%s] SSH connection test attempt %d/%d", requestID, attempt, maxRetries)
+		reqLog.Debug("SSH connection test attempt %d/%d", attempt, maxRetries)
 
 		if err := h.gitService.TestSSHConnection(); err == nil {
-			h.logger.Success("[%s] SSH connection verified", requestID)
+			reqLog.Success("SSH connection verified")
 			return nil
 		} else {
-			h.logger.Warning("[%s] SSH test attempt %d failed: %v", requestID, attempt, err)
+			reqLog.Warning("SSH test attempt %d failed: %v", attempt, err)
 
 			if attempt < maxRetries {
 				delay := baseDelay * time.Duration(attempt)
@@ -442,7 +806,7 @@ func (h *WebhookHandler) testSSHConnection(ctx context.Context, requestID string
 }
 
 // deployWithContext executes deployment with context
-func (h *WebhookHandler) deployWithContext(ctx context.Context, repo models.Repository, branch, requestID string) error {
+func (h *WebhookHandler) deployWithContext(ctx context.Context, repo models.Repository, branch string, trigger models.DeployTrigger, status *services.CommitStatusTarget, reqLog *utils.Logger) error {
 	resultChan := make(chan error, 1)
 	progressChan := make(chan string, 10)
 
@@ -450,8 +814,16 @@ func (h *WebhookHandler) deployWithContext(ctx context.Context, repo models.Repo
 		defer close(resultChan)
 		defer close(progressChan)
 
-		progressChan <- "Starting deployment"
-		err := h.deploymentService.DeployDirect(repo, branch)
+		err := h.deploymentService.DeployDirectWithOptions(ctx, repo, branch, services.DeployOptions{
+			Progress: func(stage string) {
+				select {
+				case progressChan <- stage:
+				default:
+				}
+			},
+			Trigger:      trigger,
+			CommitStatus: status,
+		})
 		resultChan <- err
 	}()
 
@@ -461,16 +833,16 @@ func (h *WebhookHandler) deployWithContext(ctx context.Context, repo models.Repo
 	for {
 		select {
 		case <-ctx.Done():
-			h.logger.Error("[%s] Deployment timeout exceeded", requestID)
+			reqLog.Error("Deployment timeout exceeded")
 			return fmt.Errorf("deployment timeout: %v", ctx.Err())
 
 		case progress := <-progressChan:
 			if progress != "" {
-				h.logger.Info("[%s] Deployment progress: %s", requestID, progress)
+				reqLog.Info("Deployment progress: %s", progress)
 			}
 
 		case <-ticker.C:
-			h.logger.Info("[%s] Deployment still in progress...", requestID)
+			reqLog.Info("Deployment still in progress...")
 
 		case err := <-resultChan:
 			return err
@@ -478,37 +850,19 @@ func (h *WebhookHandler) deployWithContext(ctx context.Context, repo models.Repo
 	}
 }
 
-// applyGitSafetyFixes applies Git safety configurations
-func (h *WebhookHandler) applyGitSafetyFixes(repoPath, requestID string) error {
-	h.logger.Debug("[%s] Applying Git safety fixes for: %s", requestID, repoPath)
-
-	currentUser := os.Getenv("USER")
-	if currentUser == "" {
-		currentUser = "unknown"
-	}
-
-	cmd := exec.Command("git", "config", "--global", "safe.directory", "*")
-	if err := cmd.Run(); err != nil {
-		h.logger.Warning("[%s] Failed to set global safe directory: %v", requestID, err)
-	}
-
-	paths := []string{
-		repoPath,
-		filepath.Dir(repoPath),
-		h.config.Settings.WorkDir,
-	}
-
-	for _, path := range paths {
-		cmd = exec.Command("git", "config", "--global", "--add", "safe.directory", path)
-		if err := cmd.Run(); err != nil {
-			h.logger.Debug("[%s] Failed to add safe directory %s: %v", requestID, path, err)
-		}
+// applyGitSafetyFixes fixes the ownership of the checkout of a branch when running as root. Git trusts the
+// checkout through the environment the git service runs git with, the global git config is left alone.
+func (h *WebhookHandler) applyGitSafetyFixes(repoName, branch string, reqLog *utils.Logger) error {
+	repoPath, err := services.RepositoryPath(h.currentConfig().Settings.WorkDir, repoName, branch)
+	if err != nil {
+		return err
 	}
+	reqLog.Debug("Applying Git safety fixes for: %s", repoPath)
 
 	if os.Getuid() == 0 {
-		h.logger.Debug("[%s] Running as root, fixing ownership", requestID)
+		reqLog.Debug("Running as root, fixing ownership")
 		if err := h.fixOwnership(repoPath); err != nil {
-			h.logger.Warning("[%s] Failed to fix ownership: %v", requestID, err)
+			reqLog.Warning("Failed to fix ownership: %v", err)
 		}
 	}
 
@@ -534,20 +888,44 @@ func (h *WebhookHandler) sendResponse(w http.ResponseWriter, statusCode int, res
 	}
 }
 
+// requestIDSuffixLength is the length of the random part of a request ID; with 36^8 suffixes, 10,000 requests
+// within one second collide with a chance of about one in 50,000
+const requestIDSuffixLength = 8
+
 // Helper functions for webhook
 func (h *WebhookHandler) generateRequestID() string {
-	return fmt.Sprintf("%d-%s", time.Now().Unix(), h.randomString(6))
+	return fmt.Sprintf("%d-%s", time.Now().Unix(), h.randomString(requestIDSuffixLength))
 }
 
+// randomString returns length characters drawn uniformly from [a-z0-9] using crypto/rand
 func (h *WebhookHandler) randomString(length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
+	// bytes at or above the largest multiple of len(charset) are discarded to keep the distribution uniform
+	const limit = 256 - 256%len(charset)
+
+	b := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(b) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return strconv.FormatInt(time.Now().UnixNano(), 36)
+		}
+		for _, c := range buf {
+			if int(c) < limit && len(b) < length {
+				b = append(b, charset[int(c)%len(charset)])
+			}
+		}
 	}
 	return string(b)
 }
 
+// getDefaultBranch returns the default branch reported by the webhook (GitHub repository or GitLab project)
+func (h *WebhookHandler) getDefaultBranch(webhook *models.GitHubWebhook) string {
+	if webhook.Repository.DefaultBranch != "" {
+		return webhook.Repository.DefaultBranch
+	}
+	return webhook.Project.DefaultBranch
+}
+
 func (h *WebhookHandler) getShortCommitID(commitID string) string {
 	if len(commitID) > 7 {
 		return commitID[:7]
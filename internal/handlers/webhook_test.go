@@ -0,0 +1,247 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"uruflow.com/internal/models"
+	"uruflow.com/internal/services"
+	"uruflow.com/internal/utils"
+)
+
+// runGit runs a git command in dir and fails the test on error
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// newGitRemote serves a repository with a compose file on every branch over dumb HTTP and returns its
+// URL and the head commit of each branch
+func newGitRemote(t *testing.T, branches ...string) (string, map[string]string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+	work, bare := filepath.Join(root, "work"), filepath.Join(root, "remote.git")
+	if err := os.MkdirAll(work, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, work, "init", "-q", "-b", branches[0])
+	if err := os.WriteFile(filepath.Join(work, "docker-compose.yml"), []byte("services:\n  web:\n    image: nginx\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, work, "add", ".")
+	runGit(t, work, "commit", "-qm", "initial")
+	for _, branch := range branches[1:] {
+		runGit(t, work, "branch", branch)
+	}
+	runGit(t, root, "clone", "-q", "--bare", work, bare)
+	runGit(t, bare, "update-server-info")
+
+	heads := make(map[string]string)
+	for _, branch := range branches {
+		heads[branch] = runGit(t, bare, "rev-parse", "refs/heads/"+branch)
+	}
+	server := httptest.NewServer(http.FileServer(http.Dir(bare)))
+	t.Cleanup(server.Close)
+	return server.URL + "/", heads
+}
+
+// stubDocker puts a docker executable on PATH whose compose commands succeed and report one web service
+func stubDocker(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	// compose commands run without the inherited environment, so the script only uses shell builtins
+	script := `#!/bin/sh
+case "$*" in
+*--services*) echo web ;;
+*"ps --filter"*) echo stub-web-1 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "docker"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// signedPush returns a GitHub push delivery for a branch, signed with secret
+func signedPush(t *testing.T, secret, repoName, branch, commit string) *http.Request {
+	t.Helper()
+	body, err := json.Marshal(map[string]interface{}{
+		"ref":         "refs/heads/" + branch,
+		"after":       commit,
+		"repository":  map[string]interface{}{"name": repoName},
+		"pusher":      map[string]interface{}{"name": "octocat"},
+		"head_commit": map[string]interface{}{"id": commit, "message": "deploy " + branch},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	r := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	r.Header.Set("X-GitHub-Event", "push")
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+// TestConcurrentWebhooks delivers pushes for several branches at once while the configuration is reloaded
+// into every service and the deployment stats are read; run it with go test -race.
+func TestConcurrentWebhooks(t *testing.T) {
+	t.Setenv("URUFLOW_LOG_DIR", t.TempDir())
+	// the handler must leave the global git config alone
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	defer func() {
+		if data, err := os.ReadFile(filepath.Join(home, ".gitconfig")); err == nil && strings.Contains(string(data), "[safe]") {
+			t.Errorf("safe.directory was written to the global git config:\n%s", data)
+		}
+	}()
+	stubDocker(t)
+	branches := []string{"main", "dev", "stage"}
+	gitURL, heads := newGitRemote(t, branches...)
+
+	logger := utils.NewLogger("[TEST] ")
+	defer logger.Close()
+	const secret = "webhook-secret"
+	var repos []models.Repository
+	for _, name := range []string{"api", "web"} {
+		repos = append(repos, models.Repository{
+			Name: name, GitURL: gitURL, Branches: branches,
+			Enabled: true, AutoDeploy: true, CloneDepth: new(int),
+		})
+	}
+	config := &models.Config{
+		Webhook:      models.WebhookConfig{Secret: secret},
+		Settings:     models.Settings{WorkDir: filepath.Join(t.TempDir(), "work"), MaxConcurrent: 2},
+		Repositories: repos,
+	}
+
+	gitService := services.NewGitService(logger)
+	dockerService := services.NewDockerService(config, logger)
+	repositoryService := services.NewRepositoryService(config, gitService, logger)
+	deploymentService := services.NewDeploymentService(config, repositoryService, gitService, dockerService, logger)
+	teardownService := services.NewTeardownService(config, repositoryService, deploymentService, gitService, dockerService, logger)
+	h := NewWebhookHandler(config, repositoryService, deploymentService, gitService, dockerService, logger)
+	h.SetTeardownService(teardownService)
+
+	stop := make(chan struct{})
+	var background sync.WaitGroup
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			reloaded := *config
+			reloaded.Settings.MaxConcurrent = 1 + i%3
+			repositoryService.UpdateConfig(&reloaded)
+			dockerService.UpdateConfig(&reloaded)
+			deploymentService.UpdateConfig(&reloaded)
+			teardownService.UpdateConfig(&reloaded)
+			h.UpdateConfig(&reloaded)
+			deploymentService.GetDeploymentStats()
+			deploymentService.GetActiveJobs()
+			deploymentService.WriteMetrics(io.Discard)
+			repositoryService.GetDeploymentState()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	var mu sync.Mutex
+	statuses := make(map[string]int)
+	var deliveries sync.WaitGroup
+	for round := 0; round < 4; round++ {
+		for _, repo := range repos {
+			for _, branch := range branches {
+				deliveries.Add(1)
+				go func(repoName, branch string) {
+					defer deliveries.Done()
+					rec := httptest.NewRecorder()
+					h.HandleWebhook(rec, signedPush(t, secret, repoName, branch, heads[branch]))
+
+					var response WebhookResponse
+					if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+						t.Errorf("%s:%s: invalid response %q", repoName, branch, rec.Body.String())
+						return
+					}
+					status := response.Status
+					// a delivery for a branch that is still deploying is refused, not queued
+					if response.Status == "failed" && strings.Contains(response.Message, "already in progress") {
+						status = "in_progress"
+					}
+					if status != "success" && status != "in_progress" {
+						t.Errorf("%s:%s: %d %s: %s", repoName, branch, rec.Code, response.Status, response.Message)
+					}
+					mu.Lock()
+					statuses[fmt.Sprintf("%s:%s", repoName, status)]++
+					mu.Unlock()
+				}(repo.Name, branch)
+			}
+		}
+		deliveries.Wait()
+	}
+	close(stop)
+	background.Wait()
+
+	for _, repo := range repos {
+		if statuses[repo.Name+":success"] == 0 {
+			t.Errorf("no successful deploy of %s: %v", repo.Name, statuses)
+		}
+		for _, branch := range branches {
+			repoPath := filepath.Join(config.Settings.WorkDir, repo.Name, branch)
+			if got := runGit(t, repoPath, "rev-parse", "HEAD"); got != heads[branch] {
+				t.Errorf("%s:%s checked out at %s, want %s", repo.Name, branch, got, heads[branch])
+			}
+		}
+	}
+	if active := deploymentService.GetActiveJobs(); len(active) != 0 {
+		t.Errorf("deployments still active: %v", active)
+	}
+}
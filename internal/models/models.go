@@ -26,40 +26,210 @@ import "time"
 
 // Config represents the main configuration structure
 type Config struct {
-	Repositories []Repository  `json:"repositories"`
+	Repositories []Repository  `json:"repositories" schema:"optional"`
 	Settings     Settings      `json:"settings,omitempty"`
 	Webhook      WebhookConfig `json:"webhook,omitempty"`
 }
 
 // Repository represents a Git repository configuration
 type Repository struct {
-	Name         string                       `json:"name"`
-	GitURL       string                       `json:"git_url"`
-	Branches     []string                     `json:"branches"`
-	ComposeFile  string                       `json:"compose_file,omitempty"`
-	BranchConfig map[string]BranchEnvironment `json:"branch_config,omitempty"`
-	AutoDeploy   bool                         `json:"auto_deploy,omitempty"`
-	Enabled      bool                         `json:"enabled,omitempty"`
+	Name            string                       `json:"name"`
+	GitURL          string                       `json:"git_url"`
+	Branches        []string                     `json:"branches" schema:"optional"`
+	ComposeFile     string                       `json:"compose_file,omitempty"`
+	BranchConfig    map[string]BranchEnvironment `json:"branch_config,omitempty"`
+	AutoDeploy      bool                         `json:"auto_deploy,omitempty"`
+	Enabled         bool                         `json:"enabled,omitempty"`
+	StrictConflicts bool                         `json:"strict_conflicts,omitempty"`
+	MaxConcurrent   int                          `json:"max_concurrent,omitempty"`
+	// DeployDefaultBranch deploys the remote default branch in addition to Branches
+	DeployDefaultBranch bool `json:"deploy_default_branch,omitempty"`
+	// SkipIfUnchanged skips a deploy when the incoming commit is already deployed and running
+	SkipIfUnchanged bool `json:"skip_if_unchanged,omitempty"`
+	// ComposeLint runs docker compose config before deploying: "warn" reports warnings, "strict" fails on them
+	ComposeLint string `json:"compose_lint,omitempty"`
+	// PreserveVolumes keeps existing containers across deploys so compose reuses their anonymous volumes
+	PreserveVolumes bool `json:"preserve_volumes,omitempty"`
+	// ComposeCommand overrides the detected compose command, e.g. "docker-compose" for a v1 project
+	ComposeCommand string `json:"compose_command,omitempty"`
+	// ExcludeBranches lists branch names or globs that are never deployed, even when otherwise matched
+	ExcludeBranches []string `json:"exclude_branches,omitempty"`
+	// TeardownOnDelete stops the projects and removes the checkout of a branch when it is deleted
+	TeardownOnDelete bool `json:"teardown_on_delete,omitempty"`
+	// TeardownDelay is the number of seconds to wait before a teardown, a push of the branch in between cancels it
+	TeardownDelay int `json:"teardown_delay,omitempty"`
+	// PullAlways re-pulls base and service images on every deploy
+	PullAlways bool `json:"pull_always,omitempty"`
+	// Secrets are fetched at deploy time and passed to compose as environment variables, never written to disk
+	Secrets []SecretRef `json:"secrets,omitempty"`
+	// VerifyRemoteURL rejects webhooks whose repository URLs do not match git_url, e.g. pushes to a fork with the same name
+	VerifyRemoteURL bool `json:"verify_remote_url,omitempty"`
+	// GitLabNamespace is the GitLab group path (e.g. group/subgroup) pushes must come from
+	GitLabNamespace string `json:"gitlab_namespace,omitempty"`
+	// CloneDepth is the history depth of clones and fetches, unset means 1 and 0 a full clone
+	CloneDepth *int `json:"clone_depth,omitempty"`
+	// RemoveOrphans passes --remove-orphans to compose up and down, unset means enabled
+	RemoveOrphans *bool `json:"remove_orphans,omitempty"`
+	// Worktrees checks branches out as git worktrees of one shared clone per repository instead of a clone each
+	Worktrees bool `json:"worktrees,omitempty"`
+	// DeployOn selects the webhook events that deploy: "push" (default), "merge" or "both"
+	DeployOn string `json:"deploy_on,omitempty"`
+	// Notify enables notifications about this repository, unset means enabled
+	Notify *bool `json:"notify,omitempty"`
+	// KeepImages keeps the images built for the last KeepImages deployed commits of each branch, 0 disables it
+	KeepImages int `json:"keep_images,omitempty"`
+	// Hosts are the docker hosts every deploy fans out to, empty deploys to the local daemon
+	Hosts []DeployHost `json:"hosts,omitempty"`
+	// HostsStrategy is "all_or_nothing" (default) to fail a deploy on the first failed host,
+	// or "best_effort" to deploy to every host and fail only when none succeeded
+	HostsStrategy string `json:"hosts_strategy,omitempty"`
+	// CommitStatus reports webhook deploys as a commit status to GitHub or GitLab, nil disables it
+	CommitStatus *CommitStatusConfig `json:"commit_status,omitempty"`
+}
+
+// CommitStatusConfig enables commit statuses for webhook deploys; the API token is read from TokenEnv or TokenFile
+type CommitStatusConfig struct {
+	TokenEnv  string `json:"token_env,omitempty"`
+	TokenFile string `json:"token_file,omitempty"`
+	// Context is the name of the status on the commit, uruflow/deploy when empty
+	Context string `json:"context,omitempty"`
+	// APIURL overrides the API base URL derived from git_url, e.g. https://github.example.com/api/v3
+	APIURL string `json:"api_url,omitempty"`
+}
+
+// DeployHost is a docker daemon a repository is deployed to, addressed by DOCKER_HOST or a docker context
+type DeployHost struct {
+	Name string `json:"name"`
+	// DockerHost is the daemon address, e.g. ssh://deploy@10.0.0.5 or tcp://10.0.0.5:2376
+	DockerHost string `json:"docker_host,omitempty"`
+	// Context is the name of a docker context created with docker context create
+	Context string `json:"context,omitempty"`
+	// IdentityFile is the SSH key of an ssh:// docker_host, the SSH client configuration of the user applies when empty
+	IdentityFile string `json:"identity_file,omitempty"`
+	// SSHOptions are extra ssh -o options of an ssh:// docker_host, e.g. StrictHostKeyChecking=accept-new
+	SSHOptions []string `json:"ssh_options,omitempty"`
+}
+
+// SecretRef names a secret injected into the compose environment and where to fetch it from
+type SecretRef struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"`
+	Key      string `json:"key,omitempty"`
 }
 
 // BranchEnvironment represents branch-specific configuration
 type BranchEnvironment struct {
+	ProjectName string        `json:"project_name,omitempty"`
+	AutoDeploy  *bool         `json:"auto_deploy,omitempty"`
+	Units       []ComposeUnit `json:"units,omitempty"`
+	// ComposeFile overrides the repository compose_file for this branch, empty falls back to it
+	ComposeFile string `json:"compose_file,omitempty"`
+	// EnvFile is a KEY=VALUE file on the host whose values are passed to compose for this branch only
+	EnvFile string `json:"env_file,omitempty"`
+	// Secrets are fetched for this branch only and override repository secrets of the same name
+	Secrets []SecretRef `json:"secrets,omitempty"`
+}
+
+// ComposeUnit is one of several compose projects deployed from the same branch
+type ComposeUnit struct {
+	Name        string `json:"name"`
+	ComposeFile string `json:"compose_file,omitempty"`
 	ProjectName string `json:"project_name,omitempty"`
 }
 
 // Settings represents application settings
 type Settings struct {
-	WorkDir        string `json:"work_dir,omitempty"`
-	MaxConcurrent  int    `json:"max_concurrent,omitempty"`
-	CleanupEnabled bool   `json:"cleanup_enabled,omitempty"`
-	AutoClone      bool   `json:"auto_clone,omitempty"`
+	WorkDir            string `json:"work_dir,omitempty"`
+	MaxConcurrent      int    `json:"max_concurrent,omitempty"`
+	CleanupEnabled     bool   `json:"cleanup_enabled,omitempty"`
+	AutoClone          bool   `json:"auto_clone,omitempty"`
+	ConflictRetries    int    `json:"conflict_retries,omitempty"`
+	ConflictRetryDelay int    `json:"conflict_retry_delay,omitempty"`
+	// FailOnInitError aborts server startup when auto_clone fails instead of starting without the repositories
+	FailOnInitError bool `json:"fail_on_init_error,omitempty"`
+	// ProactiveCleanup removes containers named like the project before the first compose up, not only after a conflict
+	ProactiveCleanup bool `json:"proactive_cleanup,omitempty"`
+	// LabelScopedCleanupOnly restricts every cleanup path to containers labelled with the deployed compose project
+	LabelScopedCleanupOnly bool `json:"label_scoped_cleanup_only,omitempty"`
+	// SelfHealInterval is the number of seconds between checks that redeploy crashed projects, 0 disables it
+	SelfHealInterval int `json:"self_heal_interval,omitempty"`
+	// DefaultBranchFallback deploys the remote default branch of repositories that list no branches
+	DefaultBranchFallback bool `json:"default_branch_fallback,omitempty"`
+	// NotificationURL receives JSON notifications such as init_failed events, empty disables notifications
+	NotificationURL string `json:"notification_url,omitempty"`
+	// NotificationTemplate is a text/template (or built-in template name) for the notification_url body
+	NotificationTemplate string `json:"notification_template,omitempty"`
+	// NotificationChannels are additional notification destinations, each with its own template
+	NotificationChannels []NotificationChannel `json:"notification_channels,omitempty"`
+	// CleanupWorkers is the number of containers removed in parallel during cleanup
+	CleanupWorkers int `json:"cleanup_workers,omitempty"`
+	// CircuitBreakerThreshold is the number of consecutive failures that pause deploys of a branch, 0 disables it
+	CircuitBreakerThreshold int `json:"circuit_breaker_threshold,omitempty"`
+	// CircuitBreakerCooldown is the number of seconds deploys stay paused once the circuit opens
+	CircuitBreakerCooldown int `json:"circuit_breaker_cooldown,omitempty"`
+	// MinFreeDiskMB is the free space the work dir and Docker data root need before a deploy, 0 disables the check
+	MinFreeDiskMB int `json:"min_free_disk_mb,omitempty"`
+	// PruneOnLowDisk runs the Docker cleanup when the disk space check fails and checks again
+	PruneOnLowDisk bool `json:"prune_on_low_disk,omitempty"`
+	// Prepull pulls and builds the images of every deployed branch in the background at startup
+	Prepull bool `json:"prepull,omitempty"`
+	// DeployTimeout is the number of seconds a deployment may take apart from building images
+	DeployTimeout int `json:"deploy_timeout,omitempty"`
+	// BuildTimeout is the number of seconds the image build of a deployment may take
+	BuildTimeout int `json:"build_timeout,omitempty"`
+	// RequireServices fails a deploy whose compose project has no services after up instead of only warning
+	RequireServices bool `json:"require_services,omitempty"`
+	// MaxJobDuration is the number of seconds after which a deployment still holding its job key is reaped, 0 disables it
+	MaxJobDuration int `json:"max_job_duration,omitempty"`
+	// SSHHosts are per-host SSH options (port, user, identity file) for Git hosts and host aliases
+	SSHHosts []SSHHost `json:"ssh_hosts,omitempty"`
+	// Registries are logged in to with docker login before deploys so private images can be pulled
+	Registries []RegistryCredential `json:"registries,omitempty"`
 }
 
 // WebhookConfig represents webhook server configuration
 type WebhookConfig struct {
-	Port   string `json:"port,omitempty"`
-	Path   string `json:"path,omitempty"`
-	Secret string `json:"secret,omitempty"`
+	Port           string   `json:"port,omitempty"`
+	Path           string   `json:"path,omitempty"`
+	Paths          []string `json:"paths,omitempty"`
+	Secret         string   `json:"secret,omitempty"`
+	TrustedProxies []string `json:"trusted_proxies,omitempty"`
+	APIToken       string   `json:"api_token,omitempty"`
+	// RequireSHA256 rejects legacy sha1= GitHub signatures
+	RequireSHA256 bool `json:"require_sha256,omitempty"`
+	// AllowUnsignedLocalhost skips signature validation for direct loopback requests, meant for local development only
+	AllowUnsignedLocalhost bool `json:"allow_unsigned_localhost,omitempty"`
+	// RespondImmediately answers valid webhooks with 202 Accepted and runs the deploy in the background
+	RespondImmediately bool `json:"respond_immediately,omitempty"`
+}
+
+// NotificationChannel is a generic outbound webhook notification destination
+type NotificationChannel struct {
+	URL      string            `json:"url"`
+	Method   string            `json:"method,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Template string            `json:"template,omitempty"`
+	// Retries is the number of retries of a failed request, unset means 3 and 0 no retry
+	Retries *int `json:"retries,omitempty"`
+}
+
+// SSHHost holds SSH options for a Git host; Host may be an alias used in git_url, like a Host block of ~/.ssh/config
+type SSHHost struct {
+	Host         string `json:"host"`
+	HostName     string `json:"hostname,omitempty"`
+	Port         int    `json:"port,omitempty"`
+	User         string `json:"user,omitempty"`
+	IdentityFile string `json:"identity_file,omitempty"`
+}
+
+// RegistryCredential is the login for a private image registry, an empty URL means Docker Hub.
+// The password is read from Password, the PasswordEnv variable or the PasswordFile file.
+type RegistryCredential struct {
+	URL          string `json:"url,omitempty"`
+	Username     string `json:"username"`
+	Password     string `json:"password,omitempty"`
+	PasswordEnv  string `json:"password_env,omitempty"`
+	PasswordFile string `json:"password_file,omitempty"`
 }
 
 // DeploymentJob represents a deployment task
@@ -71,7 +241,8 @@ type DeploymentJob struct {
 	Author     string
 }
 
-// GitHubWebhook represents the GitHub webhook payload
+// GitHubWebhook represents the GitHub webhook payload; GitLab payloads are parsed as GitLabWebhook
+// and normalized into it, so the webhook handler works the same for both providers
 type GitHubWebhook struct {
 	Ref        string `json:"ref"`
 	Before     string `json:"before"`
@@ -84,7 +255,21 @@ type GitHubWebhook struct {
 		CloneURL string `json:"clone_url"`
 		SSHURL   string `json:"ssh_url"`
 		HTMLURL  string `json:"html_url"`
+		// GitHTTPURL, GitSSHURL and Homepage are sent by GitLab
+		GitHTTPURL string `json:"git_http_url"`
+		GitSSHURL  string `json:"git_ssh_url"`
+		Homepage   string `json:"homepage"`
+		// DefaultBranch is sent by GitHub, GitLab sends it on the project object
+		DefaultBranch string `json:"default_branch"`
 	} `json:"repository"`
+	Project struct {
+		// PathWithNamespace is the full GitLab project path such as group/subgroup/app
+		PathWithNamespace string `json:"path_with_namespace"`
+		DefaultBranch     string `json:"default_branch"`
+		GitHTTPURL        string `json:"git_http_url"`
+		GitSSHURL         string `json:"git_ssh_url"`
+		WebURL            string `json:"web_url"`
+	} `json:"project"`
 	Pusher struct {
 		Name  string `json:"name"`
 		Email string `json:"email"`
@@ -95,50 +280,36 @@ type GitHubWebhook struct {
 		AvatarURL string `json:"avatar_url"`
 		Type      string `json:"type"`
 	} `json:"sender"`
-	Created bool   `json:"created"`
-	Deleted bool   `json:"deleted"`
-	Forced  bool   `json:"forced"`
-	Compare string `json:"compare"`
-	Commits []struct {
-		ID        string `json:"id"`
-		TreeID    string `json:"tree_id"`
-		Message   string `json:"message"`
-		Timestamp string `json:"timestamp"`
-		URL       string `json:"url"`
-		Author    struct {
-			Name     string `json:"name"`
-			Email    string `json:"email"`
-			Username string `json:"username,omitempty"`
-		} `json:"author"`
-		Committer struct {
-			Name     string `json:"name"`
-			Email    string `json:"email"`
-			Username string `json:"username,omitempty"`
-		} `json:"committer"`
-		Added    []string `json:"added"`
-		Removed  []string `json:"removed"`
-		Modified []string `json:"modified"`
-	} `json:"commits"`
-	HeadCommit struct {
-		ID        string `json:"id"`
-		TreeID    string `json:"tree_id"`
-		Message   string `json:"message"`
-		Timestamp string `json:"timestamp"`
-		URL       string `json:"url"`
-		Author    struct {
-			Name     string `json:"name"`
-			Email    string `json:"email"`
-			Username string `json:"username,omitempty"`
-		} `json:"author"`
-		Committer struct {
-			Name     string `json:"name"`
-			Email    string `json:"email"`
-			Username string `json:"username,omitempty"`
-		} `json:"committer"`
-		Added    []string `json:"added"`
-		Removed  []string `json:"removed"`
-		Modified []string `json:"modified"`
-	} `json:"head_commit"`
+	Created    bool            `json:"created"`
+	Deleted    bool            `json:"deleted"`
+	Forced     bool            `json:"forced"`
+	Compare    string          `json:"compare"`
+	Commits    []WebhookCommit `json:"commits"`
+	HeadCommit WebhookCommit   `json:"head_commit"`
+	// Event is "push" or "merge", set from the event type of the delivery
+	Event string `json:"-"`
+}
+
+// WebhookCommit is a commit of a push payload
+type WebhookCommit struct {
+	ID        string `json:"id"`
+	TreeID    string `json:"tree_id"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"`
+	URL       string `json:"url"`
+	Author    struct {
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Username string `json:"username,omitempty"`
+	} `json:"author"`
+	Committer struct {
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Username string `json:"username,omitempty"`
+	} `json:"committer"`
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"`
 }
 
 // GitLabWebhook represents the GitLab webhook payload
@@ -150,9 +321,11 @@ type GitLabWebhook struct {
 	CheckoutSHA string `json:"checkout_sha"`
 	UserID      int    `json:"user_id"`
 	UserName    string `json:"user_name"`
-	UserEmail   string `json:"user_email"`
-	UserAvatar  string `json:"user_avatar"`
-	Project     struct {
+	// UserUsername is the login of the pusher, UserName its display name
+	UserUsername string `json:"user_username"`
+	UserEmail    string `json:"user_email"`
+	UserAvatar   string `json:"user_avatar"`
+	Project      struct {
 		ID                int    `json:"id"`
 		Name              string `json:"name"`
 		Description       string `json:"description"`
@@ -169,6 +342,8 @@ type GitLabWebhook struct {
 		URL         string `json:"url"`
 		Description string `json:"description"`
 		Homepage    string `json:"homepage"`
+		GitHTTPURL  string `json:"git_http_url"`
+		GitSSHURL   string `json:"git_ssh_url"`
 	} `json:"repository"`
 	Commits []struct {
 		ID        string `json:"id"`
@@ -186,6 +361,47 @@ type GitLabWebhook struct {
 	TotalCommitsCount int `json:"total_commits_count"`
 }
 
+// GitHubPullRequestWebhook holds the pull request fields of a GitHub pull_request payload; its repository
+// and sender are read into GitHubWebhook from the same payload
+type GitHubPullRequestWebhook struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Title          string `json:"title"`
+		HTMLURL        string `json:"html_url"`
+		Merged         bool   `json:"merged"`
+		MergeCommitSHA string `json:"merge_commit_sha"`
+		Base           struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+		MergedBy struct {
+			Login string `json:"login"`
+		} `json:"merged_by"`
+	} `json:"pull_request"`
+}
+
+// GitLabMergeRequestWebhook holds the merge request fields of a GitLab merge_request payload; its project
+// and repository are read into GitLabWebhook from the same payload
+type GitLabMergeRequestWebhook struct {
+	User struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	} `json:"user"`
+	ObjectAttributes struct {
+		IID            int    `json:"iid"`
+		Title          string `json:"title"`
+		URL            string `json:"url"`
+		State          string `json:"state"`
+		Action         string `json:"action"`
+		TargetBranch   string `json:"target_branch"`
+		MergeCommitSHA string `json:"merge_commit_sha"`
+		LastCommit     struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+	} `json:"object_attributes"`
+}
+
 // DeploymentStatus represents the status of a deployment
 type DeploymentStatus struct {
 	Repository string    `json:"repository"`
@@ -200,6 +416,25 @@ type DeploymentStatus struct {
 	Services   []string  `json:"services,omitempty"`
 }
 
+// NotificationEvent represents a deployment lifecycle or notification event
+type NotificationEvent struct {
+	Event      string         `json:"event"`
+	Repository string         `json:"repository"`
+	Branch     string         `json:"branch"`
+	Stage      string         `json:"stage,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	Trigger    *DeployTrigger `json:"trigger,omitempty"`
+	Timestamp  time.Time      `json:"timestamp"`
+}
+
+// DeployTrigger describes what started a deployment
+type DeployTrigger struct {
+	// Source is webhook, manual or self_heal
+	Source    string `json:"source"`
+	Actor     string `json:"actor,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
 // HealthStatus represents the health check response
 type HealthStatus struct {
 	Status     string    `json:"status"`
@@ -0,0 +1,121 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"uruflow.com/internal/models"
+)
+
+// ErrCircuitOpen is returned when a branch failed too often in a row and its deploys are paused
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// circuitState tracks consecutive deployment failures of a repository branch
+type circuitState struct {
+	failures  int
+	openUntil time.Time
+}
+
+// CircuitOpenUntil reports whether the circuit of a branch is open and when it resets
+func (ds *DeploymentService) CircuitOpenUntil(repoName, branch string) (time.Time, bool) {
+	key := fmt.Sprintf("%s:%s", repoName, branch)
+
+	ds.circuitsMu.Lock()
+	defer ds.circuitsMu.Unlock()
+
+	state, exists := ds.circuits[key]
+	if !exists || state.openUntil.IsZero() {
+		return time.Time{}, false
+	}
+	if time.Now().After(state.openUntil) {
+		ds.logger.Info("Circuit breaker for %s reset after cooldown", key)
+		delete(ds.circuits, key)
+		return time.Time{}, false
+	}
+	return state.openUntil, true
+}
+
+// ResetCircuit closes the circuit of a branch and clears its failure count, it reports whether there was any state
+func (ds *DeploymentService) ResetCircuit(repoName, branch string) bool {
+	key := fmt.Sprintf("%s:%s", repoName, branch)
+
+	ds.circuitsMu.Lock()
+	defer ds.circuitsMu.Unlock()
+
+	if _, exists := ds.circuits[key]; !exists {
+		return false
+	}
+	delete(ds.circuits, key)
+	ds.logger.Info("Circuit breaker for %s reset manually", key)
+	return true
+}
+
+// recordFailure counts a failed deployment and opens the circuit once the threshold is reached
+func (ds *DeploymentService) recordFailure(repoName, branch string, deployErr error) {
+	threshold := ds.currentConfig().Settings.CircuitBreakerThreshold
+	if threshold <= 0 {
+		return
+	}
+	key := fmt.Sprintf("%s:%s", repoName, branch)
+
+	ds.circuitsMu.Lock()
+	state, exists := ds.circuits[key]
+	if !exists {
+		state = &circuitState{}
+		ds.circuits[key] = state
+	}
+	state.failures++
+	if state.failures < threshold || !state.openUntil.IsZero() {
+		ds.circuitsMu.Unlock()
+		return
+	}
+	cooldown := time.Duration(ds.currentConfig().Settings.CircuitBreakerCooldown) * time.Second
+	state.openUntil = time.Now().Add(cooldown)
+	failures := state.failures
+	ds.circuitsMu.Unlock()
+
+	ds.logger.Warning("Circuit breaker for %s opened after %d consecutive failures, deploys paused for %v", key, failures, cooldown)
+	if ds.notifier != nil {
+		ds.notifier.SendEvent(models.NotificationEvent{
+			Event:      "circuit_open",
+			Repository: repoName,
+			Branch:     branch,
+			Error:      deployErr.Error(),
+			Timestamp:  time.Now(),
+		})
+	}
+}
+
+// recordSuccess clears the failure count of a branch
+func (ds *DeploymentService) recordSuccess(repoName, branch string) {
+	key := fmt.Sprintf("%s:%s", repoName, branch)
+
+	ds.circuitsMu.Lock()
+	defer ds.circuitsMu.Unlock()
+
+	if state, exists := ds.circuits[key]; exists && state.failures > 0 {
+		ds.logger.Info("Circuit breaker for %s closed after a successful deployment", key)
+		delete(ds.circuits, key)
+	}
+}
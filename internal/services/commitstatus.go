@@ -0,0 +1,234 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"uruflow.com/internal/models"
+	"uruflow.com/internal/utils"
+)
+
+// defaultCommitStatusContext is the status name used when commit_status.context is empty
+const defaultCommitStatusContext = "uruflow/deploy"
+
+// maxStatusDescription is the longest description GitHub accepts on a commit status
+const maxStatusDescription = 140
+
+// CommitStatusTarget is the commit of a webhook deploy that its status is reported to
+type CommitStatusTarget struct {
+	// Provider is github or gitlab
+	Provider string
+	// APIURL is the API base, e.g. https://api.github.com or https://gitlab.example.com/api/v4
+	APIURL string
+	// Project is owner/repo on GitHub and the namespaced project path on GitLab
+	Project string
+	Commit  string
+}
+
+// commitStatusUpdate is a state to report with its description
+type commitStatusUpdate struct {
+	state       string
+	description string
+}
+
+// commitStatus reports the progress of one deploy to its commit, updates are posted in order in the background
+type commitStatus struct {
+	client  *http.Client
+	config  models.CommitStatusConfig
+	target  CommitStatusTarget
+	branch  string
+	logger  *utils.Logger
+	updates chan commitStatusUpdate
+}
+
+// ValidateCommitStatus checks the commit_status settings of a repository
+func ValidateCommitStatus(repo models.Repository) error {
+	status := repo.CommitStatus
+	if status == nil {
+		return nil
+	}
+	if (status.TokenEnv == "") == (status.TokenFile == "") {
+		return fmt.Errorf("commit_status of repository %s needs exactly one of token_env or token_file", repo.Name)
+	}
+	if status.APIURL != "" {
+		parsed, err := url.Parse(status.APIURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("invalid commit_status api_url %q for repository %s: must be an http(s) URL", status.APIURL, repo.Name)
+		}
+	}
+	return nil
+}
+
+// CommitStatusAPIURL returns the API base URL a provider is reached at for a Git host
+func CommitStatusAPIURL(provider, host string) string {
+	if provider == "gitlab" {
+		return "https://" + host + "/api/v4"
+	}
+	if strings.EqualFold(host, "github.com") {
+		return "https://api.github.com"
+	}
+	// GitHub Enterprise Server serves the REST API under /api/v3
+	return "https://" + host + "/api/v3"
+}
+
+// startCommitStatus reports a deploy as pending on its commit and returns the reporter to finish it with
+func (ds *DeploymentService) startCommitStatus(logger *utils.Logger, repo models.Repository, branch string, target CommitStatusTarget) *commitStatus {
+	cs := &commitStatus{
+		client:  ds.statusClient,
+		config:  *repo.CommitStatus,
+		target:  target,
+		branch:  branch,
+		logger:  logger,
+		updates: make(chan commitStatusUpdate, 2),
+	}
+	go cs.run()
+	cs.updates <- commitStatusUpdate{state: "pending", description: "Deploying " + branch}
+	return cs
+}
+
+// finish reports the outcome of the deploy; err is nil on success. A failure is posted without the error,
+// which may name hosts, paths or command output, it stays in the logs and the job.
+func (cs *commitStatus) finish(err error) {
+	update := commitStatusUpdate{state: "success", description: "Deployed " + cs.branch}
+	switch {
+	case errors.Is(err, ErrDeploymentCancelled):
+		update = commitStatusUpdate{state: "cancelled", description: "Deployment cancelled"}
+	case errors.Is(err, ErrDeploySkipped):
+		update = commitStatusUpdate{state: "skipped", description: "Already running on " + cs.branch + ", deploy skipped"}
+	case err != nil:
+		update = commitStatusUpdate{state: "failure", description: "Deployment failed"}
+	}
+	cs.updates <- update
+	close(cs.updates)
+}
+
+// run posts the queued updates; failures are logged, a status never fails the deploy
+func (cs *commitStatus) run() {
+	for update := range cs.updates {
+		if err := cs.post(update); err != nil {
+			cs.logger.Warning("Failed to report commit status %s for %s@%s: %v", update.state, cs.target.Project, shortCommit(cs.target.Commit), err)
+			continue
+		}
+		cs.logger.Info("Reported commit status %s for %s@%s", update.state, cs.target.Project, shortCommit(cs.target.Commit))
+	}
+}
+
+// post sends one status to the GitHub statuses API or the GitLab commit status API
+func (cs *commitStatus) post(update commitStatusUpdate) error {
+	token, err := cs.token()
+	if err != nil {
+		return fmt.Errorf("could not read token: %v", err)
+	}
+	if token == "" {
+		return fmt.Errorf("token is empty")
+	}
+
+	name := cs.config.Context
+	if name == "" {
+		name = defaultCommitStatusContext
+	}
+	description := update.description
+	if runes := []rune(description); len(runes) > maxStatusDescription {
+		description = string(runes[:maxStatusDescription-3]) + "..."
+	}
+	apiURL := strings.TrimSuffix(cs.target.APIURL, "/")
+
+	var endpoint string
+	var payload map[string]string
+	switch cs.target.Provider {
+	case "gitlab":
+		endpoint = fmt.Sprintf("%s/projects/%s/statuses/%s", apiURL, url.PathEscape(cs.target.Project), cs.target.Commit)
+		payload = map[string]string{"state": gitLabState(update.state), "name": name, "ref": cs.branch, "description": description}
+	default:
+		endpoint = fmt.Sprintf("%s/repos/%s/statuses/%s", apiURL, cs.target.Project, cs.target.Commit)
+		payload = map[string]string{"state": gitHubState(update.state), "context": name, "description": description}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cs.target.Provider == "gitlab" {
+		req.Header.Set("PRIVATE-TOKEN", token)
+	} else {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := cs.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s returned %s: %s", cs.target.Provider, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// token reads the API token from its configured source
+func (cs *commitStatus) token() (string, error) {
+	if cs.config.TokenEnv != "" {
+		return EnvSecretProvider{}.Fetch(context.Background(), cs.config.TokenEnv)
+	}
+	return FileSecretProvider{}.Fetch(context.Background(), cs.config.TokenFile)
+}
+
+// gitHubState maps a status to the states of the GitHub statuses API
+func gitHubState(state string) string {
+	switch state {
+	case "cancelled":
+		return "error"
+	case "skipped":
+		// GitHub has no skipped state, the commit is running so the status is not a failure
+		return "success"
+	default:
+		return state
+	}
+}
+
+// gitLabState maps a status to the states of the GitLab commit status API
+func gitLabState(state string) string {
+	switch state {
+	case "pending":
+		return "running"
+	case "failure":
+		return "failed"
+	case "cancelled":
+		return "canceled"
+	default:
+		return state
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"uruflow.com/internal/models"
+)
+
+// postedStatus is a commit status request received by the test API
+type postedStatus struct {
+	path    string
+	token   string
+	payload map[string]string
+}
+
+// newStatusAPI serves the commit status endpoints and records the posted statuses
+func newStatusAPI(t *testing.T) (*httptest.Server, chan postedStatus) {
+	t.Helper()
+	posted := make(chan postedStatus, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := postedStatus{path: r.URL.EscapedPath(), token: r.Header.Get("PRIVATE-TOKEN")}
+		if status.token == "" {
+			status.token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if err := json.NewDecoder(r.Body).Decode(&status.payload); err != nil {
+			t.Errorf("invalid status body: %v", err)
+		}
+		posted <- status
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+	return server, posted
+}
+
+func TestCommitStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		project  string
+		path     string
+		err      error
+		pending  map[string]string
+		final    map[string]string
+	}{
+		{
+			"github success", "github", "acme/api", "/repos/acme/api/statuses/abc123", nil,
+			map[string]string{"state": "pending", "context": "uruflow/deploy", "description": "Deploying main"},
+			map[string]string{"state": "success", "context": "uruflow/deploy", "description": "Deployed main"},
+		},
+		{
+			"github failure", "github", "acme/api", "/repos/acme/api/statuses/abc123", errors.New("compose up failed on 10.0.0.5: /srv/api/.env"),
+			map[string]string{"state": "pending", "context": "uruflow/deploy", "description": "Deploying main"},
+			map[string]string{"state": "failure", "context": "uruflow/deploy", "description": "Deployment failed"},
+		},
+		{
+			"github cancelled", "github", "acme/api", "/repos/acme/api/statuses/abc123", ErrDeploymentCancelled,
+			map[string]string{"state": "pending", "context": "uruflow/deploy", "description": "Deploying main"},
+			map[string]string{"state": "error", "context": "uruflow/deploy", "description": "Deployment cancelled"},
+		},
+		{
+			"github skipped", "github", "acme/api", "/repos/acme/api/statuses/abc123", ErrDeploySkipped,
+			map[string]string{"state": "pending", "context": "uruflow/deploy", "description": "Deploying main"},
+			map[string]string{"state": "success", "context": "uruflow/deploy", "description": "Already running on main, deploy skipped"},
+		},
+		{
+			"gitlab success", "gitlab", "group/sub/api", "/projects/group%2Fsub%2Fapi/statuses/abc123", nil,
+			map[string]string{"state": "running", "name": "uruflow/deploy", "ref": "main", "description": "Deploying main"},
+			map[string]string{"state": "success", "name": "uruflow/deploy", "ref": "main", "description": "Deployed main"},
+		},
+		{
+			"gitlab failure", "gitlab", "group/sub/api", "/projects/group%2Fsub%2Fapi/statuses/abc123", errors.New("build failed"),
+			map[string]string{"state": "running", "name": "uruflow/deploy", "ref": "main", "description": "Deploying main"},
+			map[string]string{"state": "failed", "name": "uruflow/deploy", "ref": "main", "description": "Deployment failed"},
+		},
+		{
+			"gitlab cancelled", "gitlab", "group/sub/api", "/projects/group%2Fsub%2Fapi/statuses/abc123", ErrDeploymentCancelled,
+			map[string]string{"state": "running", "name": "uruflow/deploy", "ref": "main", "description": "Deploying main"},
+			map[string]string{"state": "canceled", "name": "uruflow/deploy", "ref": "main", "description": "Deployment cancelled"},
+		},
+		{
+			"gitlab skipped", "gitlab", "group/sub/api", "/projects/group%2Fsub%2Fapi/statuses/abc123", ErrDeploySkipped,
+			map[string]string{"state": "running", "name": "uruflow/deploy", "ref": "main", "description": "Deploying main"},
+			map[string]string{"state": "skipped", "name": "uruflow/deploy", "ref": "main", "description": "Already running on main, deploy skipped"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, posted := newStatusAPI(t)
+			t.Setenv("UF_STATUS_TOKEN", "status-token")
+			ds := &DeploymentService{statusClient: server.Client()}
+			repo := models.Repository{Name: "api", CommitStatus: &models.CommitStatusConfig{TokenEnv: "UF_STATUS_TOKEN"}}
+			target := CommitStatusTarget{Provider: tt.provider, APIURL: server.URL + "/", Project: tt.project, Commit: "abc123"}
+
+			ds.startCommitStatus(newTestLogger(t), repo, "main", target).finish(tt.err)
+
+			for _, want := range []map[string]string{tt.pending, tt.final} {
+				status := <-posted
+				if status.path != tt.path {
+					t.Errorf("expected path %s, got %s", tt.path, status.path)
+				}
+				if status.token != "status-token" {
+					t.Errorf("expected token status-token, got %q", status.token)
+				}
+				if len(status.payload) != len(want) {
+					t.Errorf("expected payload %v, got %v", want, status.payload)
+				}
+				for key, value := range want {
+					if status.payload[key] != value {
+						t.Errorf("expected %s %q, got %q", key, value, status.payload[key])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCommitStatusDescriptionTruncated(t *testing.T) {
+	server, posted := newStatusAPI(t)
+	t.Setenv("UF_STATUS_TOKEN", "status-token")
+	cs := &commitStatus{
+		client: server.Client(),
+		config: models.CommitStatusConfig{TokenEnv: "UF_STATUS_TOKEN", Context: "deploy/prod"},
+		target: CommitStatusTarget{Provider: "github", APIURL: server.URL, Project: "acme/api", Commit: "abc123"},
+		branch: "main",
+	}
+
+	description := "Deploying " + strings.Repeat("ü", 200)
+	if err := cs.post(commitStatusUpdate{state: "pending", description: description}); err != nil {
+		t.Fatal(err)
+	}
+	status := <-posted
+	got := []rune(status.payload["description"])
+	if len(got) != maxStatusDescription {
+		t.Fatalf("expected a description of %d characters, got %d", maxStatusDescription, len(got))
+	}
+	if want := string([]rune(description)[:maxStatusDescription-3]) + "..."; string(got) != want {
+		t.Fatalf("expected description %q, got %q", want, string(got))
+	}
+	if status.payload["context"] != "deploy/prod" {
+		t.Fatalf("expected context deploy/prod, got %q", status.payload["context"])
+	}
+}
@@ -24,37 +24,103 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"net/http"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"uruflow.com/internal/models"
 	"uruflow.com/internal/utils"
 )
 
+// ErrDeploymentCancelled is the cause attached to the context of a deployment cancelled on request
+var ErrDeploymentCancelled = errors.New("deployment cancelled")
+
+// ErrNoActiveDeployment is returned when cancelling a deployment that is not running
+var ErrNoActiveDeployment = errors.New("no active deployment")
+
+// ErrDeploySkipped is returned when skip_if_unchanged skips a deploy of a running checkout without new commits
+var ErrDeploySkipped = errors.New("deployment skipped")
+
+// ErrInsufficientDiskSpace is returned when a deploy is refused because a filesystem is below min_free_disk_mb
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space")
+
+// maxEventSubscribers caps the concurrent subscribers of the deployment event stream
+const maxEventSubscribers = 16
+
+// ProgressFunc receives the name of each deployment stage as it starts
+type ProgressFunc func(stage string)
+
+// DeployOptions are optional parameters of a deployment
+type DeployOptions struct {
+	// Progress receives each deployment stage, it may be nil
+	Progress ProgressFunc
+	// Services limits the deployment to these compose services, empty deploys every service
+	Services []string
+	// Trigger records what started the deployment for logs and events
+	Trigger models.DeployTrigger
+	// CommitStatus is the commit the result is reported to when the repository has commit_status, it may be nil
+	CommitStatus *CommitStatusTarget
+
+	// jobID is the tracked job of the deployment, created by Enqueue or DeployDirectWithOptions
+	jobID string
+	// config is the configuration snapshot the whole deployment runs with, so a reload cannot change it midway
+	config *models.Config
+}
+
 // DockerDeployer interface
 type DockerDeployer interface {
 	Deploy(repo models.Repository, branch string, repoPath string) ([]string, error)
 	DeployWithContext(ctx context.Context, repo models.Repository, branch string, repoPath string) ([]string, error)
+	DeployServicesWithContext(ctx context.Context, repo models.Repository, branch string, repoPath string, only []string) ([]string, error)
+	BuildServicesWithContext(ctx context.Context, repo models.Repository, branch string, repoPath string, only []string) error
+	StartServicesWithContext(ctx context.Context, repo models.Repository, branch string, repoPath string, only []string) ([]string, error)
+	Stop(repo models.Repository, branch string, repoPath string) error
+	LintCompose(ctx context.Context, repo models.Repository, branch string, repoPath string) ([]string, error)
+	DockerRootDir() (string, error)
+	GetRunningProjectContainers(repo models.Repository, branch string) ([]string, error)
+	GetFailedProjectContainers(repo models.Repository, branch string) ([]string, error)
 	Cleanup() error
+	ForHost(host models.DeployHost) DockerDeployer
+	RestoreImages(ctx context.Context, repo models.Repository, branch string, repoPath string, commit string) (bool, error)
+	RetainImages(ctx context.Context, repo models.Repository, branch string, repoPath string, commit string, keep int) error
 }
 
 // DeploymentService manages direct deployment with smart auto-initialization
 type DeploymentService struct {
 	config            *models.Config
+	configMu          sync.RWMutex
 	repositoryService *RepositoryService
 	gitService        *GitService
 	dockerService     DockerDeployer
-	activeJobs        map[string]bool
+	activeJobs        map[string]*activeJob
 	activeJobsMu      sync.RWMutex
 	logger            *utils.Logger
-	buildMutex        sync.Mutex
-	totalJobs         int64
-	completedJobs     int64
-	failedJobs        int64
-	metricsMu         sync.RWMutex
+	globalSlots       chan struct{}
+	globalSlotsMu     sync.Mutex
+	repoSlots         map[string]chan struct{}
+	repoSlotsMu       sync.Mutex
+	totalJobs         atomic.Int64
+	completedJobs     atomic.Int64
+	failedJobs        atomic.Int64
+	cancelledJobs     atomic.Int64
+	skippedJobs       atomic.Int64
+	lintWarnings      map[string][]string
+	lintMu            sync.RWMutex
+	events            *EventBus
+	notifier          *NotificationService
+	circuits          map[string]*circuitState
+	circuitsMu        sync.Mutex
+	reapedJobs        atomic.Int64
+	jobs              map[string]*Job
+	jobOrder          []string
+	jobsMu            sync.Mutex
+	statusClient      *http.Client
+	results           map[string]*BranchResult
+	resultsMu         sync.Mutex
 }
 
 // NewDeploymentService creates a new deployment service with smart auto-initialization
@@ -65,118 +131,636 @@ func NewDeploymentService(
 	dockerService DockerDeployer,
 	logger *utils.Logger,
 ) *DeploymentService {
+	maxConcurrent := config.Settings.MaxConcurrent
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
 	ds := &DeploymentService{
 		config:            config,
 		repositoryService: repositoryService,
 		gitService:        gitService,
 		dockerService:     dockerService,
-		activeJobs:        make(map[string]bool),
+		activeJobs:        make(map[string]*activeJob),
 		logger:            logger,
+		globalSlots:       make(chan struct{}, maxConcurrent),
+		repoSlots:         make(map[string]chan struct{}),
+		lintWarnings:      make(map[string][]string),
+		events:            NewEventBus(maxEventSubscribers),
+		circuits:          make(map[string]*circuitState),
+		jobs:              make(map[string]*Job),
+		statusClient:      &http.Client{Timeout: 10 * time.Second},
+		results:           make(map[string]*BranchResult),
 	}
 
 	ds.logger.Success("Deployment service started with smart auto-initialization")
 	return ds
 }
 
+// UpdateConfig updates the configuration reference; deployments in flight keep the configuration they started with
+func (ds *DeploymentService) UpdateConfig(config *models.Config) {
+	ds.configMu.Lock()
+	defer ds.configMu.Unlock()
+	ds.config = config
+}
+
+// currentConfig returns the configuration in use; a reload swaps the pointer, the returned config is never modified
+func (ds *DeploymentService) currentConfig() *models.Config {
+	ds.configMu.RLock()
+	defer ds.configMu.RUnlock()
+	return ds.config
+}
+
+// SetNotifier sets the service notified when a circuit breaker opens
+func (ds *DeploymentService) SetNotifier(notifier *NotificationService) {
+	ds.notifier = notifier
+}
+
 // DeployDirect performs direct deployment with smart auto-initialization
 func (ds *DeploymentService) DeployDirect(repo models.Repository, branch string) error {
+	return ds.DeployDirectWithContext(context.Background(), repo, branch)
+}
+
+// DeployDirectWithContext performs direct deployment; the deployment stops when ctx is done or CancelDeployment is called
+func (ds *DeploymentService) DeployDirectWithContext(ctx context.Context, repo models.Repository, branch string) error {
+	return ds.DeployDirectWithProgress(ctx, repo, branch, nil)
+}
+
+// DeployDirectWithProgress performs direct deployment, reporting each stage to progress (which may be nil)
+func (ds *DeploymentService) DeployDirectWithProgress(ctx context.Context, repo models.Repository, branch string, progress ProgressFunc) error {
+	return ds.DeployDirectWithOptions(ctx, repo, branch, DeployOptions{Progress: progress})
+}
+
+// DeployDirectWithOptions performs direct deployment with the given options, waiting for it to finish;
+// use Enqueue to run it in the background and track it by job ID
+func (ds *DeploymentService) DeployDirectWithOptions(ctx context.Context, repo models.Repository, branch string, opts DeployOptions) (err error) {
+	if opts.jobID == "" {
+		opts.jobID = ds.createJob(repo.Name, branch, opts.Trigger)
+	}
+	if opts.config == nil {
+		opts.config = ds.currentConfig()
+	}
+	// the service log lines of the deploy carry the webhook request ID, manual deploys use their job ID
+	requestID := opts.Trigger.RequestID
+	if requestID == "" {
+		requestID = "local-" + strings.TrimPrefix(opts.jobID, "job-")
+	}
+	logger := ds.logger.With(requestID)
+	ctx = withLogger(ctx, logger)
+	defer func() {
+		if rec := recover(); rec != nil {
+			ds.finishJob(opts.jobID, fmt.Errorf("deployment panicked: %v", rec))
+			panic(rec)
+		}
+		ds.finishJob(opts.jobID, err)
+	}()
+
+	report := opts.Progress
+	progress := func(stage string) {
+		ds.publish("stage", repo.Name, branch, stage, opts.Trigger, nil)
+		if report != nil {
+			report(stage)
+		}
+	}
+
 	jobKey := fmt.Sprintf("%s:%s", repo.Name, branch)
+	if openUntil, open := ds.CircuitOpenUntil(repo.Name, branch); open {
+		return fmt.Errorf("%w for %s until %s", ErrCircuitOpen, jobKey, openUntil.Format(time.RFC3339))
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
 	ds.activeJobsMu.Lock()
-	if ds.activeJobs[jobKey] {
+	if _, exists := ds.activeJobs[jobKey]; exists {
 		ds.activeJobsMu.Unlock()
 		return fmt.Errorf("deployment already in progress for %s", jobKey)
 	}
-	ds.activeJobs[jobKey] = true
+	job := &activeJob{cancel: cancel, started: time.Now()}
+	ds.activeJobs[jobKey] = job
 	ds.activeJobsMu.Unlock()
+	// registered before anything else can panic so the job key is always released
+	defer ds.releaseJob(jobKey, job)
 	defer func() {
-		ds.activeJobsMu.Lock()
-		delete(ds.activeJobs, jobKey)
-		ds.activeJobsMu.Unlock()
+		// a deploy updates the checkout, cached status checks are stale
+		ds.repositoryService.InvalidateStatus(repo.Name, branch)
 	}()
+	ds.publish("queued", repo.Name, branch, "", opts.Trigger, nil)
+	if opts.CommitStatus != nil && repo.CommitStatus != nil {
+		status := ds.startCommitStatus(logger, repo, branch, *opts.CommitStatus)
+		defer func() { status.finish(err) }()
+	}
 
 	startTime := time.Now()
-	ds.logger.Deploy("Starting deployment: %s", jobKey)
-
-	if !ds.repositoryService.IsRepositoryInitialized(repo.Name, branch) {
-		ds.logger.Info("Repository not initialized, setting up automatically...")
-		if err := ds.repositoryService.InitializeRepository(repo, branch); err != nil {
-			ds.logger.Error("Auto-initialization failed: %v", err)
-			ds.metricsMu.Lock()
-			ds.failedJobs++
-			ds.metricsMu.Unlock()
-			return fmt.Errorf("auto-initialization failed: %v", err)
+	logger.Deploy("Starting deployment: %s%s", jobKey, describeTrigger(opts.Trigger))
+	// counted before auto-initialization, whose failures count as failed jobs too
+	ds.totalJobs.Add(1)
+
+	if !ds.repositoryService.IsRepositoryInitializedFresh(repo.Name, branch) {
+		progress("Initializing repository")
+		logger.Info("Repository not initialized, setting up automatically...")
+		if err := ds.repositoryService.InitializeRepositoryWithContext(ctx, repo, branch); err != nil {
+			logger.Error("Auto-initialization failed: %v", err)
+			ds.failedJobs.Add(1)
+			err = fmt.Errorf("auto-initialization failed: %v", err)
+			ds.publish("failed", repo.Name, branch, "", opts.Trigger, err)
+			ds.recordFailure(repo.Name, branch, err)
+			ds.recordResult(repo.Name, branch, err)
+			return err
 		}
-		ds.logger.Success("Repository %s:%s auto-initialized successfully", repo.Name, branch)
+		logger.Success("Repository %s:%s auto-initialized successfully", repo.Name, branch)
 	}
 
-	ds.metricsMu.Lock()
-	ds.totalJobs++
-	ds.metricsMu.Unlock()
-
-	if err := ds.executeSmartDeployment(repo, branch); err != nil {
+	opts.Progress = progress
+	if err := ds.executeSmartDeployment(ctx, repo, branch, opts); err != nil {
 		duration := time.Since(startTime)
-		ds.logger.Error("Deployment failed after %v: %v", duration.Round(time.Second), err)
-
-		ds.metricsMu.Lock()
-		ds.failedJobs++
-		ds.metricsMu.Unlock()
+		if errors.Is(err, ErrDeploySkipped) {
+			// nothing was deployed, so the success counters and the last successful deploy stay as they are
+			logger.Deploy("Deployment skipped: %s (after %v)%s", jobKey, duration.Round(time.Second), describeTrigger(opts.Trigger))
+			ds.skippedJobs.Add(1)
+			ds.publish("skipped", repo.Name, branch, "", opts.Trigger, nil)
+			return err
+		}
+		if errors.Is(context.Cause(ctx), ErrDeploymentCancelled) {
+			logger.Warning("Deployment cancelled: %s (after %v)%s", jobKey, duration.Round(time.Second), describeTrigger(opts.Trigger))
+			ds.cancelledJobs.Add(1)
+			ds.publish("cancelled", repo.Name, branch, "", opts.Trigger, nil)
+			return fmt.Errorf("%w: %s", ErrDeploymentCancelled, jobKey)
+		}
+		logger.Error("Deployment of %s failed after %v%s: %v", jobKey, duration.Round(time.Second), describeTrigger(opts.Trigger), err)
+		ds.failedJobs.Add(1)
+		ds.publish("failed", repo.Name, branch, "", opts.Trigger, err)
+		ds.recordFailure(repo.Name, branch, err)
+		ds.recordResult(repo.Name, branch, err)
 
 		return err
 	}
 
 	duration := time.Since(startTime)
-	ds.logger.Success("Deployment completed: %s (took %v)", jobKey, duration.Round(time.Second))
+	logger.Success("Deployment completed: %s (took %v)%s", jobKey, duration.Round(time.Second), describeTrigger(opts.Trigger))
+	ds.completedJobs.Add(1)
+	ds.publish("succeeded", repo.Name, branch, "", opts.Trigger, nil)
+	ds.recordSuccess(repo.Name, branch)
+	ds.recordResult(repo.Name, branch, nil)
 
-	ds.metricsMu.Lock()
-	ds.completedJobs++
-	ds.metricsMu.Unlock()
+	return nil
+}
+
+// describeTrigger formats a deploy trigger for log lines, empty when the trigger is unknown
+func describeTrigger(trigger models.DeployTrigger) string {
+	if trigger.Source == "" {
+		return ""
+	}
+	description := " [trigger: " + trigger.Source
+	if trigger.Actor != "" {
+		description += " by " + trigger.Actor
+	}
+	if trigger.RequestID != "" {
+		description += ", request " + trigger.RequestID
+	}
+	return description + "]"
+}
+
+// Events returns the bus carrying the deployment lifecycle events
+func (ds *DeploymentService) Events() *EventBus {
+	return ds.events
+}
+
+// publish sends a deployment lifecycle event to the event bus
+func (ds *DeploymentService) publish(event, repoName, branch, stage string, trigger models.DeployTrigger, err error) {
+	e := models.NotificationEvent{
+		Event:      event,
+		Repository: repoName,
+		Branch:     branch,
+		Stage:      stage,
+		Timestamp:  time.Now(),
+	}
+	if trigger.Source != "" {
+		e.Trigger = &trigger
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	ds.events.Publish(e)
+}
 
+// CancelDeployment cancels the in-flight deployment of a repository branch, killing its running git/compose process
+func (ds *DeploymentService) CancelDeployment(repoName, branch string) error {
+	jobKey := fmt.Sprintf("%s:%s", repoName, branch)
+
+	ds.activeJobsMu.RLock()
+	job, exists := ds.activeJobs[jobKey]
+	ds.activeJobsMu.RUnlock()
+	if !exists {
+		return fmt.Errorf("%w for %s", ErrNoActiveDeployment, jobKey)
+	}
+
+	ds.logger.Warning("Cancellation requested for deployment: %s", jobKey)
+	job.cancel(ErrDeploymentCancelled)
 	return nil
 }
 
 // executeSmartDeployment performs deployment with intelligent repository handling
-func (ds *DeploymentService) executeSmartDeployment(repo models.Repository, branch string) error {
-	ds.buildMutex.Lock()
-	defer ds.buildMutex.Unlock()
+func (ds *DeploymentService) executeSmartDeployment(ctx context.Context, repo models.Repository, branch string, opts DeployOptions) (err error) {
+	config := opts.config
+	logger := loggerFrom(ctx, ds.logger)
+	progress := opts.Progress
+	progress("Waiting for deployment slot")
+	release, err := ds.acquireSlots(ctx, repo, branch)
+	if err != nil {
+		return err
+	}
+	defer release()
+	ds.publish("started", repo.Name, branch, "", opts.Trigger, nil)
+	startedAt := time.Now()
+	ds.updateJob(opts.jobID, func(job *Job) {
+		job.Status = JobRunning
+		job.StartedAt = &startedAt
+	})
+
+	// deploy_timeout covers every phase except the image build, which is limited by build_timeout
+	budget := newDeployBudget(config.Settings.DeployTimeout)
+	phaseCtx, endPhase := budget.phase(ctx)
+	defer func() {
+		endPhase()
+		err = timeoutError(phaseCtx, budget.limit, err)
+	}()
 
-	repoPath := filepath.Join(ds.config.Settings.WorkDir, repo.Name, branch)
+	repoPath, err := RepositoryPath(config.Settings.WorkDir, repo.Name, branch)
+	if err != nil {
+		return err
+	}
+
+	if config.Settings.MinFreeDiskMB > 0 {
+		progress("Checking disk space")
+		if err := ds.checkDiskSpace(config); err != nil {
+			return err
+		}
+	}
 
 	// Additional validation: ensure repository is still valid after initialization
-	if !ds.repositoryService.IsRepositoryInitialized(repo.Name, branch) {
+	if !ds.repositoryService.IsRepositoryInitializedFresh(repo.Name, branch) {
 		return fmt.Errorf("repository validation failed: %s:%s not properly initialized", repo.Name, branch)
 	}
 
 	// Update repository to latest changes
-	ds.logger.Deploy("Updating repository %s:%s to latest changes", repo.Name, branch)
-	if err := ds.gitService.SetupRepository(repo, branch, repoPath); err != nil {
+	progress("Updating repository")
+	logger.Deploy("Updating repository %s:%s to latest changes", repo.Name, branch)
+	previousCommit := ds.currentCommit(repoPath)
+	if err := ds.gitService.SetupRepositoryWithContext(phaseCtx, repo, branch, repoPath); err != nil {
 		return fmt.Errorf("repository update failed: %v", err)
 	}
+	commit := ds.currentCommit(repoPath)
+	ds.updateJob(opts.jobID, func(job *Job) { job.Commit = commit })
+	if commit != "" && commit == previousCommit {
+		if repo.SkipIfUnchanged && len(opts.Services) == 0 && ds.isProjectHealthy(repo, branch) {
+			logger.Deploy("No new commits on %s:%s (at %s) and containers are running, skipping deployment (skip_if_unchanged)",
+				repo.Name, branch, shortCommit(commit))
+			progress("Skipped, already up to date")
+			return fmt.Errorf("%w: %s:%s is already running %s", ErrDeploySkipped, repo.Name, branch, shortCommit(commit))
+		}
+		logger.Deploy("No new commits on %s:%s (at %s), redeploying existing code", repo.Name, branch, shortCommit(commit))
+	} else if commit != "" {
+		logger.Deploy("Updated %s:%s from %s to %s", repo.Name, branch, shortCommit(previousCommit), shortCommit(commit))
+	}
+
+	// Verify compose files exist after update
+	progress("Verifying compose file")
+	units, err := ComposeUnits(repo, branch, repoPath)
+	if err != nil {
+		return fmt.Errorf("docker-compose file not found after update: %v", err)
+	}
+	if err := verifyComposeUnits(units, repoPath); err != nil {
+		return fmt.Errorf("%v after update", err)
+	}
+	for _, unit := range units {
+		logger.Deploy("Verified docker-compose file: %s (project: %s)", unit.ComposeFile, unit.ProjectName)
+	}
+
+	if repo.ComposeLint != "" {
+		progress("Linting compose file")
+		if err := ds.lintCompose(phaseCtx, repo, branch, repoPath); err != nil {
+			return err
+		}
+	}
+
+	endPhase()
+	if len(repo.Hosts) > 0 {
+		return ds.deployToHosts(ctx, budget, repo, branch, repoPath, opts)
+	}
+	_, err = ds.deployOnDaemon(ctx, budget, ds.dockerService, "", repo, branch, repoPath, opts)
+	return err
+}
+
+// deployOnDaemon builds and starts the services of a branch on one docker daemon, host names the
+// daemon in logs and is empty for the local one
+func (ds *DeploymentService) deployOnDaemon(ctx context.Context, budget *deployBudget, docker DockerDeployer, host string, repo models.Repository, branch, repoPath string, opts DeployOptions) ([]string, error) {
+	config := opts.config
+	logger := loggerFrom(ctx, ds.logger)
+	progress := opts.Progress
+	onHost := ""
+	if host != "" {
+		onHost = " on " + host
+	}
+
+	// images are kept per commit for full deploys only, a partial deploy builds just some of them
+	commit := ""
+	if repo.KeepImages > 0 && len(opts.Services) == 0 {
+		commit = ds.currentCommit(repoPath)
+	}
 
-	// Verify compose file exists after update
-	composeFile := filepath.Join(repoPath, repo.ComposeFile)
-	if _, err := os.Stat(composeFile); os.IsNotExist(err) {
-		return fmt.Errorf("docker-compose file not found after update: %s", repo.ComposeFile)
+	restored := false
+	if commit != "" && !repo.PullAlways {
+		var err error
+		if restored, err = docker.RestoreImages(ctx, repo, branch, repoPath, commit); err != nil {
+			logger.Warning("Could not reuse the kept images of %s, building: %v", shortCommit(commit), err)
+		} else if restored {
+			logger.Deploy("Reusing the images kept for commit %s%s, skipping the build", shortCommit(commit), onHost)
+		}
 	}
-	ds.logger.Deploy("Verified docker-compose file: %s", repo.ComposeFile)
 
-	ds.logger.Deploy("Starting Docker deployment")
-	services, err := ds.dockerService.Deploy(repo, branch, repoPath)
+	if !restored {
+		progress("Building images" + onHost)
+		buildTimeout := time.Duration(config.Settings.BuildTimeout) * time.Second
+		logger.Deploy("Building images for %s:%s%s (build_timeout %v)", repo.Name, branch, onHost, buildTimeout)
+		buildCtx, cancelBuild := buildContext(ctx, config.Settings.BuildTimeout)
+		err := docker.BuildServicesWithContext(buildCtx, repo, branch, repoPath, opts.Services)
+		cancelBuild()
+		if err != nil {
+			if errors.Is(context.Cause(buildCtx), ErrBuildTimeout) {
+				logger.Error("Image build for %s:%s%s killed after build_timeout of %v", repo.Name, branch, onHost, buildTimeout)
+			}
+			return nil, fmt.Errorf("image build failed: %v", timeoutError(buildCtx, buildTimeout, err))
+		}
+	}
+
+	phaseCtx, endPhase := budget.phase(ctx)
+	defer endPhase()
+	progress("Starting containers" + onHost)
+	logger.Deploy("Starting Docker deployment%s", onHost)
+	services, err := docker.StartServicesWithContext(phaseCtx, repo, branch, repoPath, opts.Services)
 	if err != nil {
-		return fmt.Errorf("docker deployment failed: %v", err)
+		if phaseCtx.Err() != nil {
+			// a compose run killed halfway leaves a partially started project behind
+			logger.Deploy("Removing partially started services for %s:%s%s", repo.Name, branch, onHost)
+			if stopErr := docker.Stop(repo, branch, repoPath); stopErr != nil {
+				logger.Warning("Failed to remove partial deployment: %v", stopErr)
+			}
+		}
+		return nil, timeoutError(phaseCtx, budget.limit, fmt.Errorf("docker deployment failed: %v", err))
+	}
+
+	logger.Success("Deployed %d services for %s:%s%s: %v", len(services), repo.Name, branch, onHost, services)
+
+	if commit != "" {
+		if err := docker.RetainImages(ctx, repo, branch, repoPath, commit, repo.KeepImages); err != nil {
+			logger.Warning("Failed to keep the images of %s: %v", shortCommit(commit), err)
+		}
 	}
 
-	ds.logger.Success("Deployed %d services for %s:%s: %v", len(services), repo.Name, branch, services)
+	if config.Settings.CleanupEnabled {
+		progress("Running cleanup" + onHost)
+		logger.Deploy("Running cleanup%s", onHost)
+		if err := docker.Cleanup(); err != nil {
+			logger.Warning("Cleanup failed: %v", err)
+		}
+	}
+	return services, nil
+}
 
-	if ds.config.Settings.CleanupEnabled {
-		ds.logger.Deploy("Running cleanup")
-		if err := ds.dockerService.Cleanup(); err != nil {
-			ds.logger.Warning("Cleanup failed: %v", err)
+// deployToHosts deploys a branch to every host of the repository in order, recording the result of each
+// host on the job. all_or_nothing stops at the first failed host, best_effort fails only when no host succeeded.
+func (ds *DeploymentService) deployToHosts(ctx context.Context, budget *deployBudget, repo models.Repository, branch, repoPath string, opts DeployOptions) error {
+	logger := loggerFrom(ctx, ds.logger)
+	strategy := hostsStrategy(repo)
+	logger.Deploy("Deploying %s:%s to %d hosts (%s)", repo.Name, branch, len(repo.Hosts), strategy)
+
+	var failed []string
+	for i, host := range repo.Hosts {
+		services, err := ds.deployOnDaemon(ctx, budget, ds.dockerService.ForHost(host), host.Name, repo, branch, repoPath, opts)
+		result := HostResult{Host: host.Name, Status: "succeeded", Services: services}
+		if err != nil {
+			logger.Error("Deployment of %s:%s to host %s failed: %v", repo.Name, branch, host.Name, err)
+			result.Status = "failed"
+			result.Error = err.Error()
+			failed = append(failed, host.Name)
+		}
+		ds.updateJob(opts.jobID, func(job *Job) { job.Hosts = append(job.Hosts, result) })
+
+		// a cancelled or timed out deploy would fail on every remaining host as well
+		if err != nil && (strategy == HostsAllOrNothing || ctx.Err() != nil) {
+			var skipped []HostResult
+			for _, rest := range repo.Hosts[i+1:] {
+				skipped = append(skipped, HostResult{Host: rest.Name, Status: "skipped"})
+			}
+			ds.updateJob(opts.jobID, func(job *Job) { job.Hosts = append(job.Hosts, skipped...) })
+			return fmt.Errorf("deployment to host %s failed: %w", host.Name, err)
 		}
 	}
 
+	if len(failed) == len(repo.Hosts) {
+		return fmt.Errorf("deployment failed on every host: %s", strings.Join(failed, ", "))
+	}
+	if len(failed) > 0 {
+		logger.Warning("Deployed %s:%s to %d of %d hosts, failed on %s (best_effort)",
+			repo.Name, branch, len(repo.Hosts)-len(failed), len(repo.Hosts), strings.Join(failed, ", "))
+		return nil
+	}
+	logger.Success("Deployed %s:%s to all %d hosts", repo.Name, branch, len(repo.Hosts))
 	return nil
 }
 
+// currentCommit returns the checked out commit of a checkout, empty when it cannot be read
+func (ds *DeploymentService) currentCommit(repoPath string) string {
+	info, err := ds.gitService.GetRepositoryInfo(repoPath)
+	if err != nil {
+		return ""
+	}
+	return info["commit_hash"]
+}
+
+// isProjectHealthy reports whether the branch projects have running containers and none that died or failed,
+// on every host of the repository when it has hosts
+func (ds *DeploymentService) isProjectHealthy(repo models.Repository, branch string) bool {
+	if len(repo.Hosts) == 0 {
+		return ds.isProjectHealthyOn(ds.dockerService, repo, branch)
+	}
+	for _, host := range repo.Hosts {
+		if !ds.isProjectHealthyOn(ds.dockerService.ForHost(host), repo, branch) {
+			return false
+		}
+	}
+	return true
+}
+
+// isProjectHealthyOn is isProjectHealthy for a single docker daemon
+func (ds *DeploymentService) isProjectHealthyOn(docker DockerDeployer, repo models.Repository, branch string) bool {
+	running, err := docker.GetRunningProjectContainers(repo, branch)
+	if err != nil {
+		ds.logger.Warning("Failed to check running containers for %s:%s: %v", repo.Name, branch, err)
+		return false
+	}
+	failed, err := docker.GetFailedProjectContainers(repo, branch)
+	if err != nil {
+		ds.logger.Warning("Failed to check failed containers for %s:%s: %v", repo.Name, branch, err)
+		return false
+	}
+	return len(running) > 0 && len(failed) == 0
+}
+
+// shortCommit abbreviates a commit hash for logs
+func shortCommit(commit string) string {
+	if commit == "" {
+		return "(unknown)"
+	}
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// checkDiskSpace fails when the work dir or the Docker data root has less than min_free_disk_mb available,
+// pruning unused Docker resources first when prune_on_low_disk is set
+func (ds *DeploymentService) checkDiskSpace(config *models.Config) error {
+	err := ds.verifyDiskSpace(config)
+	if err == nil || !config.Settings.PruneOnLowDisk {
+		return err
+	}
+
+	ds.logger.Warning("%v, pruning unused Docker resources", err)
+	if cleanupErr := ds.dockerService.Cleanup(); cleanupErr != nil {
+		ds.logger.Warning("Cleanup failed: %v", cleanupErr)
+	}
+	return ds.verifyDiskSpace(config)
+}
+
+// verifyDiskSpace checks the free space of the filesystems a deploy writes to
+func (ds *DeploymentService) verifyDiskSpace(config *models.Config) error {
+	paths := []string{config.Settings.WorkDir}
+	if rootDir, err := ds.dockerService.DockerRootDir(); err != nil {
+		ds.logger.Warning("Could not determine Docker data root: %v", err)
+	} else {
+		paths = append(paths, rootDir)
+	}
+
+	required := uint64(config.Settings.MinFreeDiskMB) * 1024 * 1024
+	for _, path := range paths {
+		free, err := freeDiskSpace(path)
+		if err != nil {
+			ds.logger.Warning("Could not check free disk space of %s: %v", path, err)
+			continue
+		}
+		if free < required {
+			return fmt.Errorf("%w: %s has %d MB free, %d MB required (min_free_disk_mb)",
+				ErrInsufficientDiskSpace, path, free/1024/1024, config.Settings.MinFreeDiskMB)
+		}
+	}
+	return nil
+}
+
+// lintCompose records the compose warnings of a branch and fails on them when compose_lint is strict
+func (ds *DeploymentService) lintCompose(ctx context.Context, repo models.Repository, branch, repoPath string) error {
+	logger := loggerFrom(ctx, ds.logger)
+	warnings, err := ds.dockerService.LintCompose(ctx, repo, branch, repoPath)
+	if err != nil {
+		return fmt.Errorf("compose lint failed: %v", err)
+	}
+
+	ds.lintMu.Lock()
+	ds.lintWarnings[fmt.Sprintf("%s:%s", repo.Name, branch)] = warnings
+	ds.lintMu.Unlock()
+
+	if len(warnings) == 0 {
+		logger.Deploy("Compose lint passed for %s:%s", repo.Name, branch)
+		return nil
+	}
+	for _, warning := range warnings {
+		logger.Warning("Compose lint %s:%s: %s", repo.Name, branch, warning)
+	}
+	if repo.ComposeLint == "strict" {
+		return fmt.Errorf("compose lint found %d warnings (compose_lint is strict): %s", len(warnings), strings.Join(warnings, "; "))
+	}
+	return nil
+}
+
+// GetComposeWarnings returns the warnings of the last compose lint of a repository branch
+func (ds *DeploymentService) GetComposeWarnings(repoName, branch string) []string {
+	ds.lintMu.RLock()
+	defer ds.lintMu.RUnlock()
+	return ds.lintWarnings[fmt.Sprintf("%s:%s", repoName, branch)]
+}
+
+// acquireSlots blocks until both the repository and the global concurrency limits allow the deployment.
+// The repository slot is taken first so a repository waiting on its own limit never holds a global slot.
+func (ds *DeploymentService) acquireSlots(ctx context.Context, repo models.Repository, branch string) (func(), error) {
+	logger := loggerFrom(ctx, ds.logger)
+	repoSlots := ds.getRepoSlots(repo)
+	if repoSlots != nil {
+		if len(repoSlots) == cap(repoSlots) {
+			logger.Deploy("Waiting for a free slot for %s:%s (repository limit %d)", repo.Name, branch, cap(repoSlots))
+		}
+		select {
+		case repoSlots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped waiting for a deployment slot: %w", ctx.Err())
+		}
+	}
+
+	globalSlots := ds.getGlobalSlots()
+	if len(globalSlots) == cap(globalSlots) {
+		logger.Deploy("Waiting for a free slot for %s:%s (global limit %d)", repo.Name, branch, cap(globalSlots))
+	}
+	select {
+	case globalSlots <- struct{}{}:
+	case <-ctx.Done():
+		if repoSlots != nil {
+			<-repoSlots
+		}
+		return nil, fmt.Errorf("stopped waiting for a deployment slot: %w", ctx.Err())
+	}
+
+	return func() {
+		<-globalSlots
+		if repoSlots != nil {
+			<-repoSlots
+		}
+	}, nil
+}
+
+// getGlobalSlots returns the semaphore enforcing settings.max_concurrent, replaced when a reload changes the limit;
+// deployments holding a slot of the old semaphore release it there
+func (ds *DeploymentService) getGlobalSlots() chan struct{} {
+	maxConcurrent := ds.currentConfig().Settings.MaxConcurrent
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	ds.globalSlotsMu.Lock()
+	defer ds.globalSlotsMu.Unlock()
+
+	if cap(ds.globalSlots) != maxConcurrent {
+		ds.globalSlots = make(chan struct{}, maxConcurrent)
+	}
+	return ds.globalSlots
+}
+
+// getRepoSlots returns the semaphore enforcing the per-repository max_concurrent, nil when unlimited
+func (ds *DeploymentService) getRepoSlots(repo models.Repository) chan struct{} {
+	if repo.MaxConcurrent <= 0 {
+		return nil
+	}
+
+	ds.repoSlotsMu.Lock()
+	defer ds.repoSlotsMu.Unlock()
+
+	slots, exists := ds.repoSlots[repo.Name]
+	if !exists || cap(slots) != repo.MaxConcurrent {
+		slots = make(chan struct{}, repo.MaxConcurrent)
+		ds.repoSlots[repo.Name] = slots
+	}
+	return slots
+}
+
 // GetActiveJobs returns the current active jobs
 func (ds *DeploymentService) GetActiveJobs() []string {
 	ds.activeJobsMu.RLock()
@@ -191,8 +775,12 @@ func (ds *DeploymentService) GetActiveJobs() []string {
 
 // GetDeploymentStats returns deployment statistics
 func (ds *DeploymentService) GetDeploymentStats() map[string]interface{} {
-	ds.metricsMu.RLock()
-	defer ds.metricsMu.RUnlock()
+	// each counter is loaded exactly once so the returned values form a single snapshot
+	totalJobs := ds.totalJobs.Load()
+	completedJobs := ds.completedJobs.Load()
+	failedJobs := ds.failedJobs.Load()
+	cancelledJobs := ds.cancelledJobs.Load()
+	skippedJobs := ds.skippedJobs.Load()
 
 	ds.activeJobsMu.RLock()
 	activeCount := len(ds.activeJobs)
@@ -201,11 +789,14 @@ func (ds *DeploymentService) GetDeploymentStats() map[string]interface{} {
 	return map[string]interface{}{
 		"queue_size":     0,
 		"queue_capacity": 0,
-		"max_workers":    ds.config.Settings.MaxConcurrent,
+		"max_workers":    ds.currentConfig().Settings.MaxConcurrent,
 		"active_jobs":    activeCount,
-		"total_jobs":     ds.totalJobs,
-		"completed_jobs": ds.completedJobs,
-		"failed_jobs":    ds.failedJobs,
+		"total_jobs":     totalJobs,
+		"completed_jobs": completedJobs,
+		"failed_jobs":    failedJobs,
+		"cancelled_jobs": cancelledJobs,
+		"skipped_jobs":   skippedJobs,
+		"reaped_jobs":    ds.reapedJobs.Load(),
 	}
 }
 
@@ -0,0 +1,413 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"uruflow.com/internal/models"
+)
+
+// TestConcurrentDeployments fires many simultaneous deploys of the same branches while configs are swapped,
+// jobs are enqueued and cancelled and the stats are read; run it with go test -race.
+func TestConcurrentDeployments(t *testing.T) {
+	logger := newTestLogger(t)
+	remote := newGitRemote(t, "dev", "stage")
+
+	var repos []models.Repository
+	for _, name := range []string{"api", "web", "worker"} {
+		repos = append(repos, testRepository(name, remote.URL, "main", "dev", "stage"))
+	}
+	const maxConcurrent = 3
+	config := &models.Config{
+		Settings:     models.Settings{WorkDir: filepath.Join(t.TempDir(), "work"), MaxConcurrent: maxConcurrent},
+		Repositories: repos,
+	}
+
+	gitService := NewGitService(logger)
+	repositoryService := NewRepositoryService(config, gitService, logger)
+	docker := &fakeDocker{buildDelay: 20 * time.Millisecond}
+	ds := NewDeploymentService(config, repositoryService, gitService, docker, logger)
+
+	events, err := ds.Events().Subscribe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for range events {
+		}
+	}()
+
+	stop := make(chan struct{})
+	var background sync.WaitGroup
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			reloaded := *config
+			ds.UpdateConfig(&reloaded)
+			repositoryService.UpdateConfig(&reloaded)
+			ds.GetDeploymentStats()
+			ds.GetActiveJobs()
+			ds.StuckJobs()
+			ds.BranchResults()
+			ds.WriteMetrics(io.Discard)
+			ds.reapStaleJobs()
+			repositoryService.GetDeploymentState()
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	var succeeded, deduplicated, enqueued atomic.Int64
+	var jobIDs []string
+	var deploys sync.WaitGroup
+	for round := 0; round < 3; round++ {
+		for i := 0; i < 8; i++ {
+			for _, repo := range repos {
+				for _, branch := range repo.Branches {
+					deploys.Add(1)
+					go func(repo models.Repository, branch string) {
+						defer deploys.Done()
+						err := ds.DeployDirect(repo, branch)
+						switch {
+						case err == nil:
+							succeeded.Add(1)
+						case strings.Contains(err.Error(), "already in progress"):
+							deduplicated.Add(1)
+						default:
+							t.Errorf("deploy %s:%s: %v", repo.Name, branch, err)
+						}
+					}(repo, branch)
+				}
+			}
+		}
+		// background jobs and cancellations race with the direct deploys of the same branches
+		for _, repo := range repos {
+			if id, err := ds.Enqueue(context.Background(), repo, "main", DeployOptions{}); err == nil {
+				enqueued.Add(1)
+				jobIDs = append(jobIDs, id)
+			}
+			if err := ds.CancelDeployment(repo.Name, "dev"); err != nil && !errors.Is(err, ErrNoActiveDeployment) {
+				t.Errorf("cancel %s:dev: %v", repo.Name, err)
+			}
+		}
+		deploys.Wait()
+	}
+	waitForJobs(t, ds, jobIDs)
+	close(stop)
+	background.Wait()
+
+	stats := ds.GetDeploymentStats()
+	total := stats["total_jobs"].(int64)
+	completed := stats["completed_jobs"].(int64)
+	failed := stats["failed_jobs"].(int64)
+	cancelled := stats["cancelled_jobs"].(int64)
+
+	if succeeded.Load() == 0 || deduplicated.Load() == 0 {
+		t.Fatalf("expected both successful and deduplicated deploys, got %d and %d", succeeded.Load(), deduplicated.Load())
+	}
+	if total != completed+failed+cancelled {
+		t.Errorf("total_jobs %d != completed %d + failed %d + cancelled %d", total, completed, failed, cancelled)
+	}
+	if failed != 0 {
+		t.Errorf("failed_jobs = %d, want 0", failed)
+	}
+	if completed < succeeded.Load() || completed > succeeded.Load()+enqueued.Load() {
+		t.Errorf("completed_jobs %d outside [%d, %d]", completed, succeeded.Load(), succeeded.Load()+enqueued.Load())
+	}
+	if docker.starts.Load() != completed {
+		t.Errorf("%d starts for %d completed jobs", docker.starts.Load(), completed)
+	}
+	if got := docker.maxRunning.Load(); got > maxConcurrent {
+		t.Errorf("%d builds ran at once, max_concurrent is %d", got, maxConcurrent)
+	}
+	if active := stats["active_jobs"].(int); active != 0 {
+		t.Errorf("active_jobs = %d after all deploys finished", active)
+	}
+}
+
+// waitForJobs waits until the enqueued jobs have finished and no deployment is active
+func waitForJobs(t *testing.T, ds *DeploymentService, ids []string) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for _, id := range ids {
+		for {
+			// finished jobs past maxJobHistory are dropped, running ones are always kept
+			if job, ok := ds.GetJob(id); !ok || job.FinishedAt != nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("job %s did not finish", id)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	for len(ds.GetActiveJobs()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("deployments still active: %v", ds.GetActiveJobs())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestReloadDuringDeploy swaps the configuration while a deploy is building; the deploy keeps its snapshot
+// and the next one runs on the reloaded configuration.
+func TestReloadDuringDeploy(t *testing.T) {
+	logger := newTestLogger(t)
+	remote := newGitRemote(t)
+
+	oldWorkDir := filepath.Join(t.TempDir(), "old")
+	newWorkDir := filepath.Join(t.TempDir(), "new")
+	repo := testRepository("api", remote.URL, "main")
+	config := &models.Config{
+		Settings:     models.Settings{WorkDir: oldWorkDir, MaxConcurrent: 1},
+		Repositories: []models.Repository{repo},
+	}
+
+	gitService := NewGitService(logger)
+	repositoryService := NewRepositoryService(config, gitService, logger)
+	docker := &fakeDocker{buildDelay: 300 * time.Millisecond}
+	ds := NewDeploymentService(config, repositoryService, gitService, docker, logger)
+
+	done := make(chan error, 1)
+	go func() { done <- ds.DeployDirect(repo, "main") }()
+
+	deadline := time.Now().Add(30 * time.Second)
+	for docker.running.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("deploy never reached the build")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	reloaded := *config
+	reloaded.Settings.WorkDir = newWorkDir
+	repositoryService.UpdateConfig(&reloaded)
+	ds.UpdateConfig(&reloaded)
+
+	if err := <-done; err != nil {
+		t.Fatalf("deploy during reload: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(oldWorkDir, "api", "main", "docker-compose.yml")); err != nil {
+		t.Errorf("in-flight deploy left its snapshot work dir: %v", err)
+	}
+
+	if err := ds.DeployDirect(repo, "main"); err != nil {
+		t.Fatalf("deploy after reload: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(newWorkDir, "api", "main", "docker-compose.yml")); err != nil {
+		t.Errorf("deploy after reload did not use the new work dir: %v", err)
+	}
+	if got := docker.starts.Load(); got != 2 {
+		t.Errorf("%d starts, want 2", got)
+	}
+}
+
+// TestMaxConcurrentReload raises settings.max_concurrent on a running service and expects the new limit to apply
+func TestMaxConcurrentReload(t *testing.T) {
+	logger := newTestLogger(t)
+	remote := newGitRemote(t, "dev", "stage")
+
+	repo := testRepository("api", remote.URL, "main", "dev", "stage")
+	config := &models.Config{
+		Settings:     models.Settings{WorkDir: filepath.Join(t.TempDir(), "work"), MaxConcurrent: 1},
+		Repositories: []models.Repository{repo},
+	}
+	gitService := NewGitService(logger)
+	repositoryService := NewRepositoryService(config, gitService, logger)
+	docker := &fakeDocker{buildDelay: 200 * time.Millisecond}
+	ds := NewDeploymentService(config, repositoryService, gitService, docker, logger)
+
+	deployAll := func() {
+		t.Helper()
+		var wg sync.WaitGroup
+		for _, branch := range repo.Branches {
+			wg.Add(1)
+			go func(branch string) {
+				defer wg.Done()
+				if err := ds.DeployDirect(repo, branch); err != nil {
+					t.Errorf("deploy %s: %v", branch, err)
+				}
+			}(branch)
+		}
+		wg.Wait()
+	}
+
+	deployAll()
+	if got := docker.maxRunning.Load(); got != 1 {
+		t.Fatalf("%d builds ran at once with max_concurrent 1", got)
+	}
+
+	reloaded := *config
+	reloaded.Settings.MaxConcurrent = 3
+	ds.UpdateConfig(&reloaded)
+	deployAll()
+	if got := docker.maxRunning.Load(); got < 2 {
+		t.Errorf("max_concurrent raised to 3 but at most %d builds ran at once", got)
+	}
+}
+
+func TestSkipIfUnchanged(t *testing.T) {
+	logger := newTestLogger(t)
+	remote := newGitRemote(t)
+
+	repo := testRepository("api", remote.URL, "main")
+	config := &models.Config{
+		Settings:     models.Settings{WorkDir: filepath.Join(t.TempDir(), "work"), MaxConcurrent: 1},
+		Repositories: []models.Repository{repo},
+	}
+	gitService := NewGitService(logger)
+	repositoryService := NewRepositoryService(config, gitService, logger)
+	docker := &fakeDocker{}
+	ds := NewDeploymentService(config, repositoryService, gitService, docker, logger)
+
+	if err := ds.DeployDirect(repo, "main"); err != nil {
+		t.Fatal(err)
+	}
+	deployed := ds.BranchResults()[0].LastSucceeded
+
+	repo.SkipIfUnchanged = true
+	jobID := ds.createJob(repo.Name, "main", models.DeployTrigger{})
+	err := ds.DeployDirectWithOptions(context.Background(), repo, "main", DeployOptions{jobID: jobID})
+	if !errors.Is(err, ErrDeploySkipped) {
+		t.Fatalf("deploy without new commits: %v, want ErrDeploySkipped", err)
+	}
+	if job, _ := ds.GetJob(jobID); job.Status != JobSkipped || job.Error != "" {
+		t.Errorf("skipped job is %s with error %q", job.Status, job.Error)
+	}
+	stats := ds.GetDeploymentStats()
+	if stats["completed_jobs"] != int64(1) || stats["skipped_jobs"] != int64(1) || stats["failed_jobs"] != int64(0) {
+		t.Errorf("stats after skip: %v", stats)
+	}
+	if got := ds.BranchResults()[0].LastSucceeded; !got.Equal(deployed) {
+		t.Errorf("skip moved the last successful deploy from %v to %v", deployed, got)
+	}
+	if got := docker.starts.Load(); got != 1 {
+		t.Errorf("%d starts after skip, want 1", got)
+	}
+
+	remote.push(t, "main", "second")
+	if err := ds.DeployDirect(repo, "main"); err != nil {
+		t.Fatalf("deploy of a new commit: %v", err)
+	}
+	if got := docker.starts.Load(); got != 2 {
+		t.Errorf("%d starts after new commit, want 2", got)
+	}
+}
+
+func TestDeployToHosts(t *testing.T) {
+	hosts := []models.DeployHost{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	tests := []struct {
+		name      string
+		strategy  string
+		failHosts map[string]bool
+		wantErr   string
+		builtOn   []string
+		results   []HostResult
+	}{
+		{
+			"every host", HostsAllOrNothing, nil, "", []string{"a", "b", "c"},
+			[]HostResult{
+				{Host: "a", Status: "succeeded", Services: []string{"web"}},
+				{Host: "b", Status: "succeeded", Services: []string{"web"}},
+				{Host: "c", Status: "succeeded", Services: []string{"web"}},
+			},
+		},
+		{
+			"all_or_nothing stops at the first failed host", HostsAllOrNothing, map[string]bool{"b": true},
+			"deployment to host b failed", []string{"a"},
+			[]HostResult{
+				{Host: "a", Status: "succeeded", Services: []string{"web"}},
+				{Host: "b", Status: "failed", Error: "image build failed: build failed on b"},
+				{Host: "c", Status: "skipped"},
+			},
+		},
+		{
+			"best_effort partial success", HostsBestEffort, map[string]bool{"b": true}, "", []string{"a", "c"},
+			[]HostResult{
+				{Host: "a", Status: "succeeded", Services: []string{"web"}},
+				{Host: "b", Status: "failed", Error: "image build failed: build failed on b"},
+				{Host: "c", Status: "succeeded", Services: []string{"web"}},
+			},
+		},
+		{
+			"best_effort without a successful host", HostsBestEffort, map[string]bool{"a": true, "b": true, "c": true},
+			"deployment failed on every host: a, b, c", nil,
+			[]HostResult{
+				{Host: "a", Status: "failed", Error: "image build failed: build failed on a"},
+				{Host: "b", Status: "failed", Error: "image build failed: build failed on b"},
+				{Host: "c", Status: "failed", Error: "image build failed: build failed on c"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := newTestLogger(t)
+			remote := newGitRemote(t)
+			repo := testRepository("api", remote.URL, "main")
+			repo.Hosts = hosts
+			repo.HostsStrategy = tt.strategy
+			config := &models.Config{
+				Settings:     models.Settings{WorkDir: filepath.Join(t.TempDir(), "work"), MaxConcurrent: 1},
+				Repositories: []models.Repository{repo},
+			}
+			gitService := NewGitService(logger)
+			docker := &fakeDocker{failHosts: tt.failHosts}
+			ds := NewDeploymentService(config, NewRepositoryService(config, gitService, logger), gitService, docker, logger)
+
+			jobID := ds.createJob(repo.Name, "main", models.DeployTrigger{})
+			err := ds.DeployDirectWithOptions(context.Background(), repo, "main", DeployOptions{jobID: jobID})
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("error %v, want %q", err, tt.wantErr)
+			}
+			if got := docker.hostBuilds(); !slices.Equal(got, tt.builtOn) {
+				t.Errorf("built on %v, want %v", got, tt.builtOn)
+			}
+
+			job, _ := ds.GetJob(jobID)
+			if len(job.Hosts) != len(tt.results) {
+				t.Fatalf("job host results %+v, want %+v", job.Hosts, tt.results)
+			}
+			for i, want := range tt.results {
+				got := job.Hosts[i]
+				if got.Host != want.Host || got.Status != want.Status || got.Error != want.Error || !slices.Equal(got.Services, want.Services) {
+					t.Errorf("host result %d is %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
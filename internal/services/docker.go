@@ -21,26 +21,48 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"uruflow.com/internal/models"
 	"uruflow.com/internal/utils"
 )
 
+// ErrNoServices is reported when a compose project has no services after a successful up
+var ErrNoServices = errors.New("compose project has no services")
+
 // DockerService handles Docker Compose operations
 type DockerService struct {
+	config         *models.Config
+	configMu       sync.RWMutex
 	logger         *utils.Logger
 	composeCommand string
+
+	registryMu     sync.Mutex
+	registryLogins map[string]string
+
+	// hostEnv selects the daemon of a deploy host, empty for the local daemon
+	hostEnv      []string
+	hostServices map[string]*DockerService
+	hostsMu      sync.Mutex
 }
 
 // NewDockerService creates a new Docker service
-func NewDockerService(logger *utils.Logger) *DockerService {
+func NewDockerService(config *models.Config, logger *utils.Logger) *DockerService {
 	ds := &DockerService{
-		logger: logger,
+		config:         config,
+		logger:         logger,
+		registryLogins: make(map[string]string),
+		hostServices:   make(map[string]*DockerService),
 	}
 
 	ds.composeCommand = ds.detectComposeCommand()
@@ -49,14 +71,34 @@ func NewDockerService(logger *utils.Logger) *DockerService {
 	return ds
 }
 
+// UpdateConfig updates the configuration reference
+func (d *DockerService) UpdateConfig(config *models.Config) {
+	d.configMu.Lock()
+	d.config = config
+	d.configMu.Unlock()
+
+	d.hostsMu.Lock()
+	defer d.hostsMu.Unlock()
+	for _, hostService := range d.hostServices {
+		hostService.UpdateConfig(config)
+	}
+}
+
+// currentConfig returns the configuration in use; a reload swaps the pointer, the returned config is never modified
+func (d *DockerService) currentConfig() *models.Config {
+	d.configMu.RLock()
+	defer d.configMu.RUnlock()
+	return d.config
+}
+
 // detectComposeCommand detects whether to use 'docker compose' or 'docker-compose'
 func (d *DockerService) detectComposeCommand() string {
-	cmd := exec.Command("docker", "compose", "version")
+	cmd := d.command("docker", "compose", "version")
 	if err := cmd.Run(); err == nil {
 		return "docker compose"
 	}
 
-	cmd = exec.Command("docker-compose", "version")
+	cmd = d.command("docker-compose", "version")
 	if err := cmd.Run(); err == nil {
 		return "docker-compose"
 	}
@@ -67,89 +109,415 @@ func (d *DockerService) detectComposeCommand() string {
 
 // Deploy deploys services using Docker Compose
 func (d *DockerService) Deploy(repo models.Repository, branch, repoPath string) ([]string, error) {
-	projectName := d.getProjectName(repo, branch)
-	d.logger.Docker("Starting deployment for %s:%s using %s (project: %s)", repo.Name, branch, d.composeCommand, projectName)
-	d.logger.Docker("Stopping any existing services...")
-	if err := d.stopServices(repo.ComposeFile, projectName, repoPath); err != nil {
-		d.logger.Warning("Failed to stop existing services (this may be normal): %v", err)
-	}
-	d.logger.Docker("Starting services with conflict resolution...")
-	if err := d.startServices(repo.ComposeFile, projectName, repoPath); err != nil {
-		d.logger.Error("Service startup failed: %v", err)
+	return d.DeployWithContext(context.Background(), repo, branch, repoPath)
+}
+
+// DeployWithContext deploys every compose unit of a branch in order, killing the running compose process when ctx is cancelled
+func (d *DockerService) DeployWithContext(ctx context.Context, repo models.Repository, branch, repoPath string) ([]string, error) {
+	return d.DeployServicesWithContext(ctx, repo, branch, repoPath, nil)
+}
+
+// DeployServicesWithContext deploys a branch like DeployWithContext; a non-empty only list rebuilds and recreates
+// just those services, leaving the other services and dependencies of the projects untouched
+func (d *DockerService) DeployServicesWithContext(ctx context.Context, repo models.Repository, branch, repoPath string, only []string) ([]string, error) {
+	if err := d.BuildServicesWithContext(ctx, repo, branch, repoPath, only); err != nil {
 		return nil, err
 	}
+	return d.StartServicesWithContext(ctx, repo, branch, repoPath, only)
+}
+
+// BuildServicesWithContext builds the images of every compose unit of a branch, pulling fresh base images with
+// pull_always, so the build phase of a deploy can run under its own timeout
+func (d *DockerService) BuildServicesWithContext(ctx context.Context, repo models.Repository, branch, repoPath string, only []string) error {
+	units, selected, err := d.deployTargets(ctx, repo, branch, repoPath, only)
+	if err != nil {
+		return err
+	}
+
+	for i, unit := range units {
+		var unitServices []string
+		if selected != nil {
+			if unitServices = selected[i]; len(unitServices) == 0 {
+				continue
+			}
+		}
+		if err := d.buildUnit(ctx, repo, branch, unit, repoPath, unitServices); err != nil {
+			if unit.Name != "" {
+				return fmt.Errorf("unit %s failed: %w", unit.Name, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// StartServicesWithContext starts the services of every compose unit of a branch in order from the images
+// built by BuildServicesWithContext, without building again
+func (d *DockerService) StartServicesWithContext(ctx context.Context, repo models.Repository, branch, repoPath string, only []string) ([]string, error) {
+	units, selected, err := d.deployTargets(ctx, repo, branch, repoPath, only)
+	if err != nil {
+		return nil, err
+	}
+
+	var deployed []string
+	for i, unit := range units {
+		var unitServices []string
+		if selected != nil {
+			if unitServices = selected[i]; len(unitServices) == 0 {
+				continue
+			}
+		}
+		services, err := d.deployUnit(ctx, repo, branch, unit, repoPath, unitServices)
+		if err != nil {
+			if unit.Name != "" {
+				return nil, fmt.Errorf("unit %s failed: %w", unit.Name, err)
+			}
+			return nil, err
+		}
+		for _, service := range services {
+			if unit.Name != "" {
+				service = unit.Name + "/" + service
+			}
+			deployed = append(deployed, service)
+		}
+	}
+	return deployed, nil
+}
+
+// deployTargets resolves the compose units of a branch and, for a non-empty only list, the services selected in each unit
+func (d *DockerService) deployTargets(ctx context.Context, repo models.Repository, branch, repoPath string, only []string) ([]models.ComposeUnit, [][]string, error) {
+	if repo.ComposeCommand != "" {
+		if err := ValidateComposeCommand(repo.ComposeCommand); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	units, err := ComposeUnits(repo, branch, repoPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	d.loginRegistries(ctx)
+
+	var selected [][]string
+	if len(only) > 0 {
+		secretEnv, _, err := d.resolveSecrets(ctx, repo, branch)
+		if err != nil {
+			return nil, nil, err
+		}
+		if selected, err = d.selectServices(ctx, repo, units, repoPath, only, secretEnv); err != nil {
+			return nil, nil, err
+		}
+	}
+	return units, selected, nil
+}
+
+// buildUnit builds the images of a single compose project, only of the given services when only is not empty
+func (d *DockerService) buildUnit(ctx context.Context, repo models.Repository, branch string, unit models.ComposeUnit, repoPath string, only []string) error {
+	logger := loggerFrom(ctx, d.logger)
+	if repo.PullAlways {
+		// build --pull refreshes the base images while building
+		return d.pullImages(ctx, repo, branch, unit, repoPath, only)
+	}
+
+	logger.Docker("Building images for %s (project: %s, file: %s)", repo.Name, unit.ProjectName, unit.ComposeFile)
+	secretEnv, secretValues, err := d.resolveSecrets(ctx, repo, branch)
+	if err != nil {
+		return err
+	}
+	cmd := d.composeCmd(ctx, repo, unit.ComposeFile, unit.ProjectName, repoPath, secretEnv, append([]string{"build"}, only...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("docker compose build interrupted: %w", ctx.Err())
+		}
+		return fmt.Errorf("docker compose build failed: %v, output: %s", err, maskSecrets(string(output), secretValues))
+	}
+	return nil
+}
+
+// selectServices assigns every requested service to the compose units defining it, failing on unknown services
+func (d *DockerService) selectServices(ctx context.Context, repo models.Repository, units []models.ComposeUnit, repoPath string, only, secretEnv []string) ([][]string, error) {
+	selected := make([][]string, len(units))
+	found := make(map[string]bool)
+	var available []string
+	for i, unit := range units {
+		// the services of a project that never ran have no containers for ps to list
+		services, err := d.definedServices(ctx, repo, unit.ComposeFile, unit.ProjectName, repoPath, secretEnv)
+		if err != nil {
+			return nil, fmt.Errorf("could not list services of project %s: %v", unit.ProjectName, err)
+		}
+		available = append(available, services...)
+		for _, name := range only {
+			for _, service := range services {
+				if service == name {
+					selected[i] = append(selected[i], name)
+					found[name] = true
+				}
+			}
+		}
+	}
+
+	for _, name := range only {
+		if !found[name] {
+			return nil, fmt.Errorf("unknown service '%s' (available: %s)", name, strings.Join(available, ", "))
+		}
+	}
+	return selected, nil
+}
+
+// deployUnit deploys a single compose project, only the given services when only is not empty
+func (d *DockerService) deployUnit(ctx context.Context, repo models.Repository, branch string, unit models.ComposeUnit, repoPath string, only []string) ([]string, error) {
+	logger := loggerFrom(ctx, d.logger)
+	projectName := unit.ProjectName
+	logger.Docker("Starting deployment for %s:%s using %s (project: %s, file: %s)", repo.Name, branch, d.ComposeCommandFor(repo), projectName, unit.ComposeFile)
+	secretEnv, secretValues, err := d.resolveSecrets(ctx, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+	if len(only) > 0 {
+		// compose down would stop every service of the project
+		logger.Docker("Recreating only services %v, other services keep running", only)
+	} else if repo.PreserveVolumes {
+		// compose down would drop the containers and with them the link to their anonymous volumes
+		logger.Docker("Keeping existing services so their volumes are reused (preserve_volumes)")
+	} else {
+		logger.Docker("Stopping any existing services...")
+		if err := d.stopServices(ctx, repo, unit.ComposeFile, projectName, repoPath, secretEnv); err != nil {
+			logger.Warning("Failed to stop existing services (this may be normal): %v", err)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("deployment interrupted: %w", ctx.Err())
+	}
+	logger.Docker("Starting services with conflict resolution...")
+	if err := d.startServices(ctx, repo, unit.ComposeFile, projectName, repoPath, only, secretEnv, secretValues); err != nil {
+		logger.Error("Service startup failed: %v", err)
+		return nil, err
+	}
+	if len(only) > 0 {
+		logger.Success("Successfully deployed %d services for %s:%s (project: %s): %v", len(only), repo.Name, branch, projectName, only)
+		return only, nil
+	}
 
 	// Get list of deployed services
-	services, err := d.getServices(repo.ComposeFile, projectName, repoPath)
+	services, err := d.getServices(ctx, repo, unit.ComposeFile, projectName, repoPath, secretEnv)
 	if err != nil {
-		d.logger.Warning("Could not get services list: %v", err)
+		logger.Warning("Could not get services list: %v", err)
 		// Don't fail deployment just because we can't list services
 		return []string{"unknown"}, nil
 	}
+	if len(services) == 0 {
+		err := d.noServicesError(ctx, repo, unit, repoPath, secretEnv)
+		if d.currentConfig().Settings.RequireServices {
+			return nil, err
+		}
+		logger.Warning("%v (set require_services to fail such deploys)", err)
+		return nil, nil
+	}
 
-	d.logger.Success("Successfully deployed %d services for %s:%s: %v", len(services), repo.Name, branch, services)
+	logger.Success("Successfully deployed %d services for %s:%s (project: %s): %v", len(services), repo.Name, branch, projectName, services)
 	return services, nil
 }
 
-// DeployWithContext deploys services using Docker Compose with context support
-func (d *DockerService) DeployWithContext(ctx context.Context, repo models.Repository, branch, repoPath string) ([]string, error) {
-	return d.Deploy(repo, branch, repoPath)
+// EnvFilePath returns the .env file docker compose reads for a unit, next to its compose file in the checkout
+func EnvFilePath(unit models.ComposeUnit, repoPath string) string {
+	return filepath.Join(repoPath, filepath.Dir(unit.ComposeFile), ".env")
 }
 
-// getProjectName returns the Docker Compose project name
-func (d *DockerService) getProjectName(repo models.Repository, branch string) string {
-	if branchConfig, exists := repo.BranchConfig[branch]; exists && branchConfig.ProjectName != "" {
-		return branchConfig.ProjectName
+// ReloadEnv recreates the containers of every compose unit whose configuration changed, without pulling or building,
+// so new .env and env_file values take effect
+func (d *DockerService) ReloadEnv(repo models.Repository, branch, repoPath string) ([]string, error) {
+	units, err := ComposeUnits(repo, branch, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	secretEnv, secretValues, err := d.resolveSecrets(context.Background(), repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	var reloaded []string
+	for _, unit := range units {
+		d.logger.Docker("Reloading environment for project %s (file: %s)", unit.ProjectName, unit.ComposeFile)
+		cmd := d.composeCmd(context.Background(), repo, unit.ComposeFile, unit.ProjectName, repoPath, secretEnv, append([]string{"up", "-d", "--no-build"}, orphanArgs(repo)...)...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("docker compose up failed for project %s: %v, output: %s", unit.ProjectName, err, maskSecrets(string(output), secretValues))
+		}
+		reloaded = append(reloaded, unit.ProjectName)
 	}
-	return fmt.Sprintf("%s-%s", repo.Name, branch)
+	return reloaded, nil
+}
+
+// LintCompose runs docker compose config for every compose unit of a branch and returns the warnings it prints
+func (d *DockerService) LintCompose(ctx context.Context, repo models.Repository, branch, repoPath string) ([]string, error) {
+	units, err := ComposeUnits(repo, branch, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	secretEnv, secretValues, err := d.resolveSecrets(ctx, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	for _, unit := range units {
+		cmd := d.composeCmd(ctx, repo, unit.ComposeFile, unit.ProjectName, repoPath, secretEnv, "config", "--quiet")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		output := maskSecrets(stderr.String(), secretValues)
+		if err != nil {
+			return nil, fmt.Errorf("docker compose config failed for %s: %v, output: %s", unit.ComposeFile, err, strings.TrimSpace(output))
+		}
+
+		for _, line := range strings.Split(output, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				warnings = append(warnings, fmt.Sprintf("%s: %s", unit.ComposeFile, line))
+			}
+		}
+	}
+	return warnings, nil
+}
+
+// PrepullImages pulls the images and warms the build cache of every compose unit of a checkout without
+// starting anything, so the next deploy of the branch does not wait for downloads
+func (d *DockerService) PrepullImages(ctx context.Context, repo models.Repository, branch, repoPath string) error {
+	units, err := ComposeUnits(repo, branch, repoPath)
+	if err != nil {
+		return err
+	}
+	d.loginRegistries(ctx)
+	for _, unit := range units {
+		if err := d.pullImages(ctx, repo, branch, unit, repoPath, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pullImages re-pulls the base images of built services and the images of the other services, reporting updated ones;
+// a non-empty only limits both to the given services, the branch secrets are passed as for a normal build
+func (d *DockerService) pullImages(ctx context.Context, repo models.Repository, branch string, unit models.ComposeUnit, repoPath string, only []string) error {
+	logger := loggerFrom(ctx, d.logger)
+	logger.Docker("Pulling fresh images for project %s", unit.ProjectName)
+	secretEnv, secretValues, err := d.resolveSecrets(ctx, repo, branch)
+	if err != nil {
+		return err
+	}
+	before := d.imageIDs()
+
+	cmd := d.composeCmd(ctx, repo, unit.ComposeFile, unit.ProjectName, repoPath, secretEnv, append([]string{"build", "--pull"}, only...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("docker compose build interrupted: %w", ctx.Err())
+		}
+		return fmt.Errorf("docker compose build --pull failed: %v, output: %s", err, maskSecrets(string(output), secretValues))
+	}
+
+	cmd = d.composeCmd(ctx, repo, unit.ComposeFile, unit.ProjectName, repoPath, secretEnv, append([]string{"pull", "--ignore-pull-failures"}, only...)...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("docker compose pull interrupted: %w", ctx.Err())
+		}
+		logger.Warning("docker compose pull failed for %s: %v, output: %s", unit.ProjectName, err, maskSecrets(string(output), secretValues))
+	}
+
+	updated := 0
+	for image, id := range d.imageIDs() {
+		// images built for the project change with every code change, only report pulled ones
+		if strings.HasPrefix(image, unit.ProjectName+"-") || strings.HasPrefix(image, unit.ProjectName+"_") {
+			continue
+		}
+		if previous, existed := before[image]; existed && previous != id {
+			logger.Docker("Image updated: %s (%s -> %s)", image, previous, id)
+			updated++
+		}
+	}
+	if updated == 0 {
+		logger.Docker("All images of project %s were already up to date", unit.ProjectName)
+	}
+	return nil
+}
+
+// imageIDs maps every tagged local image to its ID
+func (d *DockerService) imageIDs() map[string]string {
+	images := make(map[string]string)
+	output, err := d.command("docker", "images", "--format", "{{.Repository}}:{{.Tag}}\t{{.ID}}").Output()
+	if err != nil {
+		return images
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		image, id, found := strings.Cut(strings.TrimSpace(line), "\t")
+		if found && !strings.HasSuffix(image, ":<none>") {
+			images[image] = id
+		}
+	}
+	return images
+}
+
+// Stop takes down every compose unit of a repository branch, in reverse deploy order
+func (d *DockerService) Stop(repo models.Repository, branch, repoPath string) error {
+	units, err := ComposeUnits(repo, branch, repoPath)
+	if err != nil {
+		return err
+	}
+	secretEnv, _, err := d.resolveSecrets(context.Background(), repo, branch)
+	if err != nil {
+		return err
+	}
+	for i := len(units) - 1; i >= 0; i-- {
+		if err := d.stopServices(context.Background(), repo, units[i].ComposeFile, units[i].ProjectName, repoPath, secretEnv); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // stopServices stops existing Docker Compose services with enhanced cleanup
-func (d *DockerService) stopServices(composeFile, projectName, workDir string) error {
-	d.logger.Docker("Stopping existing services for project: %s", projectName)
-	args := d.buildComposeArgs(composeFile, projectName, "down", "--remove-orphans")
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Dir = workDir
+func (d *DockerService) stopServices(ctx context.Context, repo models.Repository, composeFile, projectName, workDir string, secretEnv []string) error {
+	logger := loggerFrom(ctx, d.logger)
+	logger.Docker("Stopping existing services for project: %s", projectName)
+	cmd := d.composeCmd(ctx, repo, composeFile, projectName, workDir, secretEnv, append([]string{"down"}, orphanArgs(repo)...)...)
 	output, err := cmd.CombinedOutput()
 
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("docker compose down interrupted: %w", ctx.Err())
+	}
 	if err != nil {
-		d.logger.Warning("Normal stop failed, trying force removal: %v", err)
-		d.logger.Warning("Output: %s", string(output))
-		if cleanupErr := d.aggressiveProjectCleanup(projectName, composeFile, workDir); cleanupErr != nil {
-			d.logger.Warning("Aggressive cleanup also failed: %v", cleanupErr)
+		logger.Warning("Normal stop failed, trying force removal: %v", err)
+		logger.Warning("Output: %s", string(output))
+		if cleanupErr := d.aggressiveProjectCleanup(logger, repo, projectName, composeFile, workDir, secretEnv); cleanupErr != nil {
+			logger.Warning("Aggressive cleanup also failed: %v", cleanupErr)
 		}
 	} else {
-		d.logger.Docker("Successfully stopped services for: %s", projectName)
+		logger.Docker("Successfully stopped services for: %s", projectName)
 	}
 
 	return nil
 }
 
 // aggressiveProjectCleanup performs comprehensive project cleanup
-func (d *DockerService) aggressiveProjectCleanup(projectName, composeFile, workDir string) error {
-	d.logger.Warning("Performing aggressive project cleanup for: %s", projectName)
-	containers, err := d.getProjectContainers(projectName, composeFile, workDir)
+func (d *DockerService) aggressiveProjectCleanup(logger *utils.Logger, repo models.Repository, projectName, composeFile, workDir string, secretEnv []string) error {
+	logger.Warning("Performing aggressive project cleanup for: %s", projectName)
+	containers, err := d.getProjectContainers(repo, projectName, composeFile, workDir, secretEnv)
 	if err != nil {
-		d.logger.Warning("Failed to get project containers via compose: %v", err)
+		logger.Warning("Failed to get project containers via compose: %v", err)
 	}
-	for _, container := range containers {
-		d.logger.Docker("Force removing project container: %s", container)
-		removeCmd := exec.Command("docker", "rm", "-f", container)
-		if removeErr := removeCmd.Run(); removeErr != nil {
-			d.logger.Warning("Failed to remove container %s: %v", container, removeErr)
-		} else {
-			d.logger.Success("Removed container: %s", container)
-		}
+	if removeErr := d.removeContainers(logger, containers, "project"); removeErr != nil {
+		logger.Warning("Some project containers could not be removed: %v", removeErr)
+	}
+	if repo.StrictConflicts {
+		logger.Docker("Strict conflicts enabled, skipping name pattern cleanup")
+		return nil
 	}
-	d.logger.Docker("Cleaning up containers by name pattern...")
-	return d.cleanupContainersByPattern(projectName)
+	logger.Docker("Cleaning up containers by name pattern...")
+	return d.cleanupContainersByPattern(logger, projectName)
 }
 
 // getProjectContainers gets containers for a specific docker-compose project
-func (d *DockerService) getProjectContainers(projectName, composeFile, workDir string) ([]string, error) {
-	args := d.buildComposeArgs(composeFile, projectName, "ps", "-q")
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Dir = workDir
+func (d *DockerService) getProjectContainers(repo models.Repository, projectName, composeFile, workDir string, secretEnv []string) ([]string, error) {
+	cmd := d.composeCmd(context.Background(), repo, composeFile, projectName, workDir, secretEnv, "ps", "-q")
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -167,108 +535,283 @@ func (d *DockerService) getProjectContainers(projectName, composeFile, workDir s
 	return containers, nil
 }
 
-// cleanupContainersByPattern removes containers matching project name patterns
-func (d *DockerService) cleanupContainersByPattern(projectName string) error {
-	d.logger.Docker("Cleaning up containers by pattern for project: %s", projectName)
-	cmd := exec.Command("docker", "ps", "-a", "--format", "{{.Names}}")
+// labelScopedCleanupOnly reports whether cleanup must be limited to containers carrying the project label
+func (d *DockerService) labelScopedCleanupOnly() bool {
+	config := d.currentConfig()
+	return config != nil && config.Settings.LabelScopedCleanupOnly
+}
+
+// configuredProjectNames returns the compose projects of every configured branch, sorted
+func (d *DockerService) configuredProjectNames() []string {
+	config := d.currentConfig()
+	if config == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, repo := range config.Repositories {
+		for _, branch := range repo.Branches {
+			for _, name := range ProjectNames(repo, branch) {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cleanupProjectContainersByLabel removes only containers labelled with the given compose project
+func (d *DockerService) cleanupProjectContainersByLabel(logger *utils.Logger, projectName string) error {
+	logger.Docker("Label-scoped cleanup for project: %s", projectName)
+	cmd := d.command("docker", "ps", "-a", "--filter", fmt.Sprintf("label=com.docker.compose.project=%s", projectName), "--format", "{{.Names}}")
 	output, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to list containers: %v", err)
 	}
-	containers := strings.Split(strings.TrimSpace(string(output)), "\n")
+
+	var containers []string
+	for _, container := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if container = strings.TrimSpace(container); container != "" {
+			containers = append(containers, container)
+		}
+	}
+	return d.removeContainers(logger, containers, "project")
+}
+
+// cleanupWorkers returns how many containers cleanup removes in parallel
+func (d *DockerService) cleanupWorkers() int {
+	config := d.currentConfig()
+	if config == nil || config.Settings.CleanupWorkers < 1 {
+		return 1
+	}
+	return config.Settings.CleanupWorkers
+}
+
+// removeContainers force removes containers with a bounded worker pool and returns the collected errors
+func (d *DockerService) removeContainers(logger *utils.Logger, containers []string, kind string) error {
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for i := 0; i < d.cleanupWorkers() && i < len(containers); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for container := range jobs {
+				logger.Docker("Removing %s container: %s", kind, container)
+				if err := d.command("docker", "rm", "-f", container).Run(); err != nil {
+					logger.Warning("Failed to remove %s container %s: %v", kind, container, err)
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("%s: %v", container, err))
+					mu.Unlock()
+					continue
+				}
+				logger.Success("Removed %s container: %s", kind, container)
+			}
+		}()
+	}
 	for _, container := range containers {
+		jobs <- container
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// cleanupContainersByPattern removes containers matching project name patterns
+func (d *DockerService) cleanupContainersByPattern(logger *utils.Logger, projectName string) error {
+	if d.labelScopedCleanupOnly() {
+		return d.cleanupProjectContainersByLabel(logger, projectName)
+	}
+	logger.Docker("Cleaning up containers by pattern for project: %s", projectName)
+	cmd := d.command("docker", "ps", "-a", "--format", "{{.Names}}")
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %v", err)
+	}
+	var matched []string
+	for _, container := range strings.Split(strings.TrimSpace(string(output)), "\n") {
 		container = strings.TrimSpace(container)
 		if container != "" && (strings.Contains(container, projectName) || strings.HasPrefix(container, projectName)) {
-			d.logger.Docker("Removing container by pattern: %s", container)
-			removeCmd := exec.Command("docker", "rm", "-f", container)
-			if removeErr := removeCmd.Run(); removeErr != nil {
-				d.logger.Warning("Failed to remove container %s: %v", container, removeErr)
-			} else {
-				d.logger.Success("Removed container: %s", container)
-			}
+			matched = append(matched, container)
 		}
 	}
 
-	return nil
+	return d.removeContainers(logger, matched, "pattern-matched")
 }
 
 // startServices starts Docker Compose services with enhanced conflict resolution
-func (d *DockerService) startServices(composeFile, projectName, workDir string) error {
-	d.logger.Docker("Starting services for project: %s", projectName)
-	d.logger.Docker("Performing proactive cleanup...")
-	if cleanupErr := d.cleanupContainersByPattern(projectName); cleanupErr != nil {
-		d.logger.Warning("Proactive cleanup failed: %v", cleanupErr)
+func (d *DockerService) startServices(ctx context.Context, repo models.Repository, composeFile, projectName, workDir string, only, secretEnv, secretValues []string) error {
+	logger := loggerFrom(ctx, d.logger)
+	logger.Docker("Starting services for project: %s", projectName)
+	config := d.currentConfig()
+	// compose recreates and removes the containers of the project itself, name-based cleanup
+	// before the first attempt is opt-in and otherwise only runs after a conflict
+	if config.Settings.ProactiveCleanup && !repo.StrictConflicts && !repo.PreserveVolumes && len(only) == 0 {
+		logger.Docker("Performing proactive cleanup...")
+		if cleanupErr := d.cleanupContainersByPattern(logger, projectName); cleanupErr != nil {
+			logger.Warning("Proactive cleanup failed: %v", cleanupErr)
+		}
+	}
+	maxRetries := config.Settings.ConflictRetries
+	if maxRetries < 1 {
+		maxRetries = 1
 	}
-	maxRetries := 3
+	retryDelay := time.Duration(config.Settings.ConflictRetryDelay) * time.Second
+	logger.Docker("Conflict resolution: up to %d attempts, initial retry delay %v", maxRetries, retryDelay)
+
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		d.logger.Docker("Attempt %d/%d: Starting services...", attempt, maxRetries)
+		logger.Docker("Attempt %d/%d: Starting services...", attempt, maxRetries)
 
-		args := d.buildComposeArgs(composeFile, projectName, "up", "-d", "--build", "--force-recreate", "--remove-orphans")
-		cmd := exec.Command(args[0], args[1:]...)
-		cmd.Dir = workDir
-		cmd.Env = append(cmd.Env, fmt.Sprintf("COMPOSE_PROJECT_NAME=%s", projectName))
+		cmd := d.composeCmd(ctx, repo, composeFile, projectName, workDir, secretEnv, upArgs(repo, only, false)...)
 		output, err := cmd.CombinedOutput()
 		if err == nil {
-			d.logger.Success("Successfully started services for: %s", projectName)
+			logger.Success("Successfully started services for: %s", projectName)
 			return nil
 		}
-		d.logger.Warning("Attempt %d failed: %v", attempt, err)
-		d.logger.Warning("Output: %s", string(output))
+		if ctx.Err() != nil {
+			return fmt.Errorf("docker compose up interrupted: %w", ctx.Err())
+		}
+		outputStr := maskSecrets(string(output), secretValues)
+		logger.Warning("Attempt %d failed: %v", attempt, err)
+		logger.Warning("Output: %s", outputStr)
 
-		outputStr := string(output)
 		if strings.Contains(outputStr, "already in use") ||
 			strings.Contains(outputStr, "Conflict") ||
 			strings.Contains(outputStr, "container name") ||
 			strings.Contains(outputStr, "is already in use by container") {
 
-			d.logger.Warning("Container conflict detected on attempt %d, performing aggressive cleanup...", attempt)
-			if aggressiveErr := d.aggressiveContainerCleanup(projectName, outputStr); aggressiveErr != nil {
-				d.logger.Warning("Aggressive cleanup failed: %v", aggressiveErr)
+			if repo.StrictConflicts {
+				return d.conflictDiagnostic(logger, projectName, outputStr)
+			}
+
+			logger.Warning("Container conflict detected on attempt %d, performing aggressive cleanup...", attempt)
+			if aggressiveErr := d.aggressiveContainerCleanup(logger, projectName, outputStr); aggressiveErr != nil {
+				logger.Warning("Aggressive cleanup failed: %v", aggressiveErr)
 			}
 			if attempt < maxRetries {
-				d.logger.Docker("Waiting 3 seconds before retry...")
-				time.Sleep(3 * time.Second)
+				delay := retryDelay * time.Duration(1<<(attempt-1))
+				logger.Docker("Waiting %v before retry...", delay)
+				select {
+				case <-ctx.Done():
+					return fmt.Errorf("docker compose up interrupted: %w", ctx.Err())
+				case <-time.After(delay):
+				}
 			}
 		} else {
-			return fmt.Errorf("docker compose up failed: %v, output: %s", err, output)
+			return fmt.Errorf("docker compose up failed: %v, output: %s", err, outputStr)
 		}
 	}
 	return fmt.Errorf("failed to start services after %d attempts", maxRetries)
 }
 
+// upArgs returns the compose up arguments of a deploy, for only the given services when only is not empty;
+// without build the images built by the separate build phase are used
+func upArgs(repo models.Repository, only []string, build bool) []string {
+	buildFlag := "--no-build"
+	if build {
+		buildFlag = "--build"
+	}
+	args := append([]string{"up", "-d", buildFlag, "--force-recreate"}, orphanArgs(repo)...)
+	if repo.PreserveVolumes {
+		// only containers whose image or configuration changed are recreated, compose carries their anonymous volumes over
+		args = append([]string{"up", "-d", buildFlag}, orphanArgs(repo)...)
+	}
+	if len(only) > 0 {
+		// --no-deps keeps dependencies such as databases from being recreated, --remove-orphans would not apply to a subset
+		args = []string{"up", "-d", buildFlag, "--no-deps"}
+		if !repo.PreserveVolumes {
+			args = append(args, "--force-recreate")
+		}
+		args = append(args, only...)
+	}
+	return args
+}
+
+// orphanArgs returns the --remove-orphans flag unless the repository disabled it with remove_orphans
+func orphanArgs(repo models.Repository) []string {
+	if repo.RemoveOrphans != nil && !*repo.RemoveOrphans {
+		return nil
+	}
+	return []string{"--remove-orphans"}
+}
+
+// conflictDiagnostic builds the error returned for a name conflict when strict_conflicts is enabled
+func (d *DockerService) conflictDiagnostic(logger *utils.Logger, projectName, outputStr string) error {
+	containerName := d.extractConflictingContainerName(logger, outputStr)
+	if containerName == "" {
+		logger.Error("Container name conflict for project %s (strict_conflicts enabled, nothing removed)", projectName)
+		return fmt.Errorf("container name conflict for project %s (strict_conflicts enabled, nothing removed): %s", projectName, strings.TrimSpace(outputStr))
+	}
+
+	owner := d.getContainerProject(containerName)
+	if owner == "" {
+		owner = "none (not managed by docker compose)"
+	}
+	logger.Error("Container name conflict: %s is owned by project %s, deploying project %s (strict_conflicts enabled, nothing removed)",
+		containerName, owner, projectName)
+	return fmt.Errorf("container name conflict: container %s is owned by project %s, refusing to remove it for project %s (strict_conflicts enabled)",
+		containerName, owner, projectName)
+}
+
+// getContainerProject returns the compose project label of a container
+func (d *DockerService) getContainerProject(containerName string) string {
+	cmd := d.command("docker", "inspect", "--format", `{{index .Config.Labels "com.docker.compose.project"}}`, containerName)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
 // aggressiveContainerCleanup performs targeted container removal based on error analysis
-func (d *DockerService) aggressiveContainerCleanup(projectName string, outputStr string) error {
-	d.logger.Warning("Performing aggressive container cleanup...")
-	containerName := d.extractConflictingContainerName(outputStr)
+func (d *DockerService) aggressiveContainerCleanup(logger *utils.Logger, projectName string, outputStr string) error {
+	logger.Warning("Performing aggressive container cleanup...")
+	containerName := d.extractConflictingContainerName(logger, outputStr)
+
+	if d.labelScopedCleanupOnly() {
+		if containerName != "" {
+			if owner := d.getContainerProject(containerName); owner != projectName {
+				logger.Warning("Not removing conflicting container %s: it does not belong to project %s (label_scoped_cleanup_only)", containerName, projectName)
+				return fmt.Errorf("conflicting container %s is not labelled with project %s", containerName, projectName)
+			}
+		}
+		return d.cleanupProjectContainersByLabel(logger, projectName)
+	}
 
 	if containerName != "" {
-		d.logger.Warning("Removing specific conflicting container: %s", containerName)
-		removeCmd := exec.Command("docker", "rm", "-f", containerName)
+		logger.Warning("Removing specific conflicting container: %s", containerName)
+		removeCmd := d.command("docker", "rm", "-f", containerName)
 		if removeErr := removeCmd.Run(); removeErr != nil {
-			d.logger.Warning("Failed to remove specific container %s: %v", containerName, removeErr)
+			logger.Warning("Failed to remove specific container %s: %v", containerName, removeErr)
 		} else {
-			d.logger.Success("Successfully removed conflicting container: %s", containerName)
+			logger.Success("Successfully removed conflicting container: %s", containerName)
 			return nil
 		}
 	}
-	d.logger.Warning("Falling back to pattern-based cleanup for project: %s", projectName)
-	if err := d.cleanupContainersByPattern(projectName); err != nil {
-		d.logger.Warning("Pattern-based cleanup failed: %v", err)
+	logger.Warning("Falling back to pattern-based cleanup for project: %s", projectName)
+	if err := d.cleanupContainersByPattern(logger, projectName); err != nil {
+		logger.Warning("Pattern-based cleanup failed: %v", err)
 	}
-	d.logger.Warning("Attempting cleanup of containers with similar names...")
-	return d.cleanupSimilarContainers(projectName)
+	logger.Warning("Attempting cleanup of containers with similar names...")
+	return d.cleanupSimilarContainers(logger, projectName)
 }
 
 // extractConflictingContainerName extracts container name from Docker error messages
-func (d *DockerService) extractConflictingContainerName(outputStr string) string {
-	d.logger.Docker("Analyzing error output for container name extraction...")
+func (d *DockerService) extractConflictingContainerName(logger *utils.Logger, outputStr string) string {
+	logger.Docker("Analyzing error output for container name extraction...")
 	if idx := strings.Index(outputStr, "The container name"); idx != -1 {
 		remaining := outputStr[idx:]
 		if start := strings.Index(remaining, `"/`); start != -1 {
 			nameStart := start + 2 // Skip `"/`
 			if end := strings.Index(remaining[nameStart:], `"`); end != -1 {
 				containerName := remaining[nameStart : nameStart+end]
-				d.logger.Docker("Extracted container name from pattern 1: %s", containerName)
+				logger.Docker("Extracted container name from pattern 1: %s", containerName)
 				return containerName
 			}
 		}
@@ -280,7 +823,7 @@ func (d *DockerService) extractConflictingContainerName(outputStr string) string
 			for i, part := range parts {
 				if part == "Container" && i+1 < len(parts) {
 					containerName := parts[i+1]
-					d.logger.Docker("Extracted container name from pattern 2: %s", containerName)
+					logger.Docker("Extracted container name from pattern 2: %s", containerName)
 					return containerName
 				}
 			}
@@ -290,7 +833,7 @@ func (d *DockerService) extractConflictingContainerName(outputStr string) string
 		nameStart := start + 2
 		if end := strings.Index(outputStr[nameStart:], `"`); end != -1 {
 			containerName := outputStr[nameStart : nameStart+end]
-			d.logger.Docker("Extracted container name from pattern 3: %s", containerName)
+			logger.Docker("Extracted container name from pattern 3: %s", containerName)
 			return containerName
 		}
 	}
@@ -302,19 +845,19 @@ func (d *DockerService) extractConflictingContainerName(outputStr string) string
 				containerID := remaining[nameStart : nameStart+end]
 				// Try to get container name from ID
 				if containerName := d.getContainerNameFromID(containerID); containerName != "" {
-					d.logger.Docker("Extracted container name from ID: %s -> %s", containerID, containerName)
+					logger.Docker("Extracted container name from ID: %s -> %s", containerID, containerName)
 					return containerName
 				}
 			}
 		}
 	}
-	d.logger.Warning("Could not extract container name from error output")
+	logger.Warning("Could not extract container name from error output")
 	return ""
 }
 
 // getContainerNameFromID converts container ID to container name
 func (d *DockerService) getContainerNameFromID(containerID string) string {
-	cmd := exec.Command("docker", "inspect", "--format", "{{.Name}}", containerID)
+	cmd := d.command("docker", "inspect", "--format", "{{.Name}}", containerID)
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
@@ -328,9 +871,12 @@ func (d *DockerService) getContainerNameFromID(containerID string) string {
 }
 
 // cleanupSimilarContainers removes containers with names similar to the project
-func (d *DockerService) cleanupSimilarContainers(projectName string) error {
-	d.logger.Docker("Cleaning up containers with similar names to: %s", projectName)
-	cmd := exec.Command("docker", "ps", "-a", "--format", "{{.Names}}")
+func (d *DockerService) cleanupSimilarContainers(logger *utils.Logger, projectName string) error {
+	if d.labelScopedCleanupOnly() {
+		return d.cleanupProjectContainersByLabel(logger, projectName)
+	}
+	logger.Docker("Cleaning up containers with similar names to: %s", projectName)
+	cmd := d.command("docker", "ps", "-a", "--format", "{{.Names}}")
 	output, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to list containers: %v", err)
@@ -339,38 +885,36 @@ func (d *DockerService) cleanupSimilarContainers(projectName string) error {
 	containers := strings.Split(strings.TrimSpace(string(output)), "\n")
 	projectParts := strings.Split(projectName, "-")
 
+	var similar []string
 	for _, container := range containers {
 		container = strings.TrimSpace(container)
 		if container == "" {
 			continue
 		}
-		shouldRemove := false
 		for _, part := range projectParts {
 			if part != "" && strings.Contains(container, part) {
-				shouldRemove = true
+				similar = append(similar, container)
 				break
 			}
 		}
-
-		if shouldRemove {
-			d.logger.Docker("Removing similar container: %s", container)
-			removeCmd := exec.Command("docker", "rm", "-f", container)
-			if removeErr := removeCmd.Run(); removeErr != nil {
-				d.logger.Warning("Failed to remove similar container %s: %v", container, removeErr)
-			} else {
-				d.logger.Success("Removed similar container: %s", container)
-			}
-		}
 	}
 
-	return nil
+	return d.removeContainers(logger, similar, "similar")
 }
 
-// getServices returns the list of services
-func (d *DockerService) getServices(composeFile, projectName, workDir string) ([]string, error) {
-	args := d.buildComposeArgs(composeFile, projectName, "ps", "--services")
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Dir = workDir
+// getServices returns the services of a project that have containers
+func (d *DockerService) getServices(ctx context.Context, repo models.Repository, composeFile, projectName, workDir string, secretEnv []string) ([]string, error) {
+	return d.listServices(ctx, repo, composeFile, projectName, workDir, secretEnv, "ps")
+}
+
+// definedServices returns the services defined by a compose file, whether or not they have containers
+func (d *DockerService) definedServices(ctx context.Context, repo models.Repository, composeFile, projectName, workDir string, secretEnv []string) ([]string, error) {
+	return d.listServices(ctx, repo, composeFile, projectName, workDir, secretEnv, "config")
+}
+
+// listServices runs a compose subcommand with --services and returns the listed names
+func (d *DockerService) listServices(ctx context.Context, repo models.Repository, composeFile, projectName, workDir string, secretEnv []string, subcommand string) ([]string, error) {
+	cmd := d.composeCmd(ctx, repo, composeFile, projectName, workDir, secretEnv, subcommand, "--services")
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -388,23 +932,129 @@ func (d *DockerService) getServices(composeFile, projectName, workDir string) ([
 	return filteredServices, nil
 }
 
-// buildComposeArgs builds the command arguments for Docker Compose
-func (d *DockerService) buildComposeArgs(composeFile, projectName string, subcommands ...string) []string {
-	var args []string
+// noServicesError explains why a started project has no services: its compose file defines none, or the
+// defined services are not running
+func (d *DockerService) noServicesError(ctx context.Context, repo models.Repository, unit models.ComposeUnit, repoPath string, secretEnv []string) error {
+	services, err := d.definedServices(ctx, repo, unit.ComposeFile, unit.ProjectName, repoPath, secretEnv)
+	if err == nil && len(services) == 0 {
+		return fmt.Errorf("%w: compose file %s defines no services", ErrNoServices, unit.ComposeFile)
+	}
+	return fmt.Errorf("%w: no services of project %s are running after up", ErrNoServices, unit.ProjectName)
+}
 
-	if d.composeCommand == "docker compose" {
-		args = []string{"docker", "compose", "-f", composeFile, "-p", projectName}
-	} else {
-		args = []string{"docker-compose", "-f", composeFile, "-p", projectName}
+// ValidateComposeCommand checks that the binary of a compose command is installed
+func ValidateComposeCommand(command string) error {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return fmt.Errorf("compose command is empty")
+	}
+	if _, err := exec.LookPath(fields[0]); err != nil {
+		return fmt.Errorf("compose command %q not found: %v", command, err)
 	}
+	return nil
+}
+
+// ComposeCommandFor returns the compose command of a repository, its compose_command override or the detected default
+func (d *DockerService) ComposeCommandFor(repo models.Repository) string {
+	if repo.ComposeCommand != "" {
+		return repo.ComposeCommand
+	}
+	return d.composeCommand
+}
 
+// buildComposeArgs builds the command arguments for Docker Compose
+func (d *DockerService) buildComposeArgs(repo models.Repository, composeFile, projectName string, subcommands ...string) []string {
+	args := strings.Fields(d.ComposeCommandFor(repo))
+	args = append(args, "-f", composeFile, "-p", projectName)
 	args = append(args, subcommands...)
 	return args
 }
 
-// GetStatusOutput returns formatted container status
+// composeCmd creates a compose command for a project run in workDir with the project name and the branch secrets
+// in its environment; every compose call goes through it so variables resolve the same as on deploy
+func (d *DockerService) composeCmd(ctx context.Context, repo models.Repository, composeFile, projectName, workDir string, secretEnv []string, subcommands ...string) *exec.Cmd {
+	args := d.buildComposeArgs(repo, composeFile, projectName, subcommands...)
+	cmd := d.commandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = workDir
+	cmd.Env = append(cmd.Env, fmt.Sprintf("COMPOSE_PROJECT_NAME=%s", projectName))
+	cmd.Env = append(cmd.Env, secretEnv...)
+	return cmd
+}
+
+// GetRunningProjectContainers returns the running containers of every compose project of a repository branch
+func (d *DockerService) GetRunningProjectContainers(repo models.Repository, branch string) ([]string, error) {
+	var containers []string
+	for _, projectName := range ProjectNames(repo, branch) {
+		cmd := d.command("docker", "ps", "--filter", fmt.Sprintf("label=com.docker.compose.project=%s", projectName),
+			"--filter", "status=running", "--format", "{{.Names}}")
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list containers for project %s: %v", projectName, err)
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				containers = append(containers, line)
+			}
+		}
+	}
+	return containers, nil
+}
+
+// GetFailedProjectContainers returns the dead or non-zero exited containers of every compose project of a repository branch
+func (d *DockerService) GetFailedProjectContainers(repo models.Repository, branch string) ([]string, error) {
+	var containers []string
+	for _, projectName := range ProjectNames(repo, branch) {
+		cmd := d.command("docker", "ps", "-a", "--filter", fmt.Sprintf("label=com.docker.compose.project=%s", projectName),
+			"--filter", "status=exited", "--filter", "status=dead", "--format", "{{.Names}}\t{{.Status}}")
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list containers for project %s: %v", projectName, err)
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+			name, status, _ := strings.Cut(strings.TrimSpace(line), "\t")
+			// one-shot services such as migrations exit with 0 and are not failures
+			if name == "" || strings.HasPrefix(status, "Exited (0)") {
+				continue
+			}
+			containers = append(containers, name)
+		}
+	}
+	return containers, nil
+}
+
+// GetManagedProjects returns the compose projects with running containers whose project directory lies in the work directory, mapped to that directory
+func (d *DockerService) GetManagedProjects() (map[string]string, error) {
+	workDir, err := filepath.Abs(d.currentConfig().Settings.WorkDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve work directory: %v", err)
+	}
+
+	cmd := d.command("docker", "ps", "--filter", "label=com.docker.compose.project",
+		"--format", `{{.Label "com.docker.compose.project"}}\t{{.Label "com.docker.compose.project.working_dir"}}`)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list compose containers: %v", err)
+	}
+
+	projects := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		projectName, projectDir, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		if projectName == "" {
+			continue
+		}
+		if rel, err := filepath.Rel(workDir, projectDir); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		projects[projectName] = projectDir
+	}
+	return projects, nil
+}
+
+// GetStatusOutput returns formatted container status as a human readable table
 func (d *DockerService) GetStatusOutput() (string, error) {
-	cmd := exec.Command("docker", "ps", "--format",
+	cmd := d.command("docker", "ps", "--format",
 		"table {{.Names}}\t{{.Status}}\t{{.Ports}}\t{{.Image}}")
 	output, err := cmd.Output()
 	if err != nil {
@@ -413,44 +1063,104 @@ func (d *DockerService) GetStatusOutput() (string, error) {
 	return string(output), nil
 }
 
+// ContainerStatus is a container record as listed by docker ps
+type ContainerStatus struct {
+	Name   string `json:"Names"`
+	Status string `json:"Status"`
+	Ports  string `json:"Ports"`
+	Image  string `json:"Image"`
+	State  string `json:"State"`
+}
+
+// GetStatusJSON lists running containers as structured records, one JSON object per line from docker ps
+func (d *DockerService) GetStatusJSON() ([]ContainerStatus, error) {
+	cmd := d.command("docker", "ps", "--format", "{{json .}}")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseContainerStatus(output)
+}
+
+// parseContainerStatus parses docker ps --format '{{json .}}' output, one container per line
+func parseContainerStatus(output []byte) ([]ContainerStatus, error) {
+	var containers []ContainerStatus
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		var container ContainerStatus
+		if err := json.Unmarshal([]byte(line), &container); err != nil {
+			return nil, fmt.Errorf("failed to parse docker ps output: %v", err)
+		}
+		containers = append(containers, container)
+	}
+	return containers, nil
+}
+
 // cleanupConflictingContainers removes containers that might be causing name conflicts (legacy method)
-func (d *DockerService) cleanupConflictingContainers(projectName string) error {
-	d.logger.Docker("Legacy cleanup: Cleaning up conflicting containers for project: %s", projectName)
-	cmd := exec.Command("docker", "ps", "-a", "--filter", fmt.Sprintf("name=%s", projectName), "--format", "{{.Names}}")
+func (d *DockerService) cleanupConflictingContainers(logger *utils.Logger, projectName string) error {
+	if d.labelScopedCleanupOnly() {
+		return d.cleanupProjectContainersByLabel(logger, projectName)
+	}
+	logger.Docker("Legacy cleanup: Cleaning up conflicting containers for project: %s", projectName)
+	cmd := d.command("docker", "ps", "-a", "--filter", fmt.Sprintf("name=%s", projectName), "--format", "{{.Names}}")
 	output, err := cmd.Output()
 	if err != nil {
 		return fmt.Errorf("failed to list containers: %v", err)
 	}
 
-	containers := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, container := range containers {
+	var containers []string
+	for _, container := range strings.Split(strings.TrimSpace(string(output)), "\n") {
 		if container != "" {
-			d.logger.Docker("Removing conflicting container: %s", container)
-			removeCmd := exec.Command("docker", "rm", "-f", container)
-			if removeErr := removeCmd.Run(); removeErr != nil {
-				d.logger.Warning("Failed to remove container %s: %v", container, removeErr)
-			}
+			containers = append(containers, container)
 		}
 	}
 
-	return nil
+	return d.removeContainers(logger, containers, "conflicting")
+}
+
+// DockerRootDir returns the Docker data root where images, containers and volumes are stored
+func (d *DockerService) DockerRootDir() (string, error) {
+	output, err := d.command("docker", "info", "--format", "{{.DockerRootDir}}").Output()
+	if err != nil {
+		return "", fmt.Errorf("docker info failed: %v", err)
+	}
+	rootDir := strings.TrimSpace(string(output))
+	if rootDir == "" {
+		return "", fmt.Errorf("docker info returned no data root")
+	}
+	return rootDir, nil
 }
 
 // Cleanup removes unused Docker resources (only if cleanup_enabled is true)
 func (d *DockerService) Cleanup() error {
 	d.logger.Info("Starting Docker cleanup...")
 
-	cmd := exec.Command("docker", "container", "prune", "-f")
-	if err := cmd.Run(); err != nil {
+	if d.labelScopedCleanupOnly() {
+		// one prune per project, a label filter with a value matches that project only
+		for _, projectName := range d.configuredProjectNames() {
+			cmd := d.command("docker", "container", "prune", "-f", "--filter", "label=com.docker.compose.project="+projectName)
+			if err := cmd.Run(); err != nil {
+				d.logger.Warning("Failed to cleanup containers of project %s: %v", projectName, err)
+			}
+		}
+	} else if err := d.command("docker", "container", "prune", "-f").Run(); err != nil {
 		d.logger.Warning("Failed to cleanup containers: %v", err)
 	}
 
-	cmd = exec.Command("docker", "image", "prune", "-f")
+	cmd := d.command("docker", "image", "prune", "-f")
 	if err := cmd.Run(); err != nil {
 		d.logger.Warning("Failed to cleanup images: %v", err)
 	}
 
-	cmd = exec.Command("docker", "volume", "prune", "-f")
+	if d.labelScopedCleanupOnly() {
+		d.logger.Docker("Skipping volume prune (label_scoped_cleanup_only)")
+		d.logger.Success("Docker cleanup completed")
+		return nil
+	}
+
+	cmd = d.command("docker", "volume", "prune", "-f")
 	if err := cmd.Run(); err != nil {
 		d.logger.Warning("Failed to cleanup volumes: %v", err)
 	}
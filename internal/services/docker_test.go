@@ -0,0 +1,604 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"uruflow.com/internal/models"
+)
+
+// dockerCLI is a docker executable on PATH that records its calls; compose config --services and
+// compose ps --services answer from files so a test can change the defined and the running services.
+// compose config --format json answers from a file too, and the image commands keep "ref<tab>created"
+// lines in an images file.
+type dockerCLI struct {
+	calls    string
+	services string
+	running  string
+	config   string
+	images   string
+}
+
+// newDockerCLI puts the recording docker first on PATH, listing the given compose services
+func newDockerCLI(t *testing.T, services ...string) *dockerCLI {
+	t.Helper()
+	dir := t.TempDir()
+	cli := &dockerCLI{
+		calls:    filepath.Join(dir, "calls"),
+		services: filepath.Join(dir, "services"),
+		running:  filepath.Join(dir, "running"),
+		config:   filepath.Join(dir, "config"),
+		images:   filepath.Join(dir, "images"),
+	}
+	cli.setServices(t, services...)
+	cli.setRunning(t)
+	cli.setConfig(t, `{"services":{}}`)
+	cli.setImages(t)
+	// compose commands run without the inherited environment, so the script only uses shell builtins
+	script := fmt.Sprintf(`#!/bin/sh
+images=%q
+tab=$(printf '\t')
+others() {
+	while IFS= read -r line; do case "$line" in ""|"$1$tab"*) ;; *) printf '%%s\n' "$line" ;; esac; done < "$images"
+}
+printf '%%s|%%s\n' "$*" "$UF_SECRET" >> %q
+case "$*" in
+*"config --services"*) while IFS= read -r line; do printf '%%s\n' "$line"; done < %q ;;
+*"ps --services"*) while IFS= read -r line; do printf '%%s\n' "$line"; done < %q ;;
+*"config --format json"*) while IFS= read -r line; do printf '%%s\n' "$line"; done < %q ;;
+"image inspect "*)
+	while IFS= read -r line; do case "$line" in "$5$tab"*) exit 0 ;; esac; done < "$images"
+	exit 1 ;;
+"image tag "*)
+	created=
+	while IFS= read -r line; do case "$line" in "$3$tab"*) created=${line#*"$tab"} ;; esac; done < "$images"
+	[ -n "$created" ] || exit 1
+	rest=$(others "$4")
+	printf '%%s\n%%s\n' "$rest" "$4$tab$created" > "$images" ;;
+"image ls "*)
+	while IFS= read -r line; do case "$line" in "$3:"*) ref=${line%%%%"$tab"*}; printf '%%s\t%%s\n' "${ref#"$3:"}" "${line#*"$tab"}" ;; esac; done < "$images" ;;
+"image rm "*)
+	rest=$(others "$3")
+	printf '%%s\n' "$rest" > "$images" ;;
+esac
+`, cli.images, cli.calls, cli.services, cli.running, cli.config)
+	writeFile(t, filepath.Join(dir, "docker"), script)
+	if err := os.Chmod(filepath.Join(dir, "docker"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return cli
+}
+
+func (c *dockerCLI) setServices(t *testing.T, services ...string) {
+	t.Helper()
+	writeLines(t, c.services, services)
+}
+
+func (c *dockerCLI) setRunning(t *testing.T, services ...string) {
+	t.Helper()
+	writeLines(t, c.running, services)
+}
+
+// setConfig sets the output of compose config --format json
+func (c *dockerCLI) setConfig(t *testing.T, config string) {
+	t.Helper()
+	writeFile(t, c.config, config+"\n")
+}
+
+// setImages sets the local images as "ref<tab>created" lines
+func (c *dockerCLI) setImages(t *testing.T, images ...string) {
+	t.Helper()
+	writeLines(t, c.images, images)
+}
+
+// localImages returns the refs of the local images
+func (c *dockerCLI) localImages(t *testing.T) []string {
+	t.Helper()
+	data, err := os.ReadFile(c.images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if ref, _, _ := strings.Cut(line, "\t"); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+func writeLines(t *testing.T, path string, lines []string) {
+	t.Helper()
+	content := ""
+	for _, line := range lines {
+		content += line + "\n"
+	}
+	writeFile(t, path, content)
+}
+
+// compose returns the recorded compose calls as "args|UF_SECRET" lines
+func (c *dockerCLI) compose(t *testing.T) []string {
+	t.Helper()
+	return c.commands(t, "compose -f ")
+}
+
+// commands returns the recorded calls starting with prefix as "args|UF_SECRET" lines
+func (c *dockerCLI) commands(t *testing.T, prefix string) []string {
+	t.Helper()
+	data, err := os.ReadFile(c.calls)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	var calls []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			calls = append(calls, line)
+		}
+	}
+	return calls
+}
+
+// newTestDockerService returns a docker service using the recording docker in a branch checkout with a compose file
+func newTestDockerService(t *testing.T, services ...string) (*DockerService, *dockerCLI, string) {
+	t.Helper()
+	cli := newDockerCLI(t, services...)
+	repoPath := t.TempDir()
+	writeFile(t, filepath.Join(repoPath, "docker-compose.yml"), "services: {}\n")
+	config := &models.Config{Settings: models.Settings{WorkDir: t.TempDir()}}
+	return NewDockerService(config, newTestLogger(t)), cli, repoPath
+}
+
+func TestBuildPullAlways(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "main.env")
+	writeFile(t, envFile, "UF_SECRET=hunter2\n")
+
+	tests := []struct {
+		name  string
+		only  []string
+		build string
+		pull  string
+	}{
+		{"all services", nil, "build --pull|hunter2", "pull --ignore-pull-failures|hunter2"},
+		{"selected service", []string{"web"}, "build --pull web|hunter2", "pull --ignore-pull-failures web|hunter2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, cli, repoPath := newTestDockerService(t, "web", "worker")
+			repo := models.Repository{
+				Name:         "api",
+				PullAlways:   true,
+				BranchConfig: map[string]models.BranchEnvironment{"main": {EnvFile: envFile}},
+			}
+			if err := d.BuildServicesWithContext(context.Background(), repo, "main", repoPath, tt.only); err != nil {
+				t.Fatal(err)
+			}
+
+			var build, pull string
+			for _, call := range cli.compose(t) {
+				switch {
+				case strings.Contains(call, " build "):
+					build = call
+				case strings.Contains(call, " pull "):
+					pull = call
+				}
+			}
+			if !strings.HasSuffix(build, tt.build) {
+				t.Errorf("build call %q, want suffix %q", build, tt.build)
+			}
+			if !strings.HasSuffix(pull, tt.pull) {
+				t.Errorf("pull call %q, want suffix %q", pull, tt.pull)
+			}
+		})
+	}
+}
+
+func TestSelectServices(t *testing.T) {
+	tests := []struct {
+		name    string
+		only    []string
+		want    []string
+		wantErr string
+	}{
+		{"one service", []string{"web"}, []string{"web"}, ""},
+		{"two services", []string{"worker", "web"}, []string{"worker", "web"}, ""},
+		{"unknown service", []string{"web", "db"}, nil, "unknown service 'db' (available: web, worker)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the project has never run, so only the compose file knows its services
+			d, _, repoPath := newTestDockerService(t, "web", "worker")
+			repo := models.Repository{Name: "api"}
+			units, err := ComposeUnits(repo, "main", repoPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			selected, err := d.selectServices(context.Background(), repo, units, repoPath, tt.only, nil)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(selected[0], ","); got != strings.Join(tt.want, ",") {
+				t.Errorf("selected %s, want %s", got, strings.Join(tt.want, ","))
+			}
+		})
+	}
+}
+
+// dockerPSOutput is docker ps --format '{{json .}}' output of a compose web service and a plain container,
+// the second with several published ports and names
+const dockerPSOutput = `{"Command":"\"/docker-entrypoint.…\"","CreatedAt":"2026-10-14 08:12:01 +0000 UTC","ID":"3f4e1a2b9c8d","Image":"nginx:1.27-alpine","Labels":"com.docker.compose.project=api-main,com.docker.compose.service=web,com.docker.compose.config-hash=5b1e","LocalVolumes":"0","Mounts":"","Names":"api-main-web-1","Networks":"api-main_default","Ports":"0.0.0.0:8080->80/tcp, [::]:8080->80/tcp","RunningFor":"2 hours ago","Size":"0B","State":"running","Status":"Up 2 hours (healthy)"}
+{"Command":"\"docker-entrypoint.s…\"","CreatedAt":"2026-10-13 21:40:55 +0000 UTC","ID":"9a8b7c6d5e4f","Image":"postgres:16","Labels":"","LocalVolumes":"1","Mounts":"pgdata","Names":"db,legacy-db","Networks":"bridge","Ports":"5432/tcp, 0.0.0.0:5433->5433/tcp","RunningFor":"11 hours ago","Size":"63B (virtual 431MB)","State":"running","Status":"Up 11 hours"}
+`
+
+func TestParseContainerStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []ContainerStatus
+		wantErr bool
+	}{
+		{
+			name:   "compose and plain containers",
+			output: dockerPSOutput,
+			want: []ContainerStatus{
+				{Name: "api-main-web-1", Status: "Up 2 hours (healthy)", Ports: "0.0.0.0:8080->80/tcp, [::]:8080->80/tcp", Image: "nginx:1.27-alpine", State: "running"},
+				{Name: "db,legacy-db", Status: "Up 11 hours", Ports: "5432/tcp, 0.0.0.0:5433->5433/tcp", Image: "postgres:16", State: "running"},
+			},
+		},
+		{
+			name:   "windows line endings and blank lines",
+			output: "\r\n" + strings.ReplaceAll(dockerPSOutput, "\n", "\r\n") + "\r\n\r\n",
+			want: []ContainerStatus{
+				{Name: "api-main-web-1", Status: "Up 2 hours (healthy)", Ports: "0.0.0.0:8080->80/tcp, [::]:8080->80/tcp", Image: "nginx:1.27-alpine", State: "running"},
+				{Name: "db,legacy-db", Status: "Up 11 hours", Ports: "5432/tcp, 0.0.0.0:5433->5433/tcp", Image: "postgres:16", State: "running"},
+			},
+		},
+		{
+			name:   "no containers",
+			output: "\n",
+			want:   nil,
+		},
+		{
+			name:    "table output instead of json",
+			output:  "CONTAINER ID   IMAGE   COMMAND   CREATED   STATUS   PORTS   NAMES\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseContainerStatus([]byte(tt.output))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsed %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d containers, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("container %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDeployNoServices(t *testing.T) {
+	tests := []struct {
+		name            string
+		defined         []string
+		running         []string
+		requireServices bool
+		want            []string
+		wantErr         string
+	}{
+		{"running services", []string{"web", "worker"}, []string{"web", "worker"}, true, []string{"web", "worker"}, ""},
+		{"empty compose file warns", nil, nil, false, nil, ""},
+		{"empty compose file fails", nil, nil, true, nil, "compose file docker-compose.yml defines no services"},
+		{"defined services not running warns", []string{"web"}, nil, false, nil, ""},
+		{"defined services not running fails", []string{"web"}, nil, true, nil, "no services of project api-main are running after up"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, cli, repoPath := newTestDockerService(t, tt.defined...)
+			cli.setRunning(t, tt.running...)
+			if tt.defined == nil {
+				writeFile(t, filepath.Join(repoPath, "docker-compose.yml"), "")
+			}
+			d.UpdateConfig(&models.Config{Settings: models.Settings{WorkDir: t.TempDir(), RequireServices: tt.requireServices}})
+
+			services, err := d.StartServicesWithContext(context.Background(), models.Repository{Name: "api"}, "main", repoPath, nil)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrNoServices) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want ErrNoServices with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(services, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("deployed %v, want %v", services, tt.want)
+			}
+		})
+	}
+}
+
+func TestHostSSHWrapper(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	writeFile(t, filepath.Join(dir, "ssh"), fmt.Sprintf("#!/bin/sh\nprintf '%%s\\n' \"$*\" >> %q\n", calls))
+	if err := os.Chmod(filepath.Join(dir, "ssh"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	d := NewDockerService(&models.Config{}, newTestLogger(t))
+	if env := d.forHost(models.DeployHost{Name: "plain", DockerHost: "ssh://deploy@10.0.0.4"}).hostEnv; strings.Contains(strings.Join(env, " "), "PATH=") {
+		t.Errorf("host without SSH settings got %v", env)
+	}
+
+	identityFile := filepath.Join(t.TempDir(), "deploy's key")
+	host := models.DeployHost{
+		Name:         "web-1",
+		DockerHost:   "ssh://deploy@10.0.0.5",
+		IdentityFile: identityFile,
+		SSHOptions:   []string{"StrictHostKeyChecking=accept-new"},
+	}
+	hostService := d.forHost(host)
+	if d.forHost(host) != hostService {
+		t.Error("same host got a second service")
+	}
+
+	var wrapperDir string
+	for _, entry := range hostService.hostEnv {
+		if path, ok := strings.CutPrefix(entry, "PATH="); ok {
+			wrapperDir, _, _ = strings.Cut(path, string(os.PathListSeparator))
+		}
+	}
+	if wrapperDir == "" {
+		t.Fatalf("no PATH for the ssh wrapper in %v", hostService.hostEnv)
+	}
+	t.Cleanup(func() { os.RemoveAll(wrapperDir) })
+
+	if output, err := exec.Command(filepath.Join(wrapperDir, "ssh"), "-l", "deploy", "10.0.0.5", "docker", "system", "dial-stdio").CombinedOutput(); err != nil {
+		t.Fatalf("ssh wrapper: %v\n%s", err, output)
+	}
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "-i " + identityFile + " -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new -l deploy 10.0.0.5 docker system dial-stdio"
+	if got := strings.TrimSpace(string(data)); got != want {
+		t.Errorf("ssh called with %q, want %q", got, want)
+	}
+}
+
+func TestComposeSecretEnv(t *testing.T) {
+	envFile := filepath.Join(t.TempDir(), "main.env")
+	writeFile(t, envFile, "UF_SECRET=hunter2\n")
+	d, cli, repoPath := newTestDockerService(t, "web", "worker")
+	cli.setRunning(t, "web", "worker")
+	repo := models.Repository{
+		Name:         "api",
+		BranchConfig: map[string]models.BranchEnvironment{"main": {EnvFile: envFile}},
+	}
+
+	ctx := context.Background()
+	if _, err := d.LintCompose(ctx, repo, "main", repoPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.PlanWithContext(ctx, repo, "main", repoPath, []string{"web"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.DeployWithContext(ctx, repo, "main", repoPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Stop(repo, "main", repoPath); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := cli.compose(t)
+	for _, want := range []string{"config --quiet", "config --services", "--dry-run", "down", "up -d", "ps --services"} {
+		found := false
+		for _, call := range calls {
+			found = found || strings.Contains(call, " "+want)
+		}
+		if !found {
+			t.Errorf("no compose %s call in %v", want, calls)
+		}
+	}
+	for _, call := range calls {
+		if !strings.HasSuffix(call, "|hunter2") {
+			t.Errorf("compose call %q ran without the branch secrets", call)
+		}
+	}
+}
+
+// builtImagesConfig is a compose config with a service built under the project name, a built service
+// with an image name and a service that is only pulled
+const builtImagesConfig = `{"services":{"web":{"build":{"context":"."}},"worker":{"build":{"context":"."},"image":"registry/worker:1.0"},"db":{"image":"postgres:16"}}}`
+
+// imageCreated returns an image line for setImages created hours after a fixed time
+func imageCreated(ref string, hours int) string {
+	created := time.Date(2025, 1, 1, hours, 0, 0, 0, time.UTC)
+	return ref + "\t" + created.Format("2006-01-02 15:04:05 -0700 MST")
+}
+
+func TestBranchTagPrefix(t *testing.T) {
+	if a, b := branchTagPrefix("feature/x"), branchTagPrefix("feature-x"); a == b {
+		t.Fatalf("feature/x and feature-x share the tag prefix %s", a)
+	}
+	tag := commitImageTag("feature/x", "0123456789abcdef")
+	if !strings.HasPrefix(tag, "uruflow-feature-x-") || !isCommitTagOf(tag, branchTagPrefix("feature/x")) {
+		t.Fatalf("unexpected commit tag %s", tag)
+	}
+	if isCommitTagOf(tag, branchTagPrefix("feature-x")) {
+		t.Fatalf("%s was taken for a tag of feature-x", tag)
+	}
+}
+
+func TestRetainImages(t *testing.T) {
+	d, cli, repoPath := newTestDockerService(t)
+	cli.setConfig(t, builtImagesConfig)
+	repo := models.Repository{Name: "api", BranchConfig: map[string]models.BranchEnvironment{"feature/x": {ProjectName: "api-fx"}}}
+	branch, commit := "feature/x", "cccccccccccccccc"
+	older, oldest := commitImageTag(branch, "bbbbbbbbbbbbbbbb"), commitImageTag(branch, "aaaaaaaaaaaaaaaa")
+	otherBranch := commitImageTag("feature-x", "dddddddddddddddd")
+	cli.setImages(t,
+		imageCreated("api-fx-web:latest", 5),
+		imageCreated("api-fx-web:"+older, 3),
+		imageCreated("api-fx-web:"+oldest, 2),
+		imageCreated("api-fx-web:"+otherBranch, 4),
+		imageCreated("registry/worker:1.0", 5),
+		imageCreated("registry/worker:"+oldest, 2),
+		imageCreated("postgres:16", 1),
+	)
+
+	if err := d.RetainImages(context.Background(), repo, branch, repoPath, commit, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	tag := commitImageTag(branch, commit)
+	want := []string{
+		"api-fx-web:" + older,
+		"api-fx-web:" + otherBranch,
+		"api-fx-web:" + tag,
+		"api-fx-web:latest",
+		"postgres:16",
+		"registry/worker:" + oldest,
+		"registry/worker:" + tag,
+		"registry/worker:1.0",
+	}
+	sort.Strings(want)
+	if got := cli.localImages(t); !slices.Equal(got, want) {
+		t.Fatalf("expected images %v, got %v", want, got)
+	}
+}
+
+func TestRestoreImages(t *testing.T) {
+	repo := models.Repository{Name: "api"}
+	commit := "aaaaaaaaaaaaaaaa"
+	tag := commitImageTag("main", commit)
+
+	tests := []struct {
+		name     string
+		images   []string
+		restored bool
+	}{
+		{"every image kept", []string{"api-main-web:" + tag, "registry/worker:" + tag}, true},
+		{"one image missing", []string{"api-main-web:" + tag}, false},
+		{"kept for another branch", []string{"api-main-web:" + commitImageTag("main-x", commit), "registry/worker:" + tag}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, cli, repoPath := newTestDockerService(t)
+			cli.setConfig(t, builtImagesConfig)
+			images := []string{imageCreated("api-main-web:latest", 5), imageCreated("registry/worker:1.0", 5)}
+			for _, image := range tt.images {
+				images = append(images, imageCreated(image, 1))
+			}
+			cli.setImages(t, images...)
+
+			restored, err := d.RestoreImages(context.Background(), repo, "main", repoPath, commit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if restored != tt.restored {
+				t.Fatalf("expected restored %v, got %v", tt.restored, restored)
+			}
+
+			var want []string
+			if tt.restored {
+				want = []string{
+					"image tag api-main-web:" + tag + " api-main-web:latest|",
+					"image tag registry/worker:" + tag + " registry/worker:1.0|",
+				}
+			}
+			if got := cli.commands(t, "image tag "); !slices.Equal(got, want) {
+				t.Fatalf("expected tag calls %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestCleanupLabelScoped(t *testing.T) {
+	tests := []struct {
+		name        string
+		labelScoped bool
+		prunes      []string
+	}{
+		{"whole host", false, []string{"container prune -f|", "image prune -f|", "volume prune -f|"}},
+		{"configured projects only", true, []string{
+			"container prune -f --filter label=com.docker.compose.project=api-dev|",
+			"container prune -f --filter label=com.docker.compose.project=api-main|",
+			"container prune -f --filter label=com.docker.compose.project=shop|",
+			"image prune -f|",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := newDockerCLI(t)
+			config := &models.Config{
+				Settings: models.Settings{WorkDir: t.TempDir(), LabelScopedCleanupOnly: tt.labelScoped},
+				Repositories: []models.Repository{
+					{Name: "api", Branches: []string{"main", "dev"}},
+					{Name: "web", Branches: []string{"main"}, BranchConfig: map[string]models.BranchEnvironment{"main": {ProjectName: "shop"}}},
+				},
+			}
+			if err := NewDockerService(config, newTestLogger(t)).Cleanup(); err != nil {
+				t.Fatal(err)
+			}
+
+			var prunes []string
+			for _, call := range cli.commands(t, "") {
+				if strings.Contains(call, " prune ") {
+					prunes = append(prunes, call)
+				}
+			}
+			if !slices.Equal(prunes, tt.prunes) {
+				t.Fatalf("expected prunes %v, got %v", tt.prunes, prunes)
+			}
+		})
+	}
+}
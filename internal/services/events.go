@@ -0,0 +1,83 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"errors"
+	"sync"
+
+	"uruflow.com/internal/models"
+)
+
+// ErrTooManySubscribers is returned when the event bus already serves its maximum number of subscribers
+var ErrTooManySubscribers = errors.New("too many event subscribers")
+
+// EventBus fans deployment and notification events out to subscribers such as the /events stream
+type EventBus struct {
+	subscribers    map[chan models.NotificationEvent]struct{}
+	maxSubscribers int
+	mu             sync.Mutex
+}
+
+// NewEventBus creates an event bus accepting up to maxSubscribers concurrent subscribers
+func NewEventBus(maxSubscribers int) *EventBus {
+	return &EventBus{
+		subscribers:    make(map[chan models.NotificationEvent]struct{}),
+		maxSubscribers: maxSubscribers,
+	}
+}
+
+// Subscribe registers a new subscriber, the returned channel receives every published event
+func (b *EventBus) Subscribe() (chan models.NotificationEvent, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.subscribers) >= b.maxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+	ch := make(chan models.NotificationEvent, 32)
+	b.subscribers[ch] = struct{}{}
+	return ch, nil
+}
+
+// Unsubscribe removes a subscriber and closes its channel
+func (b *EventBus) Unsubscribe(ch chan models.NotificationEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.subscribers[ch]; exists {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Publish sends an event to every subscriber; subscribers that are not keeping up miss it rather than block deployments
+func (b *EventBus) Publish(event models.NotificationEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
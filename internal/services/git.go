@@ -21,10 +21,14 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -33,10 +37,49 @@ import (
 	"uruflow.com/internal/utils"
 )
 
+// ErrRemoteBranchNotFound is returned when a configured branch does not exist on the remote
+var ErrRemoteBranchNotFound = errors.New("remote branch not found")
+
+// ErrSSHAuthFailed is returned when a remote rejects the SSH connection or key of a git command
+var ErrSSHAuthFailed = errors.New("SSH authentication failed")
+
+// ErrGitNotFound is returned when the git executable cannot be found on PATH
+var ErrGitNotFound = errors.New("git executable not found")
+
+// gitInstallHint tells the operator how to fix a missing git binary
+const gitInstallHint = "install git (e.g. 'apt-get install git' or 'apk add git') and make sure it is on the PATH of the uruflow process"
+
+// CheckGitBinary verifies that the git executable is available and returns its path
+func CheckGitBinary() (string, error) {
+	path, err := exec.LookPath("git")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrGitNotFound, gitInstallHint)
+	}
+	return path, nil
+}
+
+// gitExecError maps a failure to start git to ErrGitNotFound, and returns nil for any other error
+func gitExecError(err error) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("%w: %s", ErrGitNotFound, gitInstallHint)
+	}
+	return nil
+}
+
+// isolatedGitEnv is added to every git command UruFlow runs so the host's global and system git config
+// (insteadOf rewrites, hooks, credential helpers) cannot change how repositories are cloned and fetched
+var isolatedGitEnv = []string{
+	"GIT_CONFIG_GLOBAL=/dev/null",
+	"GIT_CONFIG_NOSYSTEM=1",
+	// safe.directory is only honoured in protected config, which includes config passed via the environment
+	"GIT_CONFIG_COUNT=1",
+	"GIT_CONFIG_KEY_0=safe.directory",
+	"GIT_CONFIG_VALUE_0=*",
+}
+
 type GitService struct {
 	logger    *utils.Logger
 	sshHelper *helper.SSHHelper
-	gitMutex  sync.Mutex
 }
 
 func NewGitService(logger *utils.Logger) *GitService {
@@ -46,12 +89,12 @@ func NewGitService(logger *utils.Logger) *GitService {
 	}
 }
 
-// Initialize sets up Git service with SSH and safety configuration
+// Initialize sets up Git service with SSH
 func (g *GitService) Initialize() error {
 	g.logger.Info("Initializing Git service...")
 
-	if err := g.configureGitSafety(); err != nil {
-		g.logger.Warning("Failed to configure Git safety: %v", err)
+	if _, err := CheckGitBinary(); err != nil {
+		return err
 	}
 
 	g.logger.Info("Checking SSH setup...")
@@ -68,71 +111,71 @@ func (g *GitService) Initialize() error {
 	return nil
 }
 
-// configureGitSafety configures Git to handle ownership issues
-func (g *GitService) configureGitSafety() error {
-	g.gitMutex.Lock()
-	defer g.gitMutex.Unlock()
-
-	cmd := exec.Command("git", "config", "--global", "safe.directory", "*")
-	if err := cmd.Run(); err != nil {
-		g.logger.Warning("Failed to set global safe directory wildcard: %v", err)
-	}
-	// specific directory and sub-directory patterns that are used by Uruflow system
-	workDirPatterns := []string{
-		"/var/uruflow/repositories",
-		"/var/uruflow/repositories/*",
-		"/var/uruflow/repositories/*/*",
-		"/var/uruflow/repositories/*/*/*",
+// ensureRepositorySafety ensures a specific repository path is safe (optimized version).
+// The check and the fix run under the lock of the path, so concurrent deploys never repeat
+// the chown of a path. Git trusts the path through isolatedGitEnv, the global git config is left alone.
+func (g *GitService) ensureRepositorySafety(repoPath string) error {
+	entry := safetyEntry(repoPath)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.applied {
+		return nil
 	}
 
-	for _, pattern := range workDirPatterns {
-		cmd = exec.Command("git", "config", "--global", "--add", "safe.directory", pattern)
-		if err := cmd.Run(); err != nil {
-			g.logger.Warning("Failed to add safe directory pattern %s: %v", pattern, err)
+	isRoot := os.Getuid() == 0
+	if isRoot {
+		// ancestors are always locked after their descendants, so nested locking cannot deadlock
+		parentDir := filepath.Dir(repoPath)
+		for _, dir := range []string{filepath.Dir(parentDir), parentDir} {
+			g.chownOnce(dir)
 		}
 	}
-	cmd = exec.Command("git", "config", "--global", "safe.directory", "*")
-	if err := cmd.Run(); err != nil {
-		g.logger.Warning("Failed to disable dubious ownership detection: %v", err)
-	}
-
-	g.logger.Success("Git safety configuration completed")
-	return nil
-}
 
-// ensureRepositorySafety ensures a specific repository path is safe (optimized version)
-func (g *GitService) ensureRepositorySafety(repoPath string) error {
-	if g.hasAppliedSafety(repoPath) {
+	if _, err := os.Stat(repoPath); err != nil {
+		// not cloned yet, the fix runs again once the path exists
 		return nil
 	}
-	cmd := exec.Command("git", "config", "--global", "safe.directory", "*")
-	cmd.Run()
-	if os.Getuid() == 0 {
-		parentDir := filepath.Dir(repoPath)
-		grandParentDir := filepath.Dir(parentDir)
-		for _, dir := range []string{grandParentDir, parentDir, repoPath} {
-			if _, err := os.Stat(dir); err == nil {
-				exec.Command("chown", "-R", "root:root", dir).Run()
-			}
-		}
+	if isRoot {
+		exec.Command("chown", "-R", "root:root", repoPath).Run()
 	}
-	g.markSafetyApplied(repoPath)
+	entry.applied = true
 	return nil
 }
 
-var appliedSafety = make(map[string]bool)
-var safetyMutex sync.Mutex
+// chownOnce fixes the ownership of an existing directory the first time it is seen
+func (g *GitService) chownOnce(dir string) {
+	entry := safetyEntry(dir)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.applied {
+		return
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return
+	}
+	exec.Command("chown", "-R", "root:root", dir).Run()
+	entry.applied = true
+}
 
-func (g *GitService) hasAppliedSafety(repoPath string) bool {
-	safetyMutex.Lock()
-	defer safetyMutex.Unlock()
-	return appliedSafety[repoPath]
+// safetyState tracks whether the safety fix was applied to a path; mu is held while applying it
+type safetyState struct {
+	mu      sync.Mutex
+	applied bool
 }
 
-func (g *GitService) markSafetyApplied(repoPath string) {
+var appliedSafety = make(map[string]*safetyState)
+var safetyMutex sync.Mutex
+
+// safetyEntry returns the safety state of a path, creating it on first use
+func safetyEntry(path string) *safetyState {
 	safetyMutex.Lock()
 	defer safetyMutex.Unlock()
-	appliedSafety[repoPath] = true
+	entry, exists := appliedSafety[path]
+	if !exists {
+		entry = &safetyState{}
+		appliedSafety[path] = entry
+	}
+	return entry
 }
 
 // fixRepositoryOwnership fixes ownership issues in Docker containers
@@ -158,31 +201,31 @@ func (gs *GitService) fixRepositoryOwnership(repoPath string) error {
 	return nil
 }
 
+// gitEnvironment returns the SSH environment for git commands, isolated from the host's git config
+func (gs *GitService) gitEnvironment() []string {
+	return append(gs.sshHelper.GetGitEnvironment(), isolatedGitEnv...)
+}
+
 // executeGitCommand executes a git command with minimal overhead
-func (gs *GitService) executeGitCommand(args []string, workDir string, env []string) error {
-	gitEnv := gs.sshHelper.GetGitEnvironment()
+func (gs *GitService) executeGitCommand(ctx context.Context, args []string, workDir string, env []string) error {
+	gitEnv := gs.gitEnvironment()
 	if env != nil {
 		gitEnv = append(gitEnv, env...)
 	}
-	gitEnv = append(gitEnv, "GIT_CONFIG_GLOBAL=/dev/null")
 
-	cmd := exec.Command("git", args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = workDir
 	cmd.Env = gitEnv
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		if strings.Contains(string(output), "dubious ownership") {
-			gs.ensureRepositorySafety(workDir)
-			cmd = exec.Command("git", args...)
-			cmd.Dir = workDir
-			cmd.Env = gitEnv
-			output, err = cmd.CombinedOutput()
+		if ctx.Err() != nil {
+			return fmt.Errorf("git command interrupted: %w", ctx.Err())
 		}
-
-		if err != nil {
-			return fmt.Errorf("git command failed: %v, output: %s", err, output)
+		if missing := gitExecError(err); missing != nil {
+			return missing
 		}
+		return fmt.Errorf("git command failed: %v, output: %s", err, output)
 	}
 
 	return nil
@@ -190,56 +233,216 @@ func (gs *GitService) executeGitCommand(args []string, workDir string, env []str
 
 // SetupRepository clones or updates a repository
 func (gs *GitService) SetupRepository(repo models.Repository, branch, repoPath string) error {
+	return gs.SetupRepositoryWithContext(context.Background(), repo, branch, repoPath)
+}
+
+// SetupRepositoryWithContext clones or updates a repository, killing the git process when ctx is cancelled
+func (gs *GitService) SetupRepositoryWithContext(ctx context.Context, repo models.Repository, branch, repoPath string) error {
+	logger := loggerFrom(ctx, gs.logger)
 	gs.ensureRepositorySafety(repoPath)
 
 	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
-		gs.logger.Git("Cloning %s:%s", repo.Name, branch)
-		return gs.cloneRepository(repo, branch, repoPath)
+		if repo.Worktrees {
+			logger.Git("Adding worktree for %s:%s", repo.Name, branch)
+			return gs.addWorktree(ctx, repo, branch, repoPath)
+		}
+		logger.Git("Cloning %s:%s", repo.Name, branch)
+		return gs.cloneRepository(ctx, repo, branch, repoPath)
 	}
 
-	gs.logger.Git("Updating %s:%s", repo.Name, branch)
-	return gs.updateRepository(repo, branch, repoPath)
+	logger.Git("Updating %s:%s", repo.Name, branch)
+	return gs.updateRepository(ctx, repo, branch, repoPath)
 }
 
 // cloneRepository clones a new repository with safety handling
-func (gs *GitService) cloneRepository(repo models.Repository, branch, repoPath string) error {
+func (gs *GitService) cloneRepository(ctx context.Context, repo models.Repository, branch, repoPath string) error {
+	logger := loggerFrom(ctx, gs.logger)
 	parentDir := filepath.Dir(repoPath)
 	if err := os.MkdirAll(parentDir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
 	gs.ensureRepositorySafety(parentDir)
-	gs.logger.Git("Cloning repository %s:%s to %s", repo.Name, branch, repoPath)
-	cmd := exec.Command("git", "clone", "-b", branch, "--depth", "1", repo.GitURL, repoPath)
-	cmd.Env = gs.sshHelper.GetGitEnvironment()
+	logger.Git("Cloning repository %s:%s to %s", repo.Name, branch, repoPath)
+	args := []string{"clone", "-b", branch}
+	if depth := cloneDepth(repo); depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	cmd := exec.CommandContext(ctx, "git", append(args, repo.GitURL, repoPath)...)
+	cmd.Env = gs.gitEnvironment()
 	output, err := cmd.CombinedOutput()
 	if err != nil {
+		if ctx.Err() != nil {
+			// an interrupted clone leaves an unusable checkout behind
+			os.RemoveAll(repoPath)
+			return fmt.Errorf("git clone interrupted: %w", ctx.Err())
+		}
+		if missing := gitExecError(err); missing != nil {
+			return missing
+		}
+		if strings.Contains(string(output), "Remote branch") && strings.Contains(string(output), "not found") {
+			return fmt.Errorf("%w: branch '%s' does not exist on %s", ErrRemoteBranchNotFound, branch, repo.GitURL)
+		}
 		return fmt.Errorf("git clone failed: %v, output: %s", err, output)
 	}
 
 	gs.ensureRepositorySafety(repoPath)
 
-	gs.logger.Success("Cloned %s:%s successfully", repo.Name, branch)
+	logger.Success("Cloned %s:%s successfully", repo.Name, branch)
 	return nil
 }
 
+// cloneDepth returns the clone and fetch depth of a repository, 0 meaning the full history
+func cloneDepth(repo models.Repository) int {
+	if repo.CloneDepth == nil {
+		return 1
+	}
+	return *repo.CloneDepth
+}
+
 // updateRepository updates an existing repository efficiently
-func (gs *GitService) updateRepository(repo models.Repository, branch, repoPath string) error {
-	gitEnv := gs.sshHelper.GetGitEnvironment()
+func (gs *GitService) updateRepository(ctx context.Context, repo models.Repository, branch, repoPath string) error {
+	logger := loggerFrom(ctx, gs.logger)
+	gitEnv := gs.gitEnvironment()
 
-	if err := gs.executeGitCommand([]string{"fetch", "origin", branch}, repoPath, gitEnv); err != nil {
-		return fmt.Errorf("fetch failed: %v", err)
+	gitDir := filepath.Join(repoPath, ".git")
+	if commonDir := worktreeCommonDir(repoPath); commonDir != "" {
+		// worktrees of a shared clone fetch into the same object store, one at a time
+		lock := sharedCloneLock(commonDir)
+		lock.Lock()
+		defer lock.Unlock()
+		gitDir = commonDir
+	}
+	if err := gs.fetchBranch(ctx, repo, branch, repoPath, gitDir); err != nil {
+		return err
+	}
+
+	if current := gs.currentBranch(ctx, repoPath); current != branch {
+		logger.Git("Branch %s is not checked out in %s (found %q), checking it out from origin", branch, repoPath, current)
+		if err := gs.executeGitCommand(ctx, []string{"checkout", "-f", "-B", branch, "origin/" + branch}, repoPath, gitEnv); err != nil {
+			return fmt.Errorf("checkout failed: %v", err)
+		}
 	}
 
 	resetArgs := []string{"reset", "--hard", fmt.Sprintf("origin/%s", branch)}
-	if err := gs.executeGitCommand(resetArgs, repoPath, gitEnv); err != nil {
+	if err := gs.executeGitCommand(ctx, resetArgs, repoPath, gitEnv); err != nil {
 		return fmt.Errorf("reset failed: %v", err)
 	}
 
-	gs.executeGitCommand([]string{"clean", "-fd"}, repoPath, gitEnv) // Best effort cleanup
+	gs.executeGitCommand(ctx, []string{"clean", "-fd"}, repoPath, gitEnv) // Best effort cleanup
 
-	gs.logger.Success("Updated %s:%s successfully", repo.Name, branch)
+	logger.Success("Updated %s:%s successfully", repo.Name, branch)
+	return nil
+}
+
+// fetchBranch fetches branch into origin/<branch> of the repository at repoPath, whose git directory is gitDir
+func (gs *GitService) fetchBranch(ctx context.Context, repo models.Repository, branch, repoPath, gitDir string) error {
+	fetchArgs := []string{"fetch"}
+	if depth := cloneDepth(repo); depth > 0 {
+		fetchArgs = append(fetchArgs, "--depth", strconv.Itoa(depth))
+	} else if _, err := os.Stat(filepath.Join(gitDir, "shallow")); err == nil {
+		// a full clone was requested for a checkout cloned shallow earlier
+		fetchArgs = append(fetchArgs, "--unshallow")
+	}
+	// the explicit refspec creates origin/<branch> even when the clone only tracks another branch,
+	// e.g. for a branch pushed for the first time after the checkout was cloned single-branch
+	refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)
+	if err := gs.executeGitCommand(ctx, append(fetchArgs, "origin", refspec), repoPath, nil); err != nil {
+		if errors.Is(err, ErrGitNotFound) {
+			return err
+		}
+		if strings.Contains(err.Error(), "couldn't find remote ref") {
+			return fmt.Errorf("%w: branch '%s' does not exist on %s", ErrRemoteBranchNotFound, branch, repo.GitURL)
+		}
+		return fmt.Errorf("fetch failed: %v", err)
+	}
 	return nil
 }
+
+// currentBranch returns the branch checked out in repoPath, empty when HEAD is detached or unreadable
+func (gs *GitService) currentBranch(ctx context.Context, repoPath string) string {
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "branch", "--show-current")
+	cmd.Env = gs.gitEnvironment()
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// GetRemoteDefaultBranch asks the remote which branch its HEAD points to
+func (gs *GitService) GetRemoteDefaultBranch(gitURL string) (string, error) {
+	cmd := exec.Command("git", "ls-remote", "--symref", gitURL, "HEAD")
+	cmd.Env = gs.gitEnvironment()
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if missing := gitExecError(err); missing != nil {
+			return "", missing
+		}
+		return "", fmt.Errorf("git ls-remote failed: %v, output: %s", err, output)
+	}
+
+	// the symref line looks like "ref: refs/heads/main\tHEAD"
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "ref: ") {
+			fields := strings.Fields(strings.TrimPrefix(line, "ref: "))
+			if len(fields) > 0 {
+				return strings.TrimPrefix(fields[0], "refs/heads/"), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("remote %s did not report a default branch", gitURL)
+}
+
+// ListRemoteBranches lists the branches of a remote with git ls-remote, without cloning, and the branch its HEAD points to
+func (gs *GitService) ListRemoteBranches(gitURL string) ([]string, string, error) {
+	cmd := exec.Command("git", "ls-remote", "--symref", gitURL, "HEAD", "refs/heads/*")
+	cmd.Env = gs.gitEnvironment()
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if missing := gitExecError(err); missing != nil {
+			return nil, "", missing
+		}
+		if gs.RequiresSSH(gitURL) && isSSHAuthFailure(string(output)) {
+			return nil, "", fmt.Errorf("%w for %s: %s", ErrSSHAuthFailed, gitURL, strings.TrimSpace(string(output)))
+		}
+		return nil, "", fmt.Errorf("git ls-remote failed: %v, output: %s", err, output)
+	}
+
+	var branches []string
+	defaultBranch := ""
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "ref: ") {
+			fields := strings.Fields(strings.TrimPrefix(line, "ref: "))
+			if len(fields) > 0 {
+				defaultBranch = strings.TrimPrefix(fields[0], "refs/heads/")
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 2 && strings.HasPrefix(fields[1], "refs/heads/") {
+			branches = append(branches, strings.TrimPrefix(fields[1], "refs/heads/"))
+		}
+	}
+	sort.Strings(branches)
+	return branches, defaultBranch, nil
+}
+
+// isSSHAuthFailure reports whether git output shows that the SSH connection or key was rejected
+func isSSHAuthFailure(output string) bool {
+	for _, marker := range []string{"Permission denied (publickey", "Host key verification failed", "Could not read from remote repository"} {
+		if strings.Contains(output, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresSSH reports whether a git URL needs SSH authentication; http(s) URLs do not
+func (gs *GitService) RequiresSSH(gitURL string) bool {
+	lower := strings.ToLower(strings.TrimSpace(gitURL))
+	return !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "http://")
+}
+
 func (gs *GitService) IsSSHAvailable() bool {
 	return gs.sshHelper.IsReady()
 }
@@ -248,26 +451,36 @@ func (gs *GitService) TestSSHConnection() error {
 	return gs.sshHelper.TestGitHubConnection()
 }
 
+// SetSSHHosts applies the per-host SSH options of the ssh_hosts setting
+func (gs *GitService) SetSSHHosts(hosts []models.SSHHost) error {
+	return gs.sshHelper.SetHosts(hosts)
+}
+
+// TestSSHHost tests the SSH connection to a Git host or ssh_hosts alias
+func (gs *GitService) TestSSHHost(host string) error {
+	return gs.sshHelper.TestHostConnection(host)
+}
+
 // GetRepositoryInfo returns basic repository information with safety handling
 func (gs *GitService) GetRepositoryInfo(repoPath string) (map[string]string, error) {
 	gs.ensureRepositorySafety(repoPath)
 	info := make(map[string]string)
-	gitEnv := gs.sshHelper.GetGitEnvironment()
-	if err := gs.executeGitCommand([]string{"rev-parse", "HEAD"}, repoPath, gitEnv); err == nil {
+	gitEnv := gs.gitEnvironment()
+	if err := gs.executeGitCommand(context.Background(), []string{"rev-parse", "HEAD"}, repoPath, gitEnv); err == nil {
 		cmd := exec.Command("git", "-C", repoPath, "rev-parse", "HEAD")
 		cmd.Env = gitEnv
 		if output, err := cmd.Output(); err == nil {
 			info["commit_hash"] = strings.TrimSpace(string(output))
 		}
 	}
-	if err := gs.executeGitCommand([]string{"branch", "--show-current"}, repoPath, gitEnv); err == nil {
+	if err := gs.executeGitCommand(context.Background(), []string{"branch", "--show-current"}, repoPath, gitEnv); err == nil {
 		cmd := exec.Command("git", "-C", repoPath, "branch", "--show-current")
 		cmd.Env = gitEnv
 		if output, err := cmd.Output(); err == nil {
 			info["current_branch"] = strings.TrimSpace(string(output))
 		}
 	}
-	if err := gs.executeGitCommand([]string{"remote", "get-url", "origin"}, repoPath, gitEnv); err == nil {
+	if err := gs.executeGitCommand(context.Background(), []string{"remote", "get-url", "origin"}, repoPath, gitEnv); err == nil {
 		cmd := exec.Command("git", "-C", repoPath, "remote", "get-url", "origin")
 		cmd.Env = gitEnv
 		if output, err := cmd.Output(); err == nil {
@@ -286,7 +499,7 @@ func (g *GitService) ValidateRepository(repoPath string) error {
 	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
 		return fmt.Errorf("not a valid Git repository: %s", repoPath)
 	}
-	return g.executeGitCommand([]string{"status", "--porcelain"}, repoPath, nil)
+	return g.executeGitCommand(context.Background(), []string{"status", "--porcelain"}, repoPath, nil)
 }
 
 // CleanupRepository removes a repository directory safely
@@ -296,6 +509,7 @@ func (g *GitService) CleanupRepository(repoPath string) error {
 	}
 
 	g.logger.Git("Cleaning up repository: %s", repoPath)
+	commonDir := worktreeCommonDir(repoPath)
 
 	// Fix ownership before removal (important in Docker)
 	if os.Getuid() == 0 {
@@ -305,6 +519,9 @@ func (g *GitService) CleanupRepository(repoPath string) error {
 	if err := os.RemoveAll(repoPath); err != nil {
 		return fmt.Errorf("failed to remove repository: %v", err)
 	}
+	if commonDir != "" {
+		g.pruneWorktrees(context.Background(), commonDir)
+	}
 
 	g.logger.Success("Repository cleaned up: %s", repoPath)
 	return nil
@@ -0,0 +1,271 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentSetupRepository clones and then updates several branches of one repository at once, while the
+// safety setup of the shared parent directories and of every checkout is requested concurrently as well
+func TestConcurrentSetupRepository(t *testing.T) {
+	branches := []string{"main", "dev", "stage", "feature/a", "feature/b"}
+	remote := newGitRemote(t, branches[1:]...)
+	gs := NewGitService(newTestLogger(t))
+	repo := testRepository("api", remote.URL, branches...)
+	workDir := filepath.Join(t.TempDir(), "work")
+
+	for round, action := range []string{"clone", "update"} {
+		var wg sync.WaitGroup
+		for _, branch := range branches {
+			repoPath := filepath.Join(workDir, repo.Name, branch)
+			wg.Add(1)
+			go func(branch string) {
+				defer wg.Done()
+				if err := gs.SetupRepository(repo, branch, repoPath); err != nil {
+					t.Errorf("%s %s: %v", action, branch, err)
+				}
+			}(branch)
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					gs.ensureRepositorySafety(repoPath)
+				}()
+			}
+		}
+		wg.Wait()
+
+		for _, branch := range branches {
+			repoPath := filepath.Join(workDir, repo.Name, branch)
+			if got, want := runGit(t, repoPath, "rev-parse", "HEAD"), remote.head(t, branch)+"\n"; got != want {
+				t.Errorf("%s %s: checkout at %s, remote at %s", action, branch, got, want)
+			}
+			if !safetyEntry(repoPath).applied {
+				t.Errorf("%s %s: safety setup not recorded for the checkout", action, branch)
+			}
+		}
+		if round == 0 {
+			for _, branch := range branches {
+				remote.push(t, branch, "update "+branch)
+			}
+		}
+	}
+}
+
+// TestGitIgnoresGlobalConfig points the remote elsewhere with an insteadOf rewrite in the global git config;
+// clones, fetches and remote queries of GitService must still reach the configured URL
+func TestGitIgnoresGlobalConfig(t *testing.T) {
+	remote := newGitRemote(t, "dev")
+	gs := NewGitService(newTestLogger(t))
+	repo := testRepository("api", remote.URL, "main", "dev")
+
+	// nothing listens on port 1, a rewritten URL fails to connect
+	writeFile(t, filepath.Join(os.Getenv("HOME"), ".gitconfig"),
+		"[url \"http://127.0.0.1:1/\"]\n\tinsteadOf = "+remote.URL+"\n")
+	hostGit := exec.Command("git", "ls-remote", remote.URL)
+	if output, err := hostGit.CombinedOutput(); err == nil {
+		t.Fatalf("the insteadOf rewrite is not in effect for plain git: %s", output)
+	}
+
+	repoPath := filepath.Join(t.TempDir(), "api", "dev")
+	if err := gs.SetupRepository(repo, "dev", repoPath); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	if got := strings.TrimSpace(runGit(t, repoPath, "remote", "get-url", "origin")); got != remote.URL {
+		t.Errorf("origin is %s, want %s", got, remote.URL)
+	}
+
+	head := remote.push(t, "dev", "second commit")
+	if err := gs.SetupRepository(repo, "dev", repoPath); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got := strings.TrimSpace(runGit(t, repoPath, "rev-parse", "HEAD")); got != head {
+		t.Errorf("checkout at %s after update, remote at %s", got, head)
+	}
+
+	branches, _, err := gs.ListRemoteBranches(remote.URL)
+	if err != nil {
+		t.Fatalf("ls-remote: %v", err)
+	}
+	if strings.Join(branches, ",") != "dev,main" {
+		t.Errorf("remote branches %v, want [dev main]", branches)
+	}
+}
+
+// TestMissingGitBinary runs GitService with a PATH that has no git on it
+func TestMissingGitBinary(t *testing.T) {
+	gs := NewGitService(newTestLogger(t))
+	repo := testRepository("api", "https://git.example.com/api.git", "main")
+	existing := t.TempDir()
+	t.Setenv("PATH", t.TempDir())
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"initialize", gs.Initialize},
+		{"git command", func() error {
+			return gs.executeGitCommand(context.Background(), []string{"status"}, existing, nil)
+		}},
+		{"clone", func() error {
+			return gs.SetupRepository(repo, "main", filepath.Join(t.TempDir(), "api", "main"))
+		}},
+		{"update", func() error {
+			return gs.SetupRepository(repo, "main", existing)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if !errors.Is(err, ErrGitNotFound) {
+				t.Fatalf("error %v, want ErrGitNotFound", err)
+			}
+			if !strings.Contains(err.Error(), gitInstallHint) {
+				t.Errorf("error %q does not tell how to install git", err)
+			}
+		})
+	}
+}
+
+// TestUpdateNewBranch updates a single-branch clone of main for branches pushed after the clone, as the
+// deploy of a push creating a branch does when the checkout of another branch is reused
+func TestUpdateNewBranch(t *testing.T) {
+	remote := newGitRemote(t)
+	gs := NewGitService(newTestLogger(t))
+	repo := testRepository("api", remote.URL, "main")
+
+	tests := []struct {
+		name    string
+		branch  string
+		push    bool
+		wantErr error
+	}{
+		{"branch created after the clone", "feature/login", true, nil},
+		{"follow-up commit on the new branch", "feature/login", true, nil},
+		{"branch missing on the remote", "feature/gone", false, ErrRemoteBranchNotFound},
+	}
+	repoPath := filepath.Join(t.TempDir(), "api", "main")
+	runGit(t, t.TempDir(), "clone", "-q", "--single-branch", "-b", "main", remote.URL, repoPath)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var head string
+			if tt.push {
+				head = remote.push(t, tt.branch, tt.name)
+			}
+			err := gs.updateRepository(context.Background(), repo, tt.branch, repoPath)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSpace(runGit(t, repoPath, "branch", "--show-current")); got != tt.branch {
+				t.Errorf("checked out %q, want %q", got, tt.branch)
+			}
+			if got := strings.TrimSpace(runGit(t, repoPath, "rev-parse", "HEAD")); got != head {
+				t.Errorf("checkout at %s, remote at %s", got, head)
+			}
+		})
+	}
+}
+
+// TestWorktrees checks two branches out as worktrees of one shared clone, sets a branch up again after its
+// directory was removed and cleans a worktree up without touching the other branch
+func TestWorktrees(t *testing.T) {
+	remote := newGitRemote(t, "dev")
+	gs := NewGitService(newTestLogger(t))
+	repo := testRepository("api", remote.URL, "main", "dev")
+	repo.Worktrees = true
+	repoDir := filepath.Join(t.TempDir(), "api")
+	mainPath, devPath := filepath.Join(repoDir, "main"), filepath.Join(repoDir, "dev")
+	sharedPath := filepath.Join(repoDir, sharedCloneName)
+
+	worktrees := func() string {
+		t.Helper()
+		return runGit(t, sharedPath, "worktree", "list", "--porcelain")
+	}
+	checkHead := func(repoPath, branch string) {
+		t.Helper()
+		if got := strings.TrimSpace(runGit(t, repoPath, "branch", "--show-current")); got != branch {
+			t.Errorf("%s: checked out %q, want %q", repoPath, got, branch)
+		}
+		if got, want := strings.TrimSpace(runGit(t, repoPath, "rev-parse", "HEAD")), remote.head(t, branch); got != want {
+			t.Errorf("%s: checkout at %s, remote at %s", repoPath, got, want)
+		}
+	}
+
+	for _, branch := range []string{"main", "dev"} {
+		if err := gs.SetupRepository(repo, branch, filepath.Join(repoDir, branch)); err != nil {
+			t.Fatalf("setup %s: %v", branch, err)
+		}
+	}
+	if got := strings.TrimSpace(runGit(t, sharedPath, "rev-parse", "--is-bare-repository")); got != "true" {
+		t.Fatalf("shared clone is not bare: %s", got)
+	}
+	for _, repoPath := range []string{mainPath, devPath} {
+		if got := worktreeCommonDir(repoPath); got != sharedPath {
+			t.Errorf("%s belongs to %q, want the shared clone %s", repoPath, got, sharedPath)
+		}
+		if !strings.Contains(worktrees(), "worktree "+repoPath+"\n") {
+			t.Errorf("%s is not a worktree of the shared clone:\n%s", repoPath, worktrees())
+		}
+	}
+	checkHead(mainPath, "main")
+	checkHead(devPath, "dev")
+
+	// an update fetches through the shared clone
+	remote.push(t, "dev", "second commit")
+	if err := gs.SetupRepository(repo, "dev", devPath); err != nil {
+		t.Fatalf("update dev: %v", err)
+	}
+	checkHead(devPath, "dev")
+
+	// the worktree of a removed directory is still registered and must not block the branch
+	if err := os.RemoveAll(devPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := gs.SetupRepository(repo, "dev", devPath); err != nil {
+		t.Fatalf("setup dev after removal: %v", err)
+	}
+	checkHead(devPath, "dev")
+
+	if err := gs.CleanupRepository(devPath); err != nil {
+		t.Fatalf("cleanup dev: %v", err)
+	}
+	if _, err := os.Stat(devPath); !os.IsNotExist(err) {
+		t.Errorf("dev checkout still exists: %v", err)
+	}
+	if list := worktrees(); strings.Contains(list, devPath) {
+		t.Errorf("cleanup left the dev worktree registered:\n%s", list)
+	}
+	checkHead(mainPath, "main")
+	runGit(t, mainPath, "status", "--porcelain")
+}
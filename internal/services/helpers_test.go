@@ -0,0 +1,252 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"uruflow.com/internal/models"
+	"uruflow.com/internal/utils"
+)
+
+// newTestLogger returns a logger writing its log file into a temporary directory
+func newTestLogger(t *testing.T) *utils.Logger {
+	t.Helper()
+	t.Setenv("URUFLOW_LOG_DIR", t.TempDir())
+	logger := utils.NewLogger("[TEST] ")
+	t.Cleanup(func() { logger.Close() })
+	return logger
+}
+
+// runGit runs a git command in dir and fails the test on error
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+	return string(output)
+}
+
+// gitRemote is a repository served over dumb HTTP, so clones take the same path as a real http git_url
+type gitRemote struct {
+	URL  string
+	work string
+	bare string
+}
+
+// newGitRemote creates a remote with a compose file on main and every extra branch
+func newGitRemote(t *testing.T, branches ...string) *gitRemote {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// GitService must leave the global git config alone, a temporary HOME shows when it writes safe.directory
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Cleanup(func() {
+		if data, err := os.ReadFile(filepath.Join(home, ".gitconfig")); err == nil && strings.Contains(string(data), "[safe]") {
+			t.Errorf("safe.directory was written to the global git config:\n%s", data)
+		}
+	})
+	root := t.TempDir()
+	remote := &gitRemote{work: filepath.Join(root, "work"), bare: filepath.Join(root, "remote.git")}
+	if err := os.MkdirAll(remote.work, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, remote.work, "init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(remote.work, "docker-compose.yml"), "services:\n  web:\n    image: nginx\n")
+	runGit(t, remote.work, "add", ".")
+	runGit(t, remote.work, "commit", "-qm", "initial")
+	for _, branch := range branches {
+		runGit(t, remote.work, "branch", branch)
+	}
+	runGit(t, root, "clone", "-q", "--bare", remote.work, remote.bare)
+	runGit(t, remote.bare, "update-server-info")
+
+	server := httptest.NewServer(http.FileServer(http.Dir(remote.bare)))
+	t.Cleanup(server.Close)
+	remote.URL = server.URL + "/"
+	return remote
+}
+
+// push commits a change on branch, creating the branch from main when needed, and publishes it
+func (r *gitRemote) push(t *testing.T, branch, message string) string {
+	t.Helper()
+	runGit(t, r.work, "checkout", "-q", "-B", branch)
+	writeFile(t, filepath.Join(r.work, "CHANGES"), message+"\n")
+	runGit(t, r.work, "add", ".")
+	runGit(t, r.work, "commit", "-qm", message)
+	runGit(t, r.work, "push", "-q", "-f", r.bare, branch)
+	runGit(t, r.bare, "update-server-info")
+	runGit(t, r.work, "checkout", "-q", "main")
+	return r.head(t, branch)
+}
+
+// head returns the commit of branch on the remote
+func (r *gitRemote) head(t *testing.T, branch string) string {
+	t.Helper()
+	out := runGit(t, r.bare, "rev-parse", "refs/heads/"+branch)
+	return out[:len(out)-1]
+}
+
+// testRepository returns an enabled repository cloned in full, dumb HTTP cannot serve shallow clones
+func testRepository(name, gitURL string, branches ...string) models.Repository {
+	return models.Repository{
+		Name:       name,
+		GitURL:     gitURL,
+		Branches:   branches,
+		AutoDeploy: true,
+		Enabled:    true,
+		CloneDepth: new(int),
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeDocker is a DockerDeployer that records the builds instead of running docker; the builds on the
+// hosts in failHosts fail
+type fakeDocker struct {
+	buildDelay time.Duration
+	failHosts  map[string]bool
+	running    atomic.Int64
+	maxRunning atomic.Int64
+	builds     atomic.Int64
+	starts     atomic.Int64
+
+	mu      sync.Mutex
+	builtOn []string
+}
+
+func (f *fakeDocker) Deploy(repo models.Repository, branch, repoPath string) ([]string, error) {
+	return f.DeployWithContext(context.Background(), repo, branch, repoPath)
+}
+
+func (f *fakeDocker) DeployWithContext(ctx context.Context, repo models.Repository, branch, repoPath string) ([]string, error) {
+	return f.DeployServicesWithContext(ctx, repo, branch, repoPath, nil)
+}
+
+func (f *fakeDocker) DeployServicesWithContext(ctx context.Context, repo models.Repository, branch, repoPath string, only []string) ([]string, error) {
+	if err := f.BuildServicesWithContext(ctx, repo, branch, repoPath, only); err != nil {
+		return nil, err
+	}
+	return f.StartServicesWithContext(ctx, repo, branch, repoPath, only)
+}
+
+func (f *fakeDocker) BuildServicesWithContext(ctx context.Context, repo models.Repository, branch, repoPath string, only []string) error {
+	running := f.running.Add(1)
+	defer f.running.Add(-1)
+	for {
+		seen := f.maxRunning.Load()
+		if running <= seen || f.maxRunning.CompareAndSwap(seen, running) {
+			break
+		}
+	}
+	f.builds.Add(1)
+	select {
+	case <-time.After(f.buildDelay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *fakeDocker) StartServicesWithContext(ctx context.Context, repo models.Repository, branch, repoPath string, only []string) ([]string, error) {
+	f.starts.Add(1)
+	return []string{"web"}, nil
+}
+
+func (f *fakeDocker) Stop(repo models.Repository, branch, repoPath string) error { return nil }
+
+func (f *fakeDocker) LintCompose(ctx context.Context, repo models.Repository, branch, repoPath string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeDocker) DockerRootDir() (string, error) { return os.TempDir(), nil }
+
+func (f *fakeDocker) GetRunningProjectContainers(repo models.Repository, branch string) ([]string, error) {
+	return []string{ProjectName(repo, branch) + "-web-1"}, nil
+}
+
+func (f *fakeDocker) GetFailedProjectContainers(repo models.Repository, branch string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeDocker) Cleanup() error { return nil }
+
+func (f *fakeDocker) ForHost(host models.DeployHost) DockerDeployer {
+	return &fakeHostDocker{fakeDocker: f, host: host.Name}
+}
+
+// hostBuilds returns the hosts that images were built on, in order
+func (f *fakeDocker) hostBuilds() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.builtOn...)
+}
+
+// fakeHostDocker is the fakeDocker of one host
+type fakeHostDocker struct {
+	*fakeDocker
+	host string
+}
+
+func (h *fakeHostDocker) BuildServicesWithContext(ctx context.Context, repo models.Repository, branch, repoPath string, only []string) error {
+	if h.failHosts[h.host] {
+		return fmt.Errorf("build failed on %s", h.host)
+	}
+	h.mu.Lock()
+	h.builtOn = append(h.builtOn, h.host)
+	h.mu.Unlock()
+	return h.fakeDocker.BuildServicesWithContext(ctx, repo, branch, repoPath, only)
+}
+
+func (f *fakeDocker) RestoreImages(ctx context.Context, repo models.Repository, branch, repoPath, commit string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeDocker) RetainImages(ctx context.Context, repo models.Repository, branch, repoPath, commit string, keep int) error {
+	return nil
+}
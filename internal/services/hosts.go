@@ -0,0 +1,216 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"uruflow.com/internal/models"
+)
+
+const (
+	// HostsAllOrNothing fails a deploy on the first host that fails, the remaining hosts are not deployed
+	HostsAllOrNothing = "all_or_nothing"
+	// HostsBestEffort deploys every host and fails only when no host succeeded
+	HostsBestEffort = "best_effort"
+)
+
+// HostResult is the outcome of a deploy on one of the hosts of a repository
+type HostResult struct {
+	Host     string   `json:"host"`
+	Status   string   `json:"status"`
+	Services []string `json:"services,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// ValidateHosts checks the hosts list and hosts_strategy of a repository
+func ValidateHosts(repo models.Repository) error {
+	switch repo.HostsStrategy {
+	case "", HostsAllOrNothing, HostsBestEffort:
+	default:
+		return fmt.Errorf("invalid hosts_strategy %q for repository %s: must be %s or %s", repo.HostsStrategy, repo.Name, HostsAllOrNothing, HostsBestEffort)
+	}
+
+	names := make(map[string]bool)
+	for _, host := range repo.Hosts {
+		if host.Name == "" {
+			return fmt.Errorf("hosts entry without name in repository %s", repo.Name)
+		}
+		if names[host.Name] {
+			return fmt.Errorf("duplicate host %q in repository %s", host.Name, repo.Name)
+		}
+		names[host.Name] = true
+
+		if (host.DockerHost == "") == (host.Context == "") {
+			return fmt.Errorf("host %s of repository %s needs exactly one of docker_host or context", host.Name, repo.Name)
+		}
+		if host.DockerHost != "" && !strings.Contains(host.DockerHost, "://") {
+			return fmt.Errorf("invalid docker_host %q for host %s of repository %s: expected e.g. ssh://user@host or tcp://host:2376", host.DockerHost, host.Name, repo.Name)
+		}
+		if (host.IdentityFile != "" || len(host.SSHOptions) > 0) && !strings.HasPrefix(host.DockerHost, "ssh://") {
+			return fmt.Errorf("identity_file and ssh_options of host %s of repository %s need an ssh:// docker_host", host.Name, repo.Name)
+		}
+		if strings.ContainsAny(host.IdentityFile, "\r\n") {
+			return fmt.Errorf("invalid identity_file for host %s of repository %s: must be a single line", host.Name, repo.Name)
+		}
+		for _, option := range host.SSHOptions {
+			if key, value, ok := strings.Cut(option, "="); !ok || key == "" || value == "" || strings.ContainsAny(option, " \t\r\n") {
+				return fmt.Errorf("invalid ssh_options entry %q for host %s of repository %s: expected Key=Value", option, host.Name, repo.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// hostsStrategy returns the hosts_strategy of a repository, all_or_nothing when unset
+func hostsStrategy(repo models.Repository) string {
+	if repo.HostsStrategy == "" {
+		return HostsAllOrNothing
+	}
+	return repo.HostsStrategy
+}
+
+// hostEnv returns the environment selecting the docker daemon of a host
+func hostEnv(host models.DeployHost) []string {
+	// the other variable is cleared so a value inherited from the uruflow process cannot redirect the command
+	if host.DockerHost != "" {
+		return []string{"DOCKER_HOST=" + host.DockerHost, "DOCKER_CONTEXT="}
+	}
+	return []string{"DOCKER_CONTEXT=" + host.Context, "DOCKER_HOST="}
+}
+
+// ForHost returns a docker service whose docker and compose commands all run against the daemon of a host
+func (d *DockerService) ForHost(host models.DeployHost) DockerDeployer {
+	return d.forHost(host)
+}
+
+// forHost is ForHost returning the concrete service
+func (d *DockerService) forHost(host models.DeployHost) *DockerService {
+	env := hostEnv(host)
+	key := strings.Join(append(append(hostEnv(host), host.IdentityFile), host.SSHOptions...), "\x00")
+
+	d.hostsMu.Lock()
+	defer d.hostsMu.Unlock()
+	if hostService, ok := d.hostServices[key]; ok {
+		return hostService
+	}
+	hostService := &DockerService{
+		config:         d.currentConfig(),
+		logger:         d.logger,
+		composeCommand: d.composeCommand,
+		registryLogins: make(map[string]string),
+		hostServices:   make(map[string]*DockerService),
+		hostEnv:        env,
+	}
+	if host.IdentityFile != "" || len(host.SSHOptions) > 0 {
+		sshDir, err := writeSSHWrapper(host)
+		if err != nil {
+			d.logger.Error("Failed to apply the SSH settings of host %s: %v", host.Name, err)
+		} else {
+			hostService.hostEnv = append(hostService.hostEnv, "PATH="+sshDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		}
+	}
+	d.hostServices[key] = hostService
+	return hostService
+}
+
+// writeSSHWrapper writes an ssh executable passing the SSH settings of a host to the real ssh client and
+// returns its directory; the docker CLI runs ssh from PATH for ssh:// hosts and takes no ssh flags itself
+func writeSSHWrapper(host models.DeployHost) (string, error) {
+	sshPath, err := exec.LookPath("ssh")
+	if err != nil {
+		return "", fmt.Errorf("ssh client not found: %v", err)
+	}
+	if sshPath, err = filepath.Abs(sshPath); err != nil {
+		return "", err
+	}
+
+	args := []string{shellQuote(sshPath)}
+	if host.IdentityFile != "" {
+		identityFile, err := filepath.Abs(host.IdentityFile)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, "-i", shellQuote(identityFile), "-o", "IdentitiesOnly=yes")
+	}
+	for _, option := range host.SSHOptions {
+		args = append(args, "-o", shellQuote(option))
+	}
+
+	dir, err := os.MkdirTemp("", "uruflow-docker-ssh-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create ssh wrapper: %v", err)
+	}
+	script := fmt.Sprintf("#!/bin/sh\nexec %s \"$@\"\n", strings.Join(args, " "))
+	if err := os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0700); err != nil {
+		return "", fmt.Errorf("failed to write ssh wrapper: %v", err)
+	}
+	return dir, nil
+}
+
+// shellQuote quotes a value as a single sh word
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// HostDaemon is one of the docker daemons a repository deploys to, Host is empty for the local daemon
+type HostDaemon struct {
+	Host   string
+	Docker *DockerService
+}
+
+// Daemons returns the docker daemons a repository deploys to: its hosts, or the local daemon without hosts
+func (d *DockerService) Daemons(repo models.Repository) []HostDaemon {
+	if len(repo.Hosts) == 0 {
+		return []HostDaemon{{Docker: d}}
+	}
+	daemons := make([]HostDaemon, 0, len(repo.Hosts))
+	for _, host := range repo.Hosts {
+		daemons = append(daemons, HostDaemon{Host: host.Name, Docker: d.forHost(host)})
+	}
+	return daemons
+}
+
+// command creates a docker or compose command targeting the daemon of the service
+func (d *DockerService) command(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	d.applyHostEnv(cmd)
+	return cmd
+}
+
+// commandContext is command for a command killed when ctx is done
+func (d *DockerService) commandContext(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	d.applyHostEnv(cmd)
+	return cmd
+}
+
+// applyHostEnv points a command at the host of the service, the local daemon keeps the inherited environment
+func (d *DockerService) applyHostEnv(cmd *exec.Cmd) {
+	if len(d.hostEnv) == 0 {
+		return
+	}
+	cmd.Env = append(os.Environ(), d.hostEnv...)
+}
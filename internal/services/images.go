@@ -0,0 +1,227 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"uruflow.com/internal/models"
+)
+
+// imageTagPrefix starts the tags UruFlow gives built images, followed by the branch and the commit
+const imageTagPrefix = "uruflow-"
+
+// commitTagLength is the number of commit hash characters in an image tag
+const commitTagLength = 12
+
+// branchHashLength is the number of branch name hash characters in an image tag
+const branchHashLength = 8
+
+// invalidTagChars matches the characters a branch name may contain but an image tag may not
+var invalidTagChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// commitImageTag returns the tag of the images built for a commit of a branch, e.g. uruflow-main-0d6e4079-0123456789ab
+func commitImageTag(branch, commit string) string {
+	return branchTagPrefix(branch) + commit[:min(len(commit), commitTagLength)]
+}
+
+// branchTagPrefix returns the part of the image tags shared by every commit of a branch. The hash of the
+// branch name keeps branches apart whose names only differ in characters a tag may not contain.
+func branchTagPrefix(branch string) string {
+	sum := sha256.Sum256([]byte(branch))
+	return imageTagPrefix + invalidTagChars.ReplaceAllString(branch, "-") + "-" + hex.EncodeToString(sum[:])[:branchHashLength] + "-"
+}
+
+// isCommitTagOf reports whether tag was given to an image built for a commit of the branch with prefix
+func isCommitTagOf(tag, prefix string) bool {
+	rest, ok := strings.CutPrefix(tag, prefix)
+	if !ok || len(rest) != commitTagLength {
+		return false
+	}
+	for _, r := range rest {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// builtImages returns the references of the images compose builds for a branch, with their tag
+func (d *DockerService) builtImages(ctx context.Context, repo models.Repository, branch, repoPath string) ([]string, error) {
+	units, err := ComposeUnits(repo, branch, repoPath)
+	if err != nil {
+		return nil, err
+	}
+	secretEnv, _, err := d.resolveSecrets(ctx, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	for _, unit := range units {
+		cmd := d.composeCmd(ctx, repo, unit.ComposeFile, unit.ProjectName, repoPath, secretEnv, "config", "--format", "json")
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("docker compose config failed for %s: %v", unit.ComposeFile, err)
+		}
+
+		var config struct {
+			Services map[string]struct {
+				Build json.RawMessage `json:"build"`
+				Image string          `json:"image"`
+			} `json:"services"`
+		}
+		if err := json.Unmarshal(output, &config); err != nil {
+			return nil, fmt.Errorf("could not parse compose config of %s: %v", unit.ComposeFile, err)
+		}
+		for name, service := range config.Services {
+			if len(service.Build) == 0 || string(service.Build) == "null" {
+				continue
+			}
+			image := service.Image
+			if image == "" {
+				// compose names built images after the project and the service
+				image = unit.ProjectName + "-" + name
+			}
+			if imageRepository(image) == image {
+				image += ":latest"
+			}
+			images = append(images, image)
+		}
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// imageRepository strips the tag and digest from an image reference
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		image = image[:colon]
+	}
+	return image
+}
+
+// imageExists reports whether a local image reference exists
+func (d *DockerService) imageExists(ctx context.Context, image string) bool {
+	return d.commandContext(ctx, "docker", "image", "inspect", "--format", "{{.Id}}", image).Run() == nil
+}
+
+// RestoreImages points the built images of a branch back to the images kept for a commit, so a deploy of a
+// commit built before (a rollback) starts without building. It reports false when any image is missing.
+func (d *DockerService) RestoreImages(ctx context.Context, repo models.Repository, branch, repoPath, commit string) (bool, error) {
+	images, err := d.builtImages(ctx, repo, branch, repoPath)
+	if err != nil || len(images) == 0 {
+		return false, err
+	}
+
+	tag := commitImageTag(branch, commit)
+	for _, image := range images {
+		if !d.imageExists(ctx, imageRepository(image)+":"+tag) {
+			return false, nil
+		}
+	}
+	for _, image := range images {
+		kept := imageRepository(image) + ":" + tag
+		if output, err := d.commandContext(ctx, "docker", "image", "tag", kept, image).CombinedOutput(); err != nil {
+			return false, fmt.Errorf("docker image tag %s failed: %v, output: %s", kept, err, strings.TrimSpace(string(output)))
+		}
+	}
+	return true, nil
+}
+
+// RetainImages tags the built images of a branch with the deployed commit and removes the commit tags
+// beyond the keep newest, so older images become dangling and are pruned
+func (d *DockerService) RetainImages(ctx context.Context, repo models.Repository, branch, repoPath, commit string, keep int) error {
+	logger := loggerFrom(ctx, d.logger)
+	images, err := d.builtImages(ctx, repo, branch, repoPath)
+	if err != nil {
+		return err
+	}
+
+	tag := commitImageTag(branch, commit)
+	prefix := branchTagPrefix(branch)
+	for _, ref := range images {
+		image := imageRepository(ref)
+		if output, err := d.commandContext(ctx, "docker", "image", "tag", ref, image+":"+tag).CombinedOutput(); err != nil {
+			logger.Warning("Failed to tag image %s with %s: %v, output: %s", ref, tag, err, strings.TrimSpace(string(output)))
+			continue
+		}
+
+		tags, err := d.commitTags(ctx, image, prefix)
+		if err != nil {
+			logger.Warning("Failed to list the kept images of %s: %v", image, err)
+			continue
+		}
+		kept := 1
+		for _, old := range tags {
+			if old == tag {
+				continue
+			}
+			if kept < keep {
+				kept++
+				continue
+			}
+			if output, err := d.commandContext(ctx, "docker", "image", "rm", image+":"+old).CombinedOutput(); err != nil {
+				logger.Warning("Failed to remove image %s:%s: %v, output: %s", image, old, err, strings.TrimSpace(string(output)))
+				continue
+			}
+			logger.Docker("Removed old image %s:%s (keep_images %d)", image, old, keep)
+		}
+	}
+	return nil
+}
+
+// commitTags lists the commit tags of an image for the branch with prefix, newest image first
+func (d *DockerService) commitTags(ctx context.Context, image, prefix string) ([]string, error) {
+	output, err := d.commandContext(ctx, "docker", "image", "ls", image, "--format", "{{.Tag}}\t{{.CreatedAt}}").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	type taggedImage struct {
+		tag     string
+		created time.Time
+	}
+	var tagged []taggedImage
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		tag, createdAt, _ := strings.Cut(line, "\t")
+		if !isCommitTagOf(tag, prefix) {
+			continue
+		}
+		created, _ := time.Parse("2006-01-02 15:04:05 -0700 MST", createdAt)
+		tagged = append(tagged, taggedImage{tag: tag, created: created})
+	}
+	sort.SliceStable(tagged, func(i, j int) bool { return tagged[i].created.After(tagged[j].created) })
+
+	tags := make([]string, len(tagged))
+	for i, t := range tagged {
+		tags[i] = t.tag
+	}
+	return tags, nil
+}
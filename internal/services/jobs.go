@@ -0,0 +1,159 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"uruflow.com/internal/models"
+)
+
+// maxJobHistory bounds the number of finished jobs kept for GetJob lookups
+const maxJobHistory = 100
+
+// JobStatus is the lifecycle state of a deployment job
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+	JobSkipped   JobStatus = "skipped"
+)
+
+// Job is a tracked deployment of a repository branch
+type Job struct {
+	ID         string                `json:"id"`
+	Repository string                `json:"repository"`
+	Branch     string                `json:"branch"`
+	Commit     string                `json:"commit,omitempty"`
+	Status     JobStatus             `json:"status"`
+	Error      string                `json:"error,omitempty"`
+	Trigger    *models.DeployTrigger `json:"trigger,omitempty"`
+	CreatedAt  time.Time             `json:"created_at"`
+	StartedAt  *time.Time            `json:"started_at,omitempty"`
+	FinishedAt *time.Time            `json:"finished_at,omitempty"`
+	// Hosts holds the per-host results of a repository deployed to hosts
+	Hosts []HostResult `json:"hosts,omitempty"`
+}
+
+// Enqueue starts a deployment in the background and returns its job ID right away; the job
+// is looked up with GetJob. A branch that is deploying or has an open circuit is refused.
+func (ds *DeploymentService) Enqueue(ctx context.Context, repo models.Repository, branch string, opts DeployOptions) (string, error) {
+	jobKey := fmt.Sprintf("%s:%s", repo.Name, branch)
+	if openUntil, open := ds.CircuitOpenUntil(repo.Name, branch); open {
+		return "", fmt.Errorf("%w for %s until %s", ErrCircuitOpen, jobKey, openUntil.Format(time.RFC3339))
+	}
+	ds.activeJobsMu.RLock()
+	_, active := ds.activeJobs[jobKey]
+	ds.activeJobsMu.RUnlock()
+	if active {
+		return "", fmt.Errorf("deployment already in progress for %s", jobKey)
+	}
+
+	opts.jobID = ds.createJob(repo.Name, branch, opts.Trigger)
+	go ds.DeployDirectWithOptions(ctx, repo, branch, opts)
+	return opts.jobID, nil
+}
+
+// GetJob returns a copy of the job with the given ID
+func (ds *DeploymentService) GetJob(id string) (Job, bool) {
+	ds.jobsMu.Lock()
+	defer ds.jobsMu.Unlock()
+	job, exists := ds.jobs[id]
+	if !exists {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// createJob records a queued job and returns its ID, dropping the oldest finished jobs past maxJobHistory
+func (ds *DeploymentService) createJob(repoName, branch string, trigger models.DeployTrigger) string {
+	job := &Job{
+		ID:         newJobID(),
+		Repository: repoName,
+		Branch:     branch,
+		Status:     JobQueued,
+		CreatedAt:  time.Now(),
+	}
+	if trigger.Source != "" {
+		job.Trigger = &trigger
+	}
+
+	ds.jobsMu.Lock()
+	defer ds.jobsMu.Unlock()
+	ds.jobs[job.ID] = job
+	ds.jobOrder = append(ds.jobOrder, job.ID)
+	for i := 0; len(ds.jobOrder) > maxJobHistory && i < len(ds.jobOrder); {
+		if old := ds.jobs[ds.jobOrder[i]]; old != nil && old.FinishedAt == nil {
+			i++
+			continue
+		}
+		delete(ds.jobs, ds.jobOrder[i])
+		ds.jobOrder = append(ds.jobOrder[:i], ds.jobOrder[i+1:]...)
+	}
+	return job.ID
+}
+
+// updateJob applies update to the job with the given ID, if it is still tracked
+func (ds *DeploymentService) updateJob(id string, update func(*Job)) {
+	ds.jobsMu.Lock()
+	defer ds.jobsMu.Unlock()
+	if job, exists := ds.jobs[id]; exists {
+		update(job)
+	}
+}
+
+// finishJob records the outcome of a job
+func (ds *DeploymentService) finishJob(id string, err error) {
+	now := time.Now()
+	ds.updateJob(id, func(job *Job) {
+		job.FinishedAt = &now
+		switch {
+		case err == nil:
+			job.Status = JobSucceeded
+		case errors.Is(err, ErrDeploySkipped):
+			job.Status = JobSkipped
+		case errors.Is(err, ErrDeploymentCancelled):
+			job.Status = JobCancelled
+			job.Error = err.Error()
+		default:
+			job.Status = JobFailed
+			job.Error = err.Error()
+		}
+	})
+}
+
+// newJobID returns a random job identifier
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("job-%d", time.Now().UnixNano())
+	}
+	return "job-" + hex.EncodeToString(b)
+}
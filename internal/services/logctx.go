@@ -0,0 +1,43 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"context"
+
+	"uruflow.com/internal/utils"
+)
+
+// loggerKey is the context key of the request-scoped logger of a deployment
+type loggerKey struct{}
+
+// withLogger returns a context carrying the logger used by the service log lines of a deployment
+func withLogger(ctx context.Context, logger *utils.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// loggerFrom returns the request-scoped logger carried by ctx, or fallback outside of a deployment
+func loggerFrom(ctx context.Context, fallback *utils.Logger) *utils.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*utils.Logger); ok {
+		return logger
+	}
+	return fallback
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BranchResult is the outcome of the last finished deployment of a repository branch
+type BranchResult struct {
+	Repository    string
+	Branch        string
+	Succeeded     bool
+	FinishedAt    time.Time
+	LastSucceeded time.Time
+}
+
+// recordResult remembers the outcome of a finished deployment for the per-branch gauges; cancelled
+// deployments are not recorded, they leave the previous result in place
+func (ds *DeploymentService) recordResult(repoName, branch string, deployErr error) {
+	key := fmt.Sprintf("%s:%s", repoName, branch)
+	now := time.Now()
+
+	ds.resultsMu.Lock()
+	defer ds.resultsMu.Unlock()
+
+	result, exists := ds.results[key]
+	if !exists {
+		result = &BranchResult{Repository: repoName, Branch: branch}
+		ds.results[key] = result
+	}
+	result.Succeeded = deployErr == nil
+	result.FinishedAt = now
+	if deployErr == nil {
+		result.LastSucceeded = now
+	}
+}
+
+// BranchResults returns the last deployment result of every branch deployed since startup, sorted by repository and branch
+func (ds *DeploymentService) BranchResults() []BranchResult {
+	ds.resultsMu.Lock()
+	results := make([]BranchResult, 0, len(ds.results))
+	for _, result := range ds.results {
+		results = append(results, *result)
+	}
+	ds.resultsMu.Unlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Repository != results[j].Repository {
+			return results[i].Repository < results[j].Repository
+		}
+		return results[i].Branch < results[j].Branch
+	})
+	return results
+}
+
+// WriteMetrics writes the deployment counters and per-branch gauges in the Prometheus text format
+func (ds *DeploymentService) WriteMetrics(w io.Writer) {
+	stats := ds.GetDeploymentStats()
+	counters := []struct {
+		name, help, stat string
+	}{
+		{"uruflow_deployments_total", "Deployments started since startup.", "total_jobs"},
+		{"uruflow_deployments_completed_total", "Deployments that succeeded since startup.", "completed_jobs"},
+		{"uruflow_deployments_failed_total", "Deployments that failed since startup.", "failed_jobs"},
+		{"uruflow_deployments_cancelled_total", "Deployments that were cancelled since startup.", "cancelled_jobs"},
+		{"uruflow_deployments_skipped_total", "Deployments skipped by skip_if_unchanged since startup.", "skipped_jobs"},
+		{"uruflow_deployments_reaped_total", "Deployments cleared by the job reaper since startup.", "reaped_jobs"},
+	}
+	for _, counter := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %v\n", counter.name, counter.help, counter.name, counter.name, stats[counter.stat])
+	}
+	fmt.Fprintf(w, "# HELP uruflow_active_deployments Deployments currently in progress.\n# TYPE uruflow_active_deployments gauge\n")
+	fmt.Fprintf(w, "uruflow_active_deployments %v\n", stats["active_jobs"])
+
+	results := ds.BranchResults()
+	now := time.Now()
+	fmt.Fprintf(w, "# HELP uruflow_last_deploy_success Whether the last finished deployment of a branch succeeded (1) or failed (0).\n")
+	fmt.Fprintf(w, "# TYPE uruflow_last_deploy_success gauge\n")
+	for _, result := range results {
+		success := 0
+		if result.Succeeded {
+			success = 1
+		}
+		fmt.Fprintf(w, "uruflow_last_deploy_success{%s} %d\n", branchLabels(result), success)
+	}
+	fmt.Fprintf(w, "# HELP uruflow_last_successful_deploy_age_seconds Seconds since the last successful deployment of a branch.\n")
+	fmt.Fprintf(w, "# TYPE uruflow_last_successful_deploy_age_seconds gauge\n")
+	for _, result := range results {
+		// a branch that never succeeded since startup has no age, alert on its absence instead
+		if result.LastSucceeded.IsZero() {
+			continue
+		}
+		fmt.Fprintf(w, "uruflow_last_successful_deploy_age_seconds{%s} %.0f\n", branchLabels(result), now.Sub(result.LastSucceeded).Seconds())
+	}
+}
+
+// branchLabels formats the repository and branch labels of a result
+func branchLabels(result BranchResult) string {
+	return fmt.Sprintf(`repository="%s",branch="%s"`, escapeLabelValue(result.Repository), escapeLabelValue(result.Branch))
+}
+
+// escapeLabelValue escapes a Prometheus label value
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -21,8 +21,17 @@
 package services
 
 import (
+	"bytes"
 	"encoding/json"
-	"os/exec"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"uruflow.com/internal/models"
 	"uruflow.com/internal/utils"
@@ -30,37 +39,223 @@ import (
 
 // NotificationService handles external notifications
 type NotificationService struct {
-	webhookURL string
-	logger     *utils.Logger
+	config   *models.Config
+	channels []webhookNotifier
+	mu       sync.RWMutex
+	client   *http.Client
+	events   *EventBus
+	logger   *utils.Logger
 }
 
-// NewNotificationService creates a new notification service
-func NewNotificationService(webhookURL string, logger *utils.Logger) *NotificationService {
-	return &NotificationService{
-		webhookURL: webhookURL,
-		logger:     logger,
+// webhookNotifier is a generic outbound webhook, a nil template sends the default JSON body
+type webhookNotifier struct {
+	url      string
+	method   string
+	headers  map[string]string
+	template *template.Template
+	retries  int
+}
+
+// defaultNotificationRetries is the number of retries of a failed notification request when retries is unset
+const defaultNotificationRetries = 3
+
+// NewNotificationService creates a notification service sending to notification_url and every notification channel
+func NewNotificationService(config *models.Config, logger *utils.Logger) *NotificationService {
+	n := &NotificationService{
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+	}
+	n.UpdateConfig(config)
+	return n
+}
+
+// UpdateConfig updates the configuration reference and rebuilds the notification channels
+func (n *NotificationService) UpdateConfig(config *models.Config) {
+	var channels []webhookNotifier
+	if config.Settings.NotificationURL != "" {
+		channels = append(channels, n.newChannel(models.NotificationChannel{
+			URL:      config.Settings.NotificationURL,
+			Template: config.Settings.NotificationTemplate,
+		}))
+	}
+	for _, channel := range config.Settings.NotificationChannels {
+		channels = append(channels, n.newChannel(channel))
+	}
+
+	n.mu.Lock()
+	n.config = config
+	n.channels = channels
+	n.mu.Unlock()
+}
+
+// channelsFor returns the channels to notify about a repository, none when its notify option is off
+func (n *NotificationService) channelsFor(repoName string) []webhookNotifier {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	for _, repo := range n.config.Repositories {
+		if repo.Name == repoName && repo.Notify != nil && !*repo.Notify {
+			return nil
+		}
+	}
+	return n.channels
+}
+
+// newChannel builds a channel, an invalid template falls back to the default body
+func (n *NotificationService) newChannel(channel models.NotificationChannel) webhookNotifier {
+	tmpl, err := ParseNotificationTemplate(channel.Template)
+	if err != nil {
+		n.logger.Warning("Invalid notification template for %s, using the default body: %v", redactURL(channel.URL), err)
+		tmpl = nil
+	}
+	method := strings.ToUpper(channel.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	retries := defaultNotificationRetries
+	if channel.Retries != nil {
+		retries = *channel.Retries
 	}
+	return webhookNotifier{
+		url:      channel.URL,
+		method:   method,
+		headers:  channel.Headers,
+		template: tmpl,
+		retries:  retries,
+	}
+}
+
+// SetEventBus publishes every sent event on the given bus as well
+func (n *NotificationService) SetEventBus(events *EventBus) {
+	n.events = events
 }
 
 // SendDeploymentStatus sends deployment status to external webhook
 func (n *NotificationService) SendDeploymentStatus(status models.DeploymentStatus) {
-	if n.webhookURL == "" {
+	channels := n.channelsFor(status.Repository)
+	if len(channels) == 0 {
 		return
 	}
-	go func() {
-		jsonData, err := json.Marshal(status)
-		if err != nil {
-			n.logger.Error("Failed to marshal deployment status: %v", err)
-			return
+	data := notificationData{
+		DeploymentStatus: status,
+		Event:            "deployment",
+		Timestamp:        time.Now(),
+	}
+	go n.send(channels, status, data, fmt.Sprintf("deployment %s", status.Status))
+}
+
+// SendInitFailure notifies that a repository branch failed to initialize
+func (n *NotificationService) SendInitFailure(repoName, branch string, initErr error) {
+	n.SendEvent(models.NotificationEvent{
+		Event:      "init_failed",
+		Repository: repoName,
+		Branch:     branch,
+		Error:      initErr.Error(),
+		Timestamp:  time.Now(),
+	})
+}
+
+// SendEvent sends a non-deployment event to external webhook
+func (n *NotificationService) SendEvent(event models.NotificationEvent) {
+	if n.events != nil {
+		n.events.Publish(event)
+	}
+	channels := n.channelsFor(event.Repository)
+	if len(channels) == 0 {
+		return
+	}
+	data := notificationData{
+		DeploymentStatus: models.DeploymentStatus{
+			Repository: event.Repository,
+			Branch:     event.Branch,
+			Status:     event.Event,
+			StartTime:  event.Timestamp,
+			Error:      event.Error,
+		},
+		Event:     event.Event,
+		Stage:     event.Stage,
+		Timestamp: event.Timestamp,
+	}
+	go n.send(channels, event, data, event.Event)
+}
+
+// send delivers a notification to the given channels, rendering their template or falling back to the JSON of payload
+func (n *NotificationService) send(channels []webhookNotifier, payload interface{}, data notificationData, kind string) {
+	defaultBody, err := json.Marshal(payload)
+	if err != nil {
+		n.logger.Error("Failed to marshal %s notification: %v", kind, err)
+		return
+	}
+
+	for _, channel := range channels {
+		body := defaultBody
+		if channel.template != nil {
+			rendered, err := renderNotification(channel.template, data)
+			if err != nil {
+				n.logger.Warning("Notification template failed for %s, sending the default body: %v", redactURL(channel.url), err)
+			} else {
+				body = rendered
+			}
 		}
-		cmd := exec.Command("curl", "-X", "POST",
-			"-H", "Content-Type: application/json",
-			"-d", string(jsonData),
-			n.webhookURL)
-		if err := cmd.Run(); err != nil {
-			n.logger.Error("Failed to send notification: %v", err)
+
+		if err := n.deliver(channel, body); err != nil {
+			n.logger.Error("Failed to send %s notification: %s %s: %v", kind, channel.method, redactURL(channel.url), err)
 		} else {
-			n.logger.Info("Notification sent for %s:%s", status.Repository, status.Branch)
+			n.logger.Info("Notification %s sent for %s:%s", kind, data.Repository, data.Branch)
+		}
+	}
+}
+
+// deliver sends a notification request, retrying with a doubling delay on errors and non-2xx responses
+func (n *NotificationService) deliver(channel webhookNotifier, body []byte) error {
+	var lastErr error
+	delay := time.Second
+	for attempt := 0; attempt <= channel.retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := http.NewRequest(channel.method, channel.url, bytes.NewReader(body))
+		if err != nil {
+			return redactURLError(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "UruFlow")
+		for name, value := range channel.headers {
+			req.Header.Set(name, value)
+		}
+
+		resp, err := n.client.Do(req)
+		if err != nil {
+			// the error embeds the URL, never the headers
+			lastErr = redactURLError(err)
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
 		}
-	}()
+		lastErr = fmt.Errorf("unexpected response %s", resp.Status)
+	}
+	return fmt.Errorf("giving up after %d attempt(s): %v", channel.retries+1, lastErr)
+}
+
+// redactURLError hides the password of the URL embedded in a *url.Error
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = redactURL(urlErr.URL)
+	}
+	return err
+}
+
+// redactURL hides the password of a URL for logging
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Redacted()
 }
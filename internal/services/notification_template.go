@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+
+	"uruflow.com/internal/models"
+)
+
+// builtinNotificationTemplates can be referenced by name instead of writing a template
+var builtinNotificationTemplates = map[string]string{
+	"slack":   `{"text": {{json (summary .)}}}`,
+	"discord": `{"content": {{json (summary .)}}}`,
+}
+
+// notificationFuncs are available to notification templates
+var notificationFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"summary": func(data notificationData) string {
+		text := fmt.Sprintf("[UruFlow] %s %s:%s", data.Status, data.Repository, data.Branch)
+		if data.CommitID != "" {
+			text += fmt.Sprintf(" (%s)", data.CommitID)
+		}
+		if data.Error != "" {
+			text += ": " + data.Error
+		}
+		return text
+	},
+}
+
+// notificationData is the value a notification template is executed with: the DeploymentStatus fields
+// plus the event name, for events Status holds the event name as well
+type notificationData struct {
+	models.DeploymentStatus
+	Event     string    `json:"event"`
+	Stage     string    `json:"stage,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ParseNotificationTemplate parses a notification body template or the name of a built-in one (slack, discord).
+// An empty template returns nil, meaning the default JSON body.
+func ParseNotificationTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	if builtin, ok := builtinNotificationTemplates[text]; ok {
+		text = builtin
+	}
+	tmpl, err := template.New("notification").Funcs(notificationFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// a dry run catches references to fields that do not exist
+	if err := tmpl.Execute(io.Discard, notificationData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// renderNotification executes a notification template
+func renderNotification(tmpl *template.Template, data notificationData) ([]byte, error) {
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"uruflow.com/internal/models"
+)
+
+func TestDeliverRedactsURL(t *testing.T) {
+	// a closed port makes the request itself fail, so the error embeds the URL
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := listener.Addr().String()
+	listener.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr string
+	}{
+		{"connection refused", "http://deploy:s3cr3t-pass@" + closedAddr + "/hook", "deploy:xxxxx@"},
+		{"error status", strings.Replace(failing.URL, "http://", "http://deploy:s3cr3t-pass@", 1), "502"},
+	}
+	n := NewNotificationService(&models.Config{}, newTestLogger(t))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := webhookNotifier{url: tt.url, method: http.MethodPost, headers: map[string]string{"Authorization": "Bearer header-token"}}
+			err := n.deliver(channel, []byte("{}"))
+			if err == nil {
+				t.Fatal("delivery succeeded")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %q does not contain %q", err, tt.wantErr)
+			}
+			if strings.Contains(err.Error(), "s3cr3t-pass") {
+				t.Errorf("error leaks the password: %v", err)
+			}
+			if strings.Contains(err.Error(), "header-token") {
+				t.Errorf("error leaks a header: %v", err)
+			}
+		})
+	}
+}
+
+func TestNotificationRetries(t *testing.T) {
+	var requests atomic.Int64
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	zero, two := 0, 2
+	tests := []struct {
+		name    string
+		retries *int
+		want    int
+	}{
+		{"unset", nil, defaultNotificationRetries},
+		{"no retry", &zero, 0},
+		{"two retries", &two, 2},
+	}
+	n := NewNotificationService(&models.Config{}, newTestLogger(t))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			channel := n.newChannel(models.NotificationChannel{URL: failing.URL, Retries: tt.retries})
+			if channel.retries != tt.want {
+				t.Fatalf("expected %d retries, got %d", tt.want, channel.retries)
+			}
+		})
+	}
+
+	channel := n.newChannel(models.NotificationChannel{URL: failing.URL, Retries: &zero})
+	if err := n.deliver(channel, []byte("{}")); err == nil || !strings.Contains(err.Error(), "after 1 attempt(s)") {
+		t.Fatalf("error %v, want a failure after one attempt", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("%d requests with retries 0, want 1", got)
+	}
+}
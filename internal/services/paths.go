@@ -0,0 +1,71 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// safeNamePattern is the charset allowed in repository names and branch name components
+var safeNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ValidateRepositoryName rejects repository names that are not a single safe path component
+func ValidateRepositoryName(name string) error {
+	if name == "" || name == "." || name == ".." || !safeNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid repository name %q: only letters, digits, '.', '_' and '-' are allowed", name)
+	}
+	return nil
+}
+
+// ValidateBranchName rejects branch names that could escape the repository directory;
+// slashes are allowed between components (feature/login) but '.' and '..' components are not
+func ValidateBranchName(branch string) error {
+	if branch == "" {
+		return fmt.Errorf("invalid branch name: empty")
+	}
+	for _, component := range strings.Split(branch, "/") {
+		if component == "" || component == "." || component == ".." || !safeNamePattern.MatchString(component) {
+			return fmt.Errorf("invalid branch name %q", branch)
+		}
+	}
+	return nil
+}
+
+// RepositoryPath returns the checkout directory of a repository branch inside workDir,
+// refusing names that would resolve outside of it
+func RepositoryPath(workDir, repoName, branch string) (string, error) {
+	if err := ValidateRepositoryName(repoName); err != nil {
+		return "", err
+	}
+	if err := ValidateBranchName(branch); err != nil {
+		return "", err
+	}
+
+	repoPath := filepath.Join(workDir, repoName, branch)
+	rel, err := filepath.Rel(workDir, repoPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("repository path %s escapes work_dir", repoPath)
+	}
+	return repoPath, nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRepositoryPath(t *testing.T) {
+	workDir := filepath.Join(string(filepath.Separator), "var", "uruflow", "repositories")
+	tests := []struct {
+		name     string
+		repoName string
+		branch   string
+		want     string
+		wantErr  bool
+	}{
+		{"plain", "api", "main", "api/main", false},
+		{"nested branch", "api", "feature/login", "api/feature/login", false},
+		{"dots inside names", "my.app", "release-1.2_rc", "my.app/release-1.2_rc", false},
+		{"dotted component", "api", "v1..2", "api/v1..2", false},
+		{"parent repository", "..", "main", "", true},
+		{"current repository", ".", "main", "", true},
+		{"repository with slash", "api/../../etc", "main", "", true},
+		{"absolute repository", "/etc", "main", "", true},
+		{"empty repository", "", "main", "", true},
+		{"parent branch", "api", "..", "", true},
+		{"traversal branch", "api", "../../etc/passwd", "", true},
+		{"traversal inside branch", "api", "feature/../../../etc", "", true},
+		{"current branch component", "api", "feature/./x", "", true},
+		{"absolute branch", "api", "/etc", "", true},
+		{"trailing slash", "api", "feature/", "", true},
+		{"double slash", "api", "feature//x", "", true},
+		{"empty branch", "api", "", "", true},
+		{"backslash", "api", `..\..\etc`, "", true},
+		{"shell metacharacters", "api", "main;rm -rf /", "", true},
+		{"command substitution", "api$(id)", "main", "", true},
+		{"newline", "api", "main\n..", "", true},
+		{"nul byte", "api", "main\x00", "", true},
+		{"unicode", "api", "fëature", "", true},
+		{"space", "my api", "main", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RepositoryPath(workDir, tt.repoName, tt.branch)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("RepositoryPath(%q, %q) = %q, want an error", tt.repoName, tt.branch, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := filepath.Join(workDir, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("RepositoryPath(%q, %q) = %q, want %q", tt.repoName, tt.branch, got, want)
+			}
+		})
+	}
+}
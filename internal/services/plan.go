@@ -0,0 +1,110 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"uruflow.com/internal/models"
+)
+
+// PlanWithContext reports the actions a deploy of the current checkout would perform, using
+// docker compose up --dry-run so nothing is built, pulled or recreated. Compose versions without
+// --dry-run only get their configuration validated, which is reported as the plan of that unit.
+func (d *DockerService) PlanWithContext(ctx context.Context, repo models.Repository, branch, repoPath string, only []string) ([]string, error) {
+	if repo.ComposeCommand != "" {
+		if err := ValidateComposeCommand(repo.ComposeCommand); err != nil {
+			return nil, err
+		}
+	}
+
+	units, err := ComposeUnits(repo, branch, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	secretEnv, secretValues, err := d.resolveSecrets(ctx, repo, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected [][]string
+	if len(only) > 0 {
+		if selected, err = d.selectServices(ctx, repo, units, repoPath, only, secretEnv); err != nil {
+			return nil, err
+		}
+	}
+
+	var plan []string
+	for i, unit := range units {
+		var unitServices []string
+		if selected != nil {
+			if unitServices = selected[i]; len(unitServices) == 0 {
+				continue
+			}
+		}
+
+		prefix := unit.ProjectName + ": "
+		if unit.Name != "" {
+			prefix = unit.Name + " (" + unit.ProjectName + "): "
+		}
+
+		cmd := d.composeCmd(ctx, repo, unit.ComposeFile, unit.ProjectName, repoPath, secretEnv, append(upArgs(repo, unitServices, true), "--dry-run")...)
+		output, err := cmd.CombinedOutput()
+		outputStr := maskSecrets(string(output), secretValues)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("docker compose up --dry-run interrupted: %w", ctx.Err())
+			}
+			if !dryRunUnsupported(outputStr) {
+				return nil, fmt.Errorf("docker compose up --dry-run failed for %s: %v, output: %s", unit.ComposeFile, err, strings.TrimSpace(outputStr))
+			}
+
+			d.logger.Warning("%s does not support --dry-run, only validating %s", d.ComposeCommandFor(repo), unit.ComposeFile)
+			cmd = d.composeCmd(ctx, repo, unit.ComposeFile, unit.ProjectName, repoPath, secretEnv, "config", "--quiet")
+			if output, err := cmd.CombinedOutput(); err != nil {
+				return nil, fmt.Errorf("docker compose config failed for %s: %v, output: %s", unit.ComposeFile, err, strings.TrimSpace(maskSecrets(string(output), secretValues)))
+			}
+			plan = append(plan, prefix+"configuration is valid (compose does not support --dry-run, planned actions unknown)")
+			continue
+		}
+
+		actions := 0
+		for _, line := range strings.Split(outputStr, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				plan = append(plan, prefix+line)
+				actions++
+			}
+		}
+		if actions == 0 {
+			plan = append(plan, prefix+"no changes")
+		}
+	}
+	return plan, nil
+}
+
+// dryRunUnsupported reports whether compose rejected --dry-run, as Compose v1 and v2 before 2.17 do
+func dryRunUnsupported(output string) bool {
+	lower := strings.ToLower(output)
+	return strings.Contains(lower, "unknown flag: --dry-run") || strings.Contains(lower, "no such option: --dry-run")
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrJobReaped is the cause attached to the context of a deployment that exceeded max_job_duration
+var ErrJobReaped = errors.New("deployment exceeded max_job_duration")
+
+// activeJob is an in-flight deployment holding its repository:branch job key
+type activeJob struct {
+	cancel  context.CancelCauseFunc
+	started time.Time
+}
+
+// StuckJob describes a deployment running longer than max_job_duration
+type StuckJob struct {
+	Job     string    `json:"job"`
+	Started time.Time `json:"started"`
+	Running string    `json:"running"`
+}
+
+// releaseJob frees a job key, unless it was reaped and since taken by a newer deployment
+func (ds *DeploymentService) releaseJob(jobKey string, job *activeJob) {
+	ds.activeJobsMu.Lock()
+	defer ds.activeJobsMu.Unlock()
+	if ds.activeJobs[jobKey] == job {
+		delete(ds.activeJobs, jobKey)
+	}
+}
+
+// maxJobDuration returns the configured job lifetime, 0 when reaping is disabled
+func (ds *DeploymentService) maxJobDuration() time.Duration {
+	return time.Duration(ds.currentConfig().Settings.MaxJobDuration) * time.Second
+}
+
+// StartJobReaper clears job keys held longer than max_job_duration every interval in the background
+func (ds *DeploymentService) StartJobReaper(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ds.reapStaleJobs()
+		}
+	}()
+}
+
+// reapStaleJobs cancels and forgets every job exceeding max_job_duration so new deploys of its key can run
+func (ds *DeploymentService) reapStaleJobs() {
+	maxDuration := ds.maxJobDuration()
+	if maxDuration <= 0 {
+		return
+	}
+
+	ds.activeJobsMu.Lock()
+	defer ds.activeJobsMu.Unlock()
+	for jobKey, job := range ds.activeJobs {
+		running := time.Since(job.started)
+		if running <= maxDuration {
+			continue
+		}
+		ds.logger.Warning("Reaping stale deployment %s: running for %v, max_job_duration is %v", jobKey, running.Round(time.Second), maxDuration)
+		job.cancel(ErrJobReaped)
+		delete(ds.activeJobs, jobKey)
+		ds.reapedJobs.Add(1)
+	}
+}
+
+// StuckJobs returns the deployments running longer than max_job_duration that have not been reaped yet
+func (ds *DeploymentService) StuckJobs() []StuckJob {
+	maxDuration := ds.maxJobDuration()
+	stuck := []StuckJob{}
+	if maxDuration <= 0 {
+		return stuck
+	}
+
+	ds.activeJobsMu.RLock()
+	defer ds.activeJobsMu.RUnlock()
+	for jobKey, job := range ds.activeJobs {
+		if running := time.Since(job.started); running > maxDuration {
+			stuck = append(stuck, StuckJob{Job: jobKey, Started: job.started, Running: running.Round(time.Second).String()})
+		}
+	}
+	sort.Slice(stuck, func(i, j int) bool { return stuck[i].Job < stuck[j].Job })
+	return stuck
+}
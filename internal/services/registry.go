@@ -0,0 +1,127 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"uruflow.com/internal/models"
+)
+
+// ValidateRegistries checks that every registry has a username and exactly one password source
+func ValidateRegistries(registries []models.RegistryCredential) error {
+	for _, registry := range registries {
+		name := registryName(registry)
+		if registry.Username == "" {
+			return fmt.Errorf("username is required for registry %s", name)
+		}
+		sources := 0
+		for _, source := range []string{registry.Password, registry.PasswordEnv, registry.PasswordFile} {
+			if source != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return fmt.Errorf("registry %s needs exactly one of password, password_env or password_file", name)
+		}
+	}
+	return nil
+}
+
+// registryName returns the registry address used in logs, Docker Hub for an empty URL
+func registryName(registry models.RegistryCredential) string {
+	if registry.URL == "" {
+		return "Docker Hub"
+	}
+	return registry.URL
+}
+
+// registryPassword reads the password of a registry from its configured source
+func registryPassword(ctx context.Context, registry models.RegistryCredential) (string, error) {
+	switch {
+	case registry.PasswordEnv != "":
+		return EnvSecretProvider{}.Fetch(ctx, registry.PasswordEnv)
+	case registry.PasswordFile != "":
+		return FileSecretProvider{}.Fetch(ctx, registry.PasswordFile)
+	default:
+		return registry.Password, nil
+	}
+}
+
+// loginRegistries runs docker login for every configured registry that is not logged in yet with the
+// current credentials. Failures are logged and retried on the next deploy, public images still deploy.
+func (d *DockerService) loginRegistries(ctx context.Context) {
+	logger := loggerFrom(ctx, d.logger)
+	d.registryMu.Lock()
+	defer d.registryMu.Unlock()
+
+	for _, registry := range d.currentConfig().Settings.Registries {
+		name := registryName(registry)
+		password, err := registryPassword(ctx, registry)
+		if err != nil {
+			logger.Warning("Could not read password for registry %s: %v", name, err)
+			continue
+		}
+		if password == "" {
+			logger.Warning("Empty password for registry %s, skipping login", name)
+			continue
+		}
+
+		sum := sha256.Sum256([]byte(registry.Username + "\x00" + password))
+		fingerprint := hex.EncodeToString(sum[:])
+		if d.registryLogins[registry.URL] == fingerprint {
+			continue
+		}
+
+		if err := d.registryLogin(ctx, registry, password); err != nil {
+			logger.Warning("Login to registry %s as %s failed: %v", name, registry.Username, err)
+			continue
+		}
+		d.registryLogins[registry.URL] = fingerprint
+		logger.Security("Logged in to registry %s as %s (password: ********)", name, registry.Username)
+	}
+}
+
+// registryLogin runs docker login passing the password on stdin so it never shows up in the process list
+func (d *DockerService) registryLogin(ctx context.Context, registry models.RegistryCredential, password string) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	args := []string{"login", "--username", registry.Username, "--password-stdin"}
+	if registry.URL != "" {
+		args = append(args, registry.URL)
+	}
+	cmd := d.commandContext(ctx, "docker", args...)
+	cmd.Stdin = strings.NewReader(password)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(maskSecrets(output.String(), []string{password})))
+	}
+	return nil
+}
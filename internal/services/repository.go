@@ -24,39 +24,243 @@ package services
 // cleanupCorruptedRepository to Handles corrupted repositories by removing them before re-initialization
 // better error handling and also update other methods to fit the deployment
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"uruflow.com/internal/models"
 	"uruflow.com/internal/utils"
 )
 
+// DefaultComposeFiles lists the standard Compose filenames in precedence order
+var DefaultComposeFiles = []string{
+	"compose.yaml",
+	"compose.yml",
+	"docker-compose.yml",
+	"docker-compose.yaml",
+}
+
+// ResolveComposeFile returns the compose file to use for a checkout. An explicit
+// compose_file always wins, otherwise the first standard filename found is used.
+func ResolveComposeFile(repo models.Repository, repoPath string) (string, error) {
+	if repo.ComposeFile != "" {
+		return repo.ComposeFile, nil
+	}
+
+	for _, name := range DefaultComposeFiles {
+		if _, err := os.Stat(filepath.Join(repoPath, name)); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no compose file found (tried: %s)", strings.Join(DefaultComposeFiles, ", "))
+}
+
+// ProjectName returns the Docker Compose project name of a repository branch
+func ProjectName(repo models.Repository, branch string) string {
+	if branchConfig, exists := repo.BranchConfig[branch]; exists && branchConfig.ProjectName != "" {
+		return branchConfig.ProjectName
+	}
+	return fmt.Sprintf("%s-%s", repo.Name, branch)
+}
+
+// ProjectNames returns the compose project of every unit deployed for a branch
+func ProjectNames(repo models.Repository, branch string) []string {
+	base := ProjectName(repo, branch)
+	units := repo.BranchConfig[branch].Units
+	if len(units) == 0 {
+		return []string{base}
+	}
+
+	names := make([]string, 0, len(units))
+	for _, unit := range units {
+		names = append(names, unitProjectName(base, unit))
+	}
+	return names
+}
+
+// ValidateProjectNames checks that no two enabled repository branches or units resolve to the same compose
+// project, since their deploys would stop and replace each other's containers. Compose lowercases project names.
+func ValidateProjectNames(repos []models.Repository) error {
+	owners := make(map[string]string)
+	for _, repo := range repos {
+		if !repo.Enabled {
+			continue
+		}
+		for _, branch := range repo.Branches {
+			owner := repo.Name + ":" + branch
+			names := ProjectNames(repo, branch)
+			units := repo.BranchConfig[branch].Units
+			for i, name := range names {
+				entry := owner
+				if len(units) > 0 {
+					entry = fmt.Sprintf("%s (unit %s)", owner, units[i].Name)
+				}
+				key := strings.ToLower(name)
+				if previous, exists := owners[key]; exists {
+					return fmt.Errorf("compose project %q is used by both %s and %s, set a distinct project_name in branch_config", name, previous, entry)
+				}
+				owners[key] = entry
+			}
+		}
+	}
+	return nil
+}
+
+// unitProjectName returns the project of a unit, defaulting to the branch project suffixed with the unit name
+func unitProjectName(base string, unit models.ComposeUnit) string {
+	if unit.ProjectName != "" {
+		return unit.ProjectName
+	}
+	return base + "-" + unit.Name
+}
+
+// ComposeUnits returns the compose units of a branch in deploy order with their compose files resolved
+// against the checkout. A branch without units is a single unnamed unit using the branch or repository compose_file.
+func ComposeUnits(repo models.Repository, branch, repoPath string) ([]models.ComposeUnit, error) {
+	base := ProjectName(repo, branch)
+	if branchFile := repo.BranchConfig[branch].ComposeFile; branchFile != "" {
+		repo.ComposeFile = branchFile
+	}
+	configured := repo.BranchConfig[branch].Units
+	if len(configured) == 0 {
+		composeFile, err := ResolveComposeFile(repo, repoPath)
+		if err != nil {
+			return nil, err
+		}
+		return []models.ComposeUnit{{ComposeFile: composeFile, ProjectName: base}}, nil
+	}
+
+	units := make([]models.ComposeUnit, 0, len(configured))
+	for _, unit := range configured {
+		unitRepo := repo
+		if unit.ComposeFile != "" {
+			unitRepo.ComposeFile = unit.ComposeFile
+		}
+		composeFile, err := ResolveComposeFile(unitRepo, repoPath)
+		if err != nil {
+			return nil, fmt.Errorf("unit %s: %v", unit.Name, err)
+		}
+		units = append(units, models.ComposeUnit{
+			Name:        unit.Name,
+			ComposeFile: composeFile,
+			ProjectName: unitProjectName(base, unit),
+		})
+	}
+	return units, nil
+}
+
+// verifyComposeUnits checks that the compose file of every unit exists and is not empty
+func verifyComposeUnits(units []models.ComposeUnit, repoPath string) error {
+	for _, unit := range units {
+		composeFile := filepath.Join(repoPath, unit.ComposeFile)
+		fileInfo, err := os.Stat(composeFile)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("docker-compose file not found: %s", unit.ComposeFile)
+		}
+		if err == nil && fileInfo.Size() == 0 {
+			return fmt.Errorf("docker-compose file is empty: %s", unit.ComposeFile)
+		}
+	}
+	return nil
+}
+
 // RepositoryService manages repository operations
 type RepositoryService struct {
-	config     *models.Config
-	gitService *GitService
-	logger     *utils.Logger
+	config          *models.Config
+	configMu        sync.RWMutex
+	gitService      *GitService
+	logger          *utils.Logger
+	notifier        *NotificationService
+	defaultBranches map[string]string
+	defaultMu       sync.RWMutex
+	statusCache     map[string]statusCacheEntry
+	statusMu        sync.Mutex
 }
 
+// statusCacheEntry is a cached result of the initialization check of a repository branch
+type statusCacheEntry struct {
+	initialized bool
+	checkedAt   time.Time
+}
+
+// statusCacheTTL is how long an initialization check result is reused
+const statusCacheTTL = 10 * time.Second
+
 // NewRepositoryService creates a new repository service
 func NewRepositoryService(config *models.Config, gitService *GitService, logger *utils.Logger) *RepositoryService {
 	return &RepositoryService{
-		config:     config,
-		gitService: gitService,
-		logger:     logger,
+		config:          config,
+		gitService:      gitService,
+		logger:          logger,
+		defaultBranches: make(map[string]string),
+		statusCache:     make(map[string]statusCacheEntry),
 	}
 }
 
+// SetNotifier sets the notification service used to report initialization failures
+func (rs *RepositoryService) SetNotifier(notifier *NotificationService) {
+	rs.notifier = notifier
+}
+
 // UpdateConfig updates the configuration reference
 func (rs *RepositoryService) UpdateConfig(config *models.Config) {
+	rs.configMu.Lock()
 	rs.config = config
+	rs.configMu.Unlock()
+	rs.InvalidateStatus("", "")
 	rs.logger.Config("Repository service configuration updated")
 }
 
-// IsRepositoryInitialized checks if a repository branch is properly initialized
+// currentConfig returns the configuration in use; a reload swaps the pointer, the returned config is never modified
+func (rs *RepositoryService) currentConfig() *models.Config {
+	rs.configMu.RLock()
+	defer rs.configMu.RUnlock()
+	return rs.config
+}
+
+// IsRepositoryInitialized checks if a repository branch is properly initialized, reusing a check made within statusCacheTTL
 func (rs *RepositoryService) IsRepositoryInitialized(repoName, branch string) bool {
+	key := fmt.Sprintf("%s:%s", repoName, branch)
+
+	rs.statusMu.Lock()
+	entry, cached := rs.statusCache[key]
+	rs.statusMu.Unlock()
+	if cached && time.Since(entry.checkedAt) < statusCacheTTL {
+		return entry.initialized
+	}
+	return rs.IsRepositoryInitializedFresh(repoName, branch)
+}
+
+// IsRepositoryInitializedFresh checks the filesystem for the initialization of a repository branch and refreshes the cache
+func (rs *RepositoryService) IsRepositoryInitializedFresh(repoName, branch string) bool {
+	initialized := rs.checkRepositoryInitialized(repoName, branch)
+
+	rs.statusMu.Lock()
+	rs.statusCache[fmt.Sprintf("%s:%s", repoName, branch)] = statusCacheEntry{initialized: initialized, checkedAt: time.Now()}
+	rs.statusMu.Unlock()
+	return initialized
+}
+
+// InvalidateStatus drops the cached initialization status of a branch, an empty repoName drops every entry
+func (rs *RepositoryService) InvalidateStatus(repoName, branch string) {
+	rs.statusMu.Lock()
+	defer rs.statusMu.Unlock()
+
+	if repoName == "" {
+		rs.statusCache = make(map[string]statusCacheEntry)
+		return
+	}
+	delete(rs.statusCache, fmt.Sprintf("%s:%s", repoName, branch))
+}
+
+// checkRepositoryInitialized verifies the checkout, branch and compose files of a repository branch
+func (rs *RepositoryService) checkRepositoryInitialized(repoName, branch string) bool {
 	repo := rs.GetRepository(repoName)
 	if repo == nil {
 		rs.logger.Warning("Repository %s not found or disabled", repoName)
@@ -68,7 +272,11 @@ func (rs *RepositoryService) IsRepositoryInitialized(repoName, branch string) bo
 		return false
 	}
 
-	repoPath := rs.getRepositoryPath(repoName, branch)
+	repoPath, err := rs.getRepositoryPath(repoName, branch)
+	if err != nil {
+		rs.logger.Security("Refusing repository path: %v", err)
+		return false
+	}
 
 	if _, err := os.Stat(repoPath); os.IsNotExist(err) {
 		rs.logger.Debug("Repository directory missing: %s", repoPath)
@@ -81,12 +289,22 @@ func (rs *RepositoryService) IsRepositoryInitialized(repoName, branch string) bo
 		return false
 	}
 
-	composeFile := filepath.Join(repoPath, repo.ComposeFile)
-	if fileInfo, err := os.Stat(composeFile); os.IsNotExist(err) {
-		rs.logger.Debug("Docker compose file missing: %s", composeFile)
+	info, _ := rs.gitService.GetRepositoryInfo(repoPath)
+	if currentBranch := info["current_branch"]; currentBranch != branch {
+		if currentBranch == "" {
+			currentBranch = "(detached or unknown)"
+		}
+		rs.logger.Warning("Branch mismatch in %s: checked out %s, expected %s - treating as uninitialized", repoPath, currentBranch, branch)
 		return false
-	} else if fileInfo.Size() == 0 {
-		rs.logger.Debug("Docker compose file is empty: %s", composeFile)
+	}
+
+	units, err := ComposeUnits(*repo, branch, repoPath)
+	if err != nil {
+		rs.logger.Debug("Docker compose file missing in %s: %v", repoPath, err)
+		return false
+	}
+	if err := verifyComposeUnits(units, repoPath); err != nil {
+		rs.logger.Debug("%v in %s", err, repoPath)
 		return false
 	}
 
@@ -96,6 +314,12 @@ func (rs *RepositoryService) IsRepositoryInitialized(repoName, branch string) bo
 
 // InitializeRepository initializes a specific repository and branch
 func (rs *RepositoryService) InitializeRepository(repo models.Repository, branch string) error {
+	return rs.InitializeRepositoryWithContext(context.Background(), repo, branch)
+}
+
+// InitializeRepositoryWithContext initializes a repository and branch like InitializeRepository, killing the clone when ctx is cancelled
+func (rs *RepositoryService) InitializeRepositoryWithContext(ctx context.Context, repo models.Repository, branch string) error {
+	logger := loggerFrom(ctx, rs.logger)
 	if err := rs.ValidateRepository(repo); err != nil {
 		return fmt.Errorf("repository validation failed: %v", err)
 	}
@@ -104,23 +328,27 @@ func (rs *RepositoryService) InitializeRepository(repo models.Repository, branch
 		return fmt.Errorf("branch %s is not configured for repository %s", branch, repo.Name)
 	}
 
-	repoPath := rs.getRepositoryPath(repo.Name, branch)
+	repoPath, err := rs.getRepositoryPath(repo.Name, branch)
+	if err != nil {
+		return err
+	}
+	defer rs.InvalidateStatus(repo.Name, branch)
 
-	rs.logger.Info("Initializing repository %s:%s at %s", repo.Name, branch, repoPath)
+	logger.Info("Initializing repository %s:%s at %s", repo.Name, branch, repoPath)
 
 	if err := rs.cleanupCorruptedRepository(repoPath); err != nil {
-		rs.logger.Warning("Failed to cleanup existing repository: %v", err)
+		logger.Warning("Failed to cleanup existing repository: %v", err)
 	}
 
-	if err := rs.gitService.SetupRepository(repo, branch, repoPath); err != nil {
-		return fmt.Errorf("failed to setup repository %s:%s - %v", repo.Name, branch, err)
+	if err := rs.gitService.SetupRepositoryWithContext(ctx, repo, branch, repoPath); err != nil {
+		return fmt.Errorf("failed to setup repository %s:%s - %w", repo.Name, branch, err)
 	}
 
-	if err := rs.verifyDockerCompose(repo, repoPath); err != nil {
+	if err := rs.verifyDockerCompose(repo, branch, repoPath); err != nil {
 		return fmt.Errorf("docker compose verification failed for %s:%s - %v", repo.Name, branch, err)
 	}
 
-	rs.logger.Success("Repository %s:%s initialized successfully", repo.Name, branch)
+	logger.Success("Repository %s:%s initialized successfully", repo.Name, branch)
 	return nil
 }
 
@@ -138,64 +366,92 @@ func (rs *RepositoryService) cleanupCorruptedRepository(repoPath string) error {
 func (rs *RepositoryService) InitializeRepositories() error {
 	rs.logger.Info("Initializing repositories...")
 
-	if rs.config.Settings.AutoClone {
-		for _, repo := range rs.config.Repositories {
+	var skipped []string
+	config := rs.currentConfig()
+	// branch env files are checked even without auto_clone, a missing file would only fail the first deploy
+	for _, repo := range config.Repositories {
+		if !repo.Enabled {
+			continue
+		}
+		if err := ValidateBranchSecrets(repo); err != nil {
+			rs.logger.Error("Invalid branch_config for repository %s: %v", repo.Name, err)
+			return fmt.Errorf("invalid branch_config for repository %s: %v", repo.Name, err)
+		}
+	}
+
+	if config.Settings.AutoClone {
+		for _, repo := range config.Repositories {
 			if !repo.Enabled {
 				rs.logger.Info("Skipping disabled repository: %s", repo.Name)
 				continue
 			}
 
-			if err := rs.cloneRepository(repo); err != nil {
+			skippedBranches, err := rs.cloneRepository(repo)
+			skipped = append(skipped, skippedBranches...)
+			if err != nil {
 				rs.logger.Error("Failed to initialize repository %s: %v", repo.Name, err)
 				return err
 			}
 		}
 	}
 
+	if len(skipped) > 0 {
+		rs.logger.Warning("Repositories initialized, skipped %d missing branches: %s", len(skipped), strings.Join(skipped, ", "))
+		return nil
+	}
+
 	rs.logger.Success("All repositories initialized successfully")
 	return nil
 }
 
-// cloneRepository clones a repository and its configured branches
-func (rs *RepositoryService) cloneRepository(repo models.Repository) error {
+// cloneRepository clones a repository and its configured branches, returning branches skipped because they do not exist on the remote
+func (rs *RepositoryService) cloneRepository(repo models.Repository) ([]string, error) {
 	rs.logger.Info("Initializing repository: %s", repo.Name)
 
-	for _, branch := range repo.Branches {
+	var skipped []string
+	branches := rs.GetDeployBranches(repo)
+	for _, branch := range branches {
 		if err := rs.InitializeRepository(repo, branch); err != nil {
-			return err
+			if rs.notifier != nil {
+				rs.notifier.SendInitFailure(repo.Name, branch, err)
+			}
+			if errors.Is(err, ErrRemoteBranchNotFound) {
+				rs.logger.Warning("Skipping %s:%s - branch not found on remote (renamed or deleted?)", repo.Name, branch)
+				skipped = append(skipped, fmt.Sprintf("%s:%s", repo.Name, branch))
+				continue
+			}
+			return skipped, err
 		}
 	}
 
-	rs.logger.Success("Repository %s initialized with %d branches", repo.Name, len(repo.Branches))
-	return nil
+	rs.logger.Success("Repository %s initialized with %d branches", repo.Name, len(branches)-len(skipped))
+	return skipped, nil
 }
 
-// verifyDockerCompose checks if docker-compose.yml exists in the repository
-func (rs *RepositoryService) verifyDockerCompose(repo models.Repository, repoPath string) error {
-	composeFile := filepath.Join(repoPath, repo.ComposeFile)
-
-	if _, err := os.Stat(composeFile); os.IsNotExist(err) {
-		return fmt.Errorf("docker-compose file not found: %s", repo.ComposeFile)
+// verifyDockerCompose checks if the compose file of every unit exists in the repository
+func (rs *RepositoryService) verifyDockerCompose(repo models.Repository, branch, repoPath string) error {
+	units, err := ComposeUnits(repo, branch, repoPath)
+	if err != nil {
+		return err
 	}
-
-	if fileInfo, err := os.Stat(composeFile); err == nil {
-		if fileInfo.Size() == 0 {
-			return fmt.Errorf("docker-compose file is empty: %s", repo.ComposeFile)
-		}
+	if err := verifyComposeUnits(units, repoPath); err != nil {
+		return err
 	}
 
-	rs.logger.Debug("Verified docker-compose file: %s", repo.ComposeFile)
+	for _, unit := range units {
+		rs.logger.Debug("Verified docker-compose file: %s", unit.ComposeFile)
+	}
 	return nil
 }
 
-// getRepositoryPath returns the local path for a repository branch
-func (rs *RepositoryService) getRepositoryPath(repoName, branch string) string {
-	return filepath.Join(rs.config.Settings.WorkDir, repoName, branch)
+// getRepositoryPath returns the local path for a repository branch, rejecting names that would escape the work dir
+func (rs *RepositoryService) getRepositoryPath(repoName, branch string) (string, error) {
+	return RepositoryPath(rs.currentConfig().Settings.WorkDir, repoName, branch)
 }
 
 // GetRepository finds a repository by name
 func (rs *RepositoryService) GetRepository(name string) *models.Repository {
-	for _, repo := range rs.config.Repositories {
+	for _, repo := range rs.currentConfig().Repositories {
 		if repo.Name == name && repo.Enabled {
 			return &repo
 		}
@@ -206,7 +462,7 @@ func (rs *RepositoryService) GetRepository(name string) *models.Repository {
 // ListRepositories returns all enabled repositories
 func (rs *RepositoryService) ListRepositories() []models.Repository {
 	var repos []models.Repository
-	for _, repo := range rs.config.Repositories {
+	for _, repo := range rs.currentConfig().Repositories {
 		if repo.Enabled {
 			repos = append(repos, repo)
 		}
@@ -214,21 +470,108 @@ func (rs *RepositoryService) ListRepositories() []models.Repository {
 	return repos
 }
 
-// IsBranchConfigured checks if a branch is configured for deployment
+// IsBranchConfigured checks if a branch is configured for deployment, either listed in branches
+// or the known default branch of a repository with deploy_default_branch enabled
 func (rs *RepositoryService) IsBranchConfigured(repo *models.Repository, branch string) bool {
+	return rs.isBranchIncluded(repo, branch) && !rs.IsBranchExcluded(repo, branch)
+}
+
+// isBranchIncluded checks if a branch is listed in branches or is the known default branch
+func (rs *RepositoryService) isBranchIncluded(repo *models.Repository, branch string) bool {
 	for _, b := range repo.Branches {
 		if b == branch {
 			return true
 		}
 	}
+	if repo.DeployDefaultBranch {
+		return branch != "" && branch == rs.GetDefaultBranch(repo.Name)
+	}
+	return false
+}
+
+// IsBranchExcluded checks if a branch matches one of the exclude_branches names or globs
+func (rs *RepositoryService) IsBranchExcluded(repo *models.Repository, branch string) bool {
+	for _, pattern := range repo.ExcludeBranches {
+		if matched, err := path.Match(pattern, branch); err == nil && matched {
+			return true
+		}
+	}
 	return false
 }
 
+// SetDefaultBranch records the remote default branch of a repository, as reported by a webhook
+func (rs *RepositoryService) SetDefaultBranch(repoName, branch string) {
+	rs.defaultMu.Lock()
+	defer rs.defaultMu.Unlock()
+
+	if previous := rs.defaultBranches[repoName]; previous != branch {
+		rs.logger.Git("Default branch of %s is %s", repoName, branch)
+	}
+	rs.defaultBranches[repoName] = branch
+}
+
+// GetDefaultBranch returns the recorded default branch of a repository, empty when unknown
+func (rs *RepositoryService) GetDefaultBranch(repoName string) string {
+	rs.defaultMu.RLock()
+	defer rs.defaultMu.RUnlock()
+	return rs.defaultBranches[repoName]
+}
+
+// ResolveDefaultBranch returns the default branch of a repository, asking the remote when no webhook reported it yet
+func (rs *RepositoryService) ResolveDefaultBranch(repo models.Repository) (string, error) {
+	if branch := rs.GetDefaultBranch(repo.Name); branch != "" {
+		return branch, nil
+	}
+
+	branch, err := rs.gitService.GetRemoteDefaultBranch(repo.GitURL)
+	if err != nil {
+		return "", err
+	}
+	rs.SetDefaultBranch(repo.Name, branch)
+	return branch, nil
+}
+
+// GetDeployBranches returns the configured branches plus the default branch when deploy_default_branch is enabled, minus exclude_branches
+func (rs *RepositoryService) GetDeployBranches(repo models.Repository) []string {
+	var branches []string
+	for _, b := range repo.Branches {
+		if !rs.IsBranchExcluded(&repo, b) {
+			branches = append(branches, b)
+		}
+	}
+	if !repo.DeployDefaultBranch {
+		return branches
+	}
+
+	defaultBranch, err := rs.ResolveDefaultBranch(repo)
+	if err != nil {
+		rs.logger.Warning("Could not resolve default branch of %s: %v", repo.Name, err)
+		return branches
+	}
+	if rs.IsBranchExcluded(&repo, defaultBranch) {
+		return branches
+	}
+	for _, b := range branches {
+		if b == defaultBranch {
+			return branches
+		}
+	}
+	return append(branches, defaultBranch)
+}
+
+// IsAutoDeployEnabled checks if webhooks should deploy a branch, a branch-level auto_deploy overrides the repository setting
+func (rs *RepositoryService) IsAutoDeployEnabled(repo *models.Repository, branch string) bool {
+	if branchConfig, exists := repo.BranchConfig[branch]; exists && branchConfig.AutoDeploy != nil {
+		return *branchConfig.AutoDeploy
+	}
+	return repo.AutoDeploy
+}
+
 // GetRepositoryInfo returns detailed information about repositories
 func (rs *RepositoryService) GetRepositoryInfo() map[string]interface{} {
 	info := make(map[string]interface{})
 
-	for _, repo := range rs.config.Repositories {
+	for _, repo := range rs.currentConfig().Repositories {
 		if !repo.Enabled {
 			continue
 		}
@@ -248,6 +591,38 @@ func (rs *RepositoryService) GetRepositoryInfo() map[string]interface{} {
 	return info
 }
 
+// GetDeploymentState returns the initialization status and checked-out commit of every enabled repository branch
+func (rs *RepositoryService) GetDeploymentState() map[string]interface{} {
+	state := make(map[string]interface{})
+
+	for _, repo := range rs.currentConfig().Repositories {
+		if !repo.Enabled {
+			continue
+		}
+
+		status := rs.getRepositoryStatus(repo)
+		branches := make(map[string]interface{})
+		for _, branch := range repo.Branches {
+			branchState := map[string]interface{}{
+				"status":   status[branch],
+				"projects": ProjectNames(repo, branch),
+			}
+			if status[branch] != "not_cloned" {
+				if repoPath, err := rs.getRepositoryPath(repo.Name, branch); err == nil {
+					if info, err := rs.gitService.GetRepositoryInfo(repoPath); err == nil && info["commit_hash"] != "" {
+						branchState["commit"] = info["commit_hash"]
+					}
+				}
+			}
+			branches[branch] = branchState
+		}
+
+		state[repo.Name] = branches
+	}
+
+	return state
+}
+
 // getRepositoryStatus checks the status of a repository
 func (rs *RepositoryService) getRepositoryStatus(repo models.Repository) map[string]string {
 	status := make(map[string]string)
@@ -256,8 +631,10 @@ func (rs *RepositoryService) getRepositoryStatus(repo models.Repository) map[str
 		if rs.IsRepositoryInitialized(repo.Name, branch) {
 			status[branch] = "ready"
 		} else {
-			repoPath := rs.getRepositoryPath(repo.Name, branch)
-			if _, err := os.Stat(repoPath); os.IsNotExist(err) {
+			repoPath, err := rs.getRepositoryPath(repo.Name, branch)
+			if err != nil {
+				status[branch] = "invalid_name"
+			} else if _, err := os.Stat(repoPath); os.IsNotExist(err) {
 				status[branch] = "not_cloned"
 			} else {
 				status[branch] = "missing_compose"
@@ -274,20 +651,40 @@ func (rs *RepositoryService) ValidateRepository(repo models.Repository) error {
 		return fmt.Errorf("repository name is required")
 	}
 
+	if err := ValidateRepositoryName(repo.Name); err != nil {
+		return err
+	}
+
 	if repo.GitURL == "" {
 		return fmt.Errorf("git URL is required for repository %s", repo.Name)
 	}
 
-	if len(repo.Branches) == 0 {
-		return fmt.Errorf("at least one branch is required for repository %s", repo.Name)
+	for _, branch := range repo.Branches {
+		if err := ValidateBranchName(branch); err != nil {
+			return fmt.Errorf("%v for repository %s", err, repo.Name)
+		}
+	}
+
+	if len(repo.Branches) == 0 && !repo.DeployDefaultBranch {
+		return fmt.Errorf("at least one branch (or deploy_default_branch) is required for repository %s", repo.Name)
 	}
 
 	if !strings.HasPrefix(repo.GitURL, "http") && !strings.HasPrefix(repo.GitURL, "git@") {
 		return fmt.Errorf("invalid git URL format for repository %s", repo.Name)
 	}
 
-	if repo.ComposeFile == "" {
-		return fmt.Errorf("compose file is required for repository %s", repo.Name)
+	if repo.ComposeCommand != "" {
+		if err := ValidateComposeCommand(repo.ComposeCommand); err != nil {
+			return fmt.Errorf("invalid compose_command for repository %s: %v", repo.Name, err)
+		}
+	}
+
+	if err := ValidateSecrets(repo.Secrets); err != nil {
+		return fmt.Errorf("invalid secrets for repository %s: %v", repo.Name, err)
+	}
+
+	if err := ValidateBranchSecrets(repo); err != nil {
+		return fmt.Errorf("invalid branch_config for repository %s: %v", repo.Name, err)
 	}
 
 	return nil
@@ -301,8 +698,11 @@ func (rs *RepositoryService) UpdateRepository(repoName string) error {
 	}
 
 	rs.logger.Info("Updating repository: %s", repoName)
-	for _, branch := range repo.Branches {
-		repoPath := rs.getRepositoryPath(repo.Name, branch)
+	for _, branch := range rs.GetDeployBranches(*repo) {
+		repoPath, err := rs.getRepositoryPath(repo.Name, branch)
+		if err != nil {
+			return err
+		}
 
 		if err := rs.gitService.SetupRepository(*repo, branch, repoPath); err != nil {
 			return fmt.Errorf("failed to update %s:%s - %v", repo.Name, branch, err)
@@ -321,7 +721,10 @@ func (rs *RepositoryService) ForceReinitializeRepository(repoName, branch string
 	}
 
 	rs.logger.Info("Force re-initializing repository %s:%s", repoName, branch)
-	repoPath := rs.getRepositoryPath(repoName, branch)
+	repoPath, err := rs.getRepositoryPath(repoName, branch)
+	if err != nil {
+		return err
+	}
 	if err := rs.cleanupCorruptedRepository(repoPath); err != nil {
 		return fmt.Errorf("failed to cleanup repository: %v", err)
 	}
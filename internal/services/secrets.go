@@ -0,0 +1,255 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"uruflow.com/internal/models"
+)
+
+// SecretProvider fetches the value of a secret from an external source
+type SecretProvider interface {
+	Fetch(ctx context.Context, key string) (string, error)
+}
+
+// EnvSecretProvider reads secrets from the environment of the UruFlow process
+type EnvSecretProvider struct{}
+
+// Fetch returns the value of the environment variable key
+func (EnvSecretProvider) Fetch(ctx context.Context, key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", key)
+	}
+	return value, nil
+}
+
+// FileSecretProvider reads secrets from files such as mounted Docker or Kubernetes secrets
+type FileSecretProvider struct{}
+
+// Fetch returns the content of the file at key without the trailing newline
+func (FileSecretProvider) Fetch(ctx context.Context, key string) (string, error) {
+	data, err := os.ReadFile(key)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// CommandSecretProvider runs a command such as `pass show app/db` and uses its output as the secret
+type CommandSecretProvider struct{}
+
+// Fetch runs key as a command (without a shell) and returns its first output line
+func (CommandSecretProvider) Fetch(ctx context.Context, key string) (string, error) {
+	args := strings.Fields(key)
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty secret command")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("secret command %s failed: %v", args[0], err)
+	}
+	value, _, _ := strings.Cut(string(output), "\n")
+	return strings.TrimRight(value, "\r"), nil
+}
+
+var (
+	secretProviders = map[string]SecretProvider{
+		"env":     EnvSecretProvider{},
+		"file":    FileSecretProvider{},
+		"command": CommandSecretProvider{},
+	}
+	secretProvidersMu sync.RWMutex
+)
+
+// RegisterSecretProvider makes a secret provider available under name for the secrets repository option
+func RegisterSecretProvider(name string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+	secretProviders[name] = provider
+}
+
+// secretProvider returns the provider registered under name, an empty name selects env
+func secretProvider(name string) (SecretProvider, bool) {
+	if name == "" {
+		name = "env"
+	}
+	secretProvidersMu.RLock()
+	defer secretProvidersMu.RUnlock()
+	provider, ok := secretProviders[name]
+	return provider, ok
+}
+
+// ValidateSecrets checks that every secret of a repository has a name and a known provider
+func ValidateSecrets(secrets []models.SecretRef) error {
+	for _, secret := range secrets {
+		if secret.Name == "" {
+			return fmt.Errorf("secret name is required")
+		}
+		if _, ok := secretProvider(secret.Provider); !ok {
+			return fmt.Errorf("unknown provider '%s' for secret %s", secret.Provider, secret.Name)
+		}
+		if secret.Provider != "" && secret.Provider != "env" && secret.Key == "" {
+			return fmt.Errorf("key is required for secret %s", secret.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateBranchSecrets checks the secrets and env_file of every branch_config entry, the env_file must exist
+func ValidateBranchSecrets(repo models.Repository) error {
+	for branch, branchConfig := range repo.BranchConfig {
+		if err := ValidateSecrets(branchConfig.Secrets); err != nil {
+			return fmt.Errorf("branch %s: %v", branch, err)
+		}
+		if branchConfig.EnvFile == "" {
+			continue
+		}
+		// relative paths would resolve inside the checkout or the working directory of the process
+		if !filepath.IsAbs(branchConfig.EnvFile) {
+			return fmt.Errorf("branch %s: env_file must be an absolute path: %s", branch, branchConfig.EnvFile)
+		}
+		info, err := os.Stat(branchConfig.EnvFile)
+		if err != nil {
+			return fmt.Errorf("branch %s: env_file not readable: %v", branch, err)
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("branch %s: env_file is not a regular file: %s", branch, branchConfig.EnvFile)
+		}
+	}
+	return nil
+}
+
+// branchSecrets returns the repository secrets with those of the branch added, a branch secret replaces the
+// repository secret of the same name
+func branchSecrets(repo models.Repository, branch string) []models.SecretRef {
+	overrides := repo.BranchConfig[branch].Secrets
+	if len(overrides) == 0 {
+		return repo.Secrets
+	}
+
+	overridden := make(map[string]bool, len(overrides))
+	for _, secret := range overrides {
+		overridden[secret.Name] = true
+	}
+	secrets := make([]models.SecretRef, 0, len(repo.Secrets)+len(overrides))
+	for _, secret := range repo.Secrets {
+		if !overridden[secret.Name] {
+			secrets = append(secrets, secret)
+		}
+	}
+	return append(secrets, overrides...)
+}
+
+// readEnvFile parses a KEY=VALUE file into environment entries, skipping blank lines and # comments;
+// an optional export prefix and quotes around the value are removed
+func readEnvFile(path string) ([]string, []string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var env, values []string
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNumber)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env = append(env, key+"="+value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return env, values, nil
+}
+
+// resolveSecrets fetches the secrets of a repository branch, its env_file values first so that secrets win over
+// them, as NAME=value pairs for the compose environment; the plain values are returned as well so they can be
+// masked in logged output
+func (d *DockerService) resolveSecrets(ctx context.Context, repo models.Repository, branch string) ([]string, []string, error) {
+	logger := loggerFrom(ctx, d.logger)
+	var env, values []string
+	if envFile := repo.BranchConfig[branch].EnvFile; envFile != "" {
+		fileEnv, fileValues, err := readEnvFile(envFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read env_file of branch %s: %v", branch, err)
+		}
+		env = append(env, fileEnv...)
+		values = append(values, fileValues...)
+	}
+	for _, secret := range branchSecrets(repo, branch) {
+		provider, ok := secretProvider(secret.Provider)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown provider '%s' for secret %s", secret.Provider, secret.Name)
+		}
+		key := secret.Key
+		if key == "" {
+			key = secret.Name
+		}
+		value, err := provider.Fetch(ctx, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch secret %s: %v", secret.Name, err)
+		}
+		env = append(env, secret.Name+"="+value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(env) > 0 {
+		logger.Security("Injected %d secret(s) into the compose environment of %s:%s", len(env), repo.Name, branch)
+	}
+	return env, values, nil
+}
+
+// maskSecrets replaces every secret value in s so fetched secrets never reach the logs
+func maskSecrets(s string, values []string) string {
+	for _, value := range values {
+		s = strings.ReplaceAll(s, value, "********")
+	}
+	return s
+}
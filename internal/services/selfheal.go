@@ -0,0 +1,191 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"uruflow.com/internal/models"
+	"uruflow.com/internal/utils"
+)
+
+// maxSelfHealBackoff caps the delay between heal attempts of a crash-looping project
+const maxSelfHealBackoff = time.Hour
+
+// healState tracks heal attempts of a repository branch for rate limiting
+type healState struct {
+	attempts    int
+	lastAttempt time.Time
+	nextAttempt time.Time
+}
+
+// SelfHealService periodically redeploys repository branches whose containers are down
+type SelfHealService struct {
+	config            *models.Config
+	repositoryService *RepositoryService
+	deploymentService *DeploymentService
+	dockerService     *DockerService
+	logger            *utils.Logger
+	states            map[string]*healState
+	mu                sync.Mutex
+}
+
+// NewSelfHealService creates a new self-heal service
+func NewSelfHealService(
+	config *models.Config,
+	repositoryService *RepositoryService,
+	deploymentService *DeploymentService,
+	dockerService *DockerService,
+	logger *utils.Logger,
+) *SelfHealService {
+	return &SelfHealService{
+		config:            config,
+		repositoryService: repositoryService,
+		deploymentService: deploymentService,
+		dockerService:     dockerService,
+		logger:            logger,
+		states:            make(map[string]*healState),
+	}
+}
+
+// UpdateConfig updates the configuration used by the next reconciliation
+func (sh *SelfHealService) UpdateConfig(config *models.Config) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.config = config
+}
+
+// Start runs a reconciliation every interval in the background
+func (sh *SelfHealService) Start(interval time.Duration) {
+	sh.logger.Info("Self-heal enabled, checking projects every %v", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			sh.reconcile(interval)
+		}
+	}()
+}
+
+// reconcile checks every enabled repository branch and redeploys the ones that are down
+func (sh *SelfHealService) reconcile(interval time.Duration) {
+	sh.mu.Lock()
+	config := sh.config
+	sh.mu.Unlock()
+
+	for _, repo := range config.Repositories {
+		if !repo.Enabled {
+			continue
+		}
+		for _, branch := range sh.repositoryService.GetDeployBranches(repo) {
+			sh.reconcileBranch(repo, branch, interval)
+		}
+	}
+}
+
+// reconcileBranch redeploys a single repository branch if its containers are down and it is not rate limited
+func (sh *SelfHealService) reconcileBranch(repo models.Repository, branch string, interval time.Duration) {
+	key := fmt.Sprintf("%s:%s", repo.Name, branch)
+
+	// only heal branches that were deployed before, a missing checkout is left to the next push
+	if !sh.repositoryService.IsRepositoryInitialized(repo.Name, branch) {
+		return
+	}
+	for _, job := range sh.deploymentService.GetActiveJobs() {
+		if job == key {
+			return
+		}
+	}
+
+	down, reason, err := sh.isProjectDown(repo, branch)
+	if err != nil {
+		sh.logger.Warning("Self-heal could not check %s: %v", key, err)
+		return
+	}
+
+	sh.mu.Lock()
+	state := sh.states[key]
+	if !down {
+		if state != nil && time.Since(state.lastAttempt) > maxSelfHealBackoff {
+			delete(sh.states, key)
+		}
+		sh.mu.Unlock()
+		return
+	}
+	if state == nil {
+		state = &healState{}
+		sh.states[key] = state
+	}
+	if time.Now().Before(state.nextAttempt) {
+		sh.mu.Unlock()
+		sh.logger.Debug("Self-heal for %s is rate limited until %s", key, state.nextAttempt.Format("15:04:05"))
+		return
+	}
+	state.attempts++
+	state.lastAttempt = time.Now()
+	backoff := interval << (state.attempts - 1)
+	if backoff <= 0 || backoff > maxSelfHealBackoff {
+		backoff = maxSelfHealBackoff
+	}
+	state.nextAttempt = state.lastAttempt.Add(backoff)
+	attempt := state.attempts
+	sh.mu.Unlock()
+
+	sh.logger.Warning("Self-heal: %s is down (%s), redeploying (attempt %d, next attempt not before %v)", key, reason, attempt, backoff)
+	trigger := models.DeployTrigger{Source: "self_heal", Actor: "uruflow"}
+	if err := sh.deploymentService.DeployDirectWithOptions(context.Background(), repo, branch, DeployOptions{Trigger: trigger}); err != nil && !errors.Is(err, ErrDeploySkipped) {
+		sh.logger.Error("Self-heal redeploy of %s failed: %v", key, err)
+		return
+	}
+	sh.logger.Success("Self-heal redeployed %s", key)
+}
+
+// isProjectDown reports whether a branch has no running containers or has failed containers on any of its daemons
+func (sh *SelfHealService) isProjectDown(repo models.Repository, branch string) (bool, string, error) {
+	for _, daemon := range sh.dockerService.Daemons(repo) {
+		onHost := ""
+		if daemon.Host != "" {
+			onHost = " on " + daemon.Host
+		}
+		running, err := daemon.Docker.GetRunningProjectContainers(repo, branch)
+		if err != nil {
+			return false, "", err
+		}
+		if len(running) == 0 {
+			return true, "no running containers" + onHost, nil
+		}
+
+		failed, err := daemon.Docker.GetFailedProjectContainers(repo, branch)
+		if err != nil {
+			return false, "", err
+		}
+		if len(failed) > 0 {
+			return true, fmt.Sprintf("failed containers%s: %v", onHost, failed), nil
+		}
+	}
+	return false, "", nil
+}
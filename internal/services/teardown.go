@@ -0,0 +1,229 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"uruflow.com/internal/models"
+	"uruflow.com/internal/utils"
+)
+
+// TeardownService tears down the environment of deleted branches, optionally after a grace period
+type TeardownService struct {
+	config            *models.Config
+	configMu          sync.RWMutex
+	repositoryService *RepositoryService
+	deploymentService *DeploymentService
+	gitService        *GitService
+	dockerService     *DockerService
+	logger            *utils.Logger
+	stateFile         string
+	pending           map[string]time.Time
+	timers            map[string]*time.Timer
+	mu                sync.Mutex
+}
+
+// NewTeardownService creates a new teardown service persisting pending teardowns in the work directory
+func NewTeardownService(
+	config *models.Config,
+	repositoryService *RepositoryService,
+	deploymentService *DeploymentService,
+	gitService *GitService,
+	dockerService *DockerService,
+	logger *utils.Logger,
+) *TeardownService {
+	return &TeardownService{
+		config:            config,
+		repositoryService: repositoryService,
+		deploymentService: deploymentService,
+		gitService:        gitService,
+		dockerService:     dockerService,
+		logger:            logger,
+		stateFile:         filepath.Join(config.Settings.WorkDir, ".uruflow-teardowns.json"),
+		pending:           make(map[string]time.Time),
+		timers:            make(map[string]*time.Timer),
+	}
+}
+
+// UpdateConfig updates the configuration reference; the state file stays in the work directory of startup
+func (ts *TeardownService) UpdateConfig(config *models.Config) {
+	ts.configMu.Lock()
+	defer ts.configMu.Unlock()
+	ts.config = config
+}
+
+// currentConfig returns the configuration in use; a reload swaps the pointer, the returned config is never modified
+func (ts *TeardownService) currentConfig() *models.Config {
+	ts.configMu.RLock()
+	defer ts.configMu.RUnlock()
+	return ts.config
+}
+
+// Schedule tears down a repository branch after its teardown_delay, replacing an already pending teardown
+func (ts *TeardownService) Schedule(repo models.Repository, branch string) time.Time {
+	key := fmt.Sprintf("%s:%s", repo.Name, branch)
+	due := time.Now().Add(time.Duration(repo.TeardownDelay) * time.Second)
+
+	ts.mu.Lock()
+	ts.pending[key] = due
+	ts.startTimerLocked(key, due)
+	ts.saveLocked()
+	ts.mu.Unlock()
+
+	if repo.TeardownDelay > 0 {
+		ts.logger.Deploy("Teardown of %s scheduled for %s", key, due.Format("2006-01-02 15:04:05"))
+	}
+	return due
+}
+
+// Cancel drops the pending teardown of a repository branch, reporting whether one was pending
+func (ts *TeardownService) Cancel(repoName, branch string) bool {
+	key := fmt.Sprintf("%s:%s", repoName, branch)
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if _, exists := ts.pending[key]; !exists {
+		return false
+	}
+	if timer := ts.timers[key]; timer != nil {
+		timer.Stop()
+	}
+	delete(ts.timers, key)
+	delete(ts.pending, key)
+	ts.saveLocked()
+
+	ts.logger.Deploy("Pending teardown of %s cancelled, branch was pushed again", key)
+	return true
+}
+
+// Restore reschedules the teardowns persisted before a restart, running overdue ones right away
+func (ts *TeardownService) Restore() error {
+	data, err := os.ReadFile(ts.stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read pending teardowns: %v", err)
+	}
+
+	var pending map[string]time.Time
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return fmt.Errorf("failed to parse pending teardowns %s: %v", ts.stateFile, err)
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for key, due := range pending {
+		ts.pending[key] = due
+		ts.startTimerLocked(key, due)
+	}
+	if len(pending) > 0 {
+		ts.logger.Info("Restored %d pending teardowns", len(pending))
+	}
+	return nil
+}
+
+// startTimerLocked arms the timer that runs a teardown when it is due; ts.mu must be held
+func (ts *TeardownService) startTimerLocked(key string, due time.Time) {
+	if timer := ts.timers[key]; timer != nil {
+		timer.Stop()
+	}
+	ts.timers[key] = time.AfterFunc(time.Until(due), func() {
+		ts.run(key, due)
+	})
+}
+
+// run tears down a branch unless its teardown was cancelled or rescheduled in the meantime
+func (ts *TeardownService) run(key string, due time.Time) {
+	ts.mu.Lock()
+	if current, exists := ts.pending[key]; !exists || !current.Equal(due) {
+		ts.mu.Unlock()
+		return
+	}
+	delete(ts.pending, key)
+	delete(ts.timers, key)
+	ts.saveLocked()
+	ts.mu.Unlock()
+
+	repoName, branch, _ := strings.Cut(key, ":")
+	if err := ts.teardown(repoName, branch); err != nil {
+		ts.logger.Error("Teardown of %s failed: %v", key, err)
+	}
+}
+
+// teardown stops the compose projects of a branch and removes its checkout
+func (ts *TeardownService) teardown(repoName, branch string) error {
+	repo := ts.repositoryService.GetRepository(repoName)
+	if repo == nil {
+		return fmt.Errorf("repository %s is no longer configured", repoName)
+	}
+
+	ts.logger.Deploy("Tearing down %s:%s (branch deleted)", repoName, branch)
+	if err := ts.deploymentService.CancelDeployment(repoName, branch); err != nil && !errors.Is(err, ErrNoActiveDeployment) {
+		ts.logger.Warning("Failed to cancel deployment of %s:%s: %v", repoName, branch, err)
+	}
+
+	repoPath, err := RepositoryPath(ts.currentConfig().Settings.WorkDir, repoName, branch)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(repoPath); err == nil {
+		for _, daemon := range ts.dockerService.Daemons(*repo) {
+			if err := daemon.Docker.Stop(*repo, branch, repoPath); err != nil {
+				if daemon.Host != "" {
+					return fmt.Errorf("failed to stop services on %s: %v", daemon.Host, err)
+				}
+				return fmt.Errorf("failed to stop services: %v", err)
+			}
+		}
+	}
+	if err := ts.gitService.CleanupRepository(repoPath); err != nil {
+		return err
+	}
+
+	ts.logger.Success("Torn down %s:%s", repoName, branch)
+	return nil
+}
+
+// saveLocked persists the pending teardowns; ts.mu must be held
+func (ts *TeardownService) saveLocked() {
+	data, err := json.MarshalIndent(ts.pending, "", "  ")
+	if err != nil {
+		ts.logger.Warning("Failed to encode pending teardowns: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(ts.stateFile), 0755); err != nil {
+		ts.logger.Warning("Failed to persist pending teardowns: %v", err)
+		return
+	}
+	if err := os.WriteFile(ts.stateFile, data, 0644); err != nil {
+		ts.logger.Warning("Failed to persist pending teardowns: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDeployTimeout is the cancellation cause of a deployment that ran out of its deploy_timeout
+var ErrDeployTimeout = errors.New("deployment exceeded deploy_timeout")
+
+// ErrBuildTimeout is the cancellation cause of an image build that ran longer than build_timeout
+var ErrBuildTimeout = errors.New("image build exceeded build_timeout")
+
+// deployBudget tracks the deploy_timeout of a deployment across its phases; the build phase runs
+// outside of it so a long build does not eat the time left for starting and verifying services
+type deployBudget struct {
+	limit     time.Duration
+	remaining time.Duration
+}
+
+// newDeployBudget creates a budget of the given number of seconds, 0 disables the limit
+func newDeployBudget(seconds int) *deployBudget {
+	limit := time.Duration(seconds) * time.Second
+	return &deployBudget{limit: limit, remaining: limit}
+}
+
+// phase derives the context of a deployment phase limited to the time left in the budget; the returned
+// function ends the phase, charges the time it took and must always be called, later calls do nothing
+func (b *deployBudget) phase(ctx context.Context) (context.Context, func()) {
+	if b.limit <= 0 {
+		return context.WithCancel(ctx)
+	}
+	started := time.Now()
+	phaseCtx, cancel := context.WithTimeoutCause(ctx, b.remaining, ErrDeployTimeout)
+	ended := false
+	return phaseCtx, func() {
+		cancel()
+		if !ended {
+			ended = true
+			b.remaining -= time.Since(started)
+		}
+	}
+}
+
+// buildContext derives the context of the build phase limited to build_timeout seconds, 0 disables the limit
+func buildContext(ctx context.Context, seconds int) (context.Context, context.CancelFunc) {
+	if seconds <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, time.Duration(seconds)*time.Second, ErrBuildTimeout)
+}
+
+// timeoutError reports which limit stopped a phase, err is returned unchanged when no limit was hit
+func timeoutError(phaseCtx context.Context, limit time.Duration, err error) error {
+	cause := context.Cause(phaseCtx)
+	if errors.Is(cause, ErrDeployTimeout) || errors.Is(cause, ErrBuildTimeout) {
+		return fmt.Errorf("%w (%v): %v", cause, limit, err)
+	}
+	return err
+}
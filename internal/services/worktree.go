@@ -0,0 +1,130 @@
+/*
+ * Copyright (C) 2025 Mustafa Naseer (Mustafa Gaeed)
+ *
+ * This file is part of Uruflow, an open-source automation tool.
+ *
+ * Uruflow is a tool designed to streamline and automate Docker-based deployments.
+ *
+ * Uruflow is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, version 3 of the License.
+ *
+ * Uruflow is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with Uruflow. If not, see <https://www.gnu.org/licenses/>.
+ */
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"uruflow.com/internal/models"
+)
+
+// sharedCloneName is the directory of the shared clone next to the branch checkouts of a repository;
+// git refuses branch name components starting with a dot, so it cannot collide with a checkout
+const sharedCloneName = ".shared.git"
+
+var sharedCloneLocks = make(map[string]*sync.Mutex)
+var sharedCloneLocksMu sync.Mutex
+
+// SharedClonePath returns the shared clone of the repository whose branch is checked out at repoPath
+func SharedClonePath(repoPath, branch string) string {
+	repoDir := strings.TrimSuffix(repoPath, string(filepath.Separator)+filepath.FromSlash(branch))
+	return filepath.Join(repoDir, sharedCloneName)
+}
+
+// sharedCloneLock returns the lock serializing git operations on the object store of a shared clone
+func sharedCloneLock(path string) *sync.Mutex {
+	// worktrees record absolute paths, the lock of a relative work_dir must be the same
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	sharedCloneLocksMu.Lock()
+	defer sharedCloneLocksMu.Unlock()
+	lock, exists := sharedCloneLocks[path]
+	if !exists {
+		lock = &sync.Mutex{}
+		sharedCloneLocks[path] = lock
+	}
+	return lock
+}
+
+// worktreeCommonDir returns the git directory of the shared clone when repoPath is a worktree, empty otherwise
+func worktreeCommonDir(repoPath string) string {
+	data, err := os.ReadFile(filepath.Join(repoPath, ".git"))
+	if err != nil {
+		// a regular checkout has a .git directory, which cannot be read as a file
+		return ""
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(repoPath, gitDir)
+	}
+	// worktree git directories live in <common dir>/worktrees/<name>
+	return filepath.Dir(filepath.Dir(gitDir))
+}
+
+// addWorktree checks a branch out at repoPath as a worktree of the shared clone of the repository,
+// creating the shared clone on first use
+func (gs *GitService) addWorktree(ctx context.Context, repo models.Repository, branch, repoPath string) error {
+	logger := loggerFrom(ctx, gs.logger)
+	sharedPath := SharedClonePath(repoPath, branch)
+	lock := sharedCloneLock(sharedPath)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(repoPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+	gs.ensureRepositorySafety(filepath.Dir(sharedPath))
+
+	if _, err := os.Stat(sharedPath); os.IsNotExist(err) {
+		logger.Git("Creating shared clone of %s at %s", repo.Name, sharedPath)
+		if err := gs.executeGitCommand(ctx, []string{"init", "--bare", sharedPath}, filepath.Dir(sharedPath), nil); err != nil {
+			return fmt.Errorf("failed to create shared clone: %v", err)
+		}
+		if err := gs.executeGitCommand(ctx, []string{"remote", "add", "origin", repo.GitURL}, sharedPath, nil); err != nil {
+			os.RemoveAll(sharedPath)
+			return fmt.Errorf("failed to create shared clone: %v", err)
+		}
+	} else if err := gs.executeGitCommand(ctx, []string{"remote", "set-url", "origin", repo.GitURL}, sharedPath, nil); err != nil {
+		// keeps the shared clone in line with a changed git_url
+		return fmt.Errorf("failed to update shared clone remote: %v", err)
+	}
+
+	if err := gs.fetchBranch(ctx, repo, branch, sharedPath, sharedPath); err != nil {
+		return err
+	}
+
+	// drops the registrations of worktrees whose directories were removed, which would block re-adding their branch
+	gs.pruneWorktrees(ctx, sharedPath)
+	args := []string{"worktree", "add", "--force", "-B", branch, repoPath, "origin/" + branch}
+	if err := gs.executeGitCommand(ctx, args, sharedPath, nil); err != nil {
+		if ctx.Err() != nil {
+			os.RemoveAll(repoPath)
+			gs.pruneWorktrees(context.Background(), sharedPath)
+		}
+		return fmt.Errorf("git worktree add failed: %v", err)
+	}
+
+	gs.ensureRepositorySafety(repoPath)
+	logger.Success("Checked out %s:%s as a worktree of %s", repo.Name, branch, sharedPath)
+	return nil
+}
+
+// pruneWorktrees removes the registrations of deleted worktrees from a shared clone, best effort
+func (gs *GitService) pruneWorktrees(ctx context.Context, sharedPath string) {
+	if err := gs.executeGitCommand(ctx, []string{"worktree", "prune"}, sharedPath, nil); err != nil {
+		gs.logger.Warning("Failed to prune worktrees of %s: %v", sharedPath, err)
+	}
+}
@@ -26,6 +26,9 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -33,6 +36,7 @@ import (
 type Logger struct {
 	*log.Logger
 	logFile *os.File
+	scope   string
 }
 
 // NewLogger creates a new logger that writes to both console and file
@@ -60,6 +64,10 @@ func NewLogger(prefix string) *Logger {
 		}
 	}
 
+	if maxFiles, err := strconv.Atoi(os.Getenv("URUFLOW_LOG_MAX_FILES")); err == nil && maxFiles > 0 {
+		pruneLogFiles(logDir, logPath, maxFiles)
+	}
+
 	multiWriter := io.MultiWriter(os.Stdout, logFile)
 
 	return &Logger{
@@ -68,6 +76,63 @@ func NewLogger(prefix string) *Logger {
 	}
 }
 
+// pruneLogFiles keeps the maxFiles most recent uruflow-*.log files in logDir, the active file is never removed
+func pruneLogFiles(logDir, activePath string, maxFiles int) {
+	files, err := filepath.Glob(filepath.Join(logDir, "uruflow-*.log"))
+	if err != nil {
+		return
+	}
+
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	var candidates []logFile
+	for _, path := range files {
+		if path == activePath {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		candidates = append(candidates, logFile{path: path, modTime: info.ModTime()})
+	}
+
+	// the active file counts toward the limit
+	keep := maxFiles - 1
+	if len(candidates) <= keep {
+		return
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].modTime.After(candidates[j].modTime)
+	})
+	for _, candidate := range candidates[keep:] {
+		if err := os.Remove(candidate.path); err != nil {
+			log.Printf("WARNING: Failed to remove old log file %s: %v", candidate.path, err)
+		}
+	}
+}
+
+// With returns a logger that prefixes every message with [id], sharing the output of l.
+// Only the root logger should be closed.
+func (l *Logger) With(id string) *Logger {
+	return &Logger{
+		Logger:  l.Logger,
+		logFile: l.logFile,
+		scope:   l.scope + "[" + strings.ReplaceAll(id, "%", "%%") + "] ",
+	}
+}
+
+// ConsoleToStderr moves console output to stderr so stdout carries only command output
+func (l *Logger) ConsoleToStderr() {
+	if l.logFile != nil {
+		l.SetOutput(io.MultiWriter(os.Stderr, l.logFile))
+		return
+	}
+	l.SetOutput(os.Stderr)
+}
+
 func (l *Logger) Close() error {
 	if l.logFile != nil {
 		return l.logFile.Close()
@@ -77,80 +142,80 @@ func (l *Logger) Close() error {
 
 // Info logs an info message
 func (l *Logger) Info(format string, v ...interface{}) {
-	l.Printf("INFO: "+format, v...)
+	l.Printf("INFO: "+l.scope+format, v...)
 }
 
 // Success logs a success message
 func (l *Logger) Success(format string, v ...interface{}) {
-	l.Printf("SUCCESS: "+format, v...)
+	l.Printf("SUCCESS: "+l.scope+format, v...)
 }
 
 // Warning logs a warning message
 func (l *Logger) Warning(format string, v ...interface{}) {
-	l.Printf("WARNING: "+format, v...)
+	l.Printf("WARNING: "+l.scope+format, v...)
 }
 
 // Error logs an error message
 func (l *Logger) Error(format string, v ...interface{}) {
-	l.Printf("ERROR: "+format, v...)
+	l.Printf("ERROR: "+l.scope+format, v...)
 }
 
 // Deploy logs a deployment message
 func (l *Logger) Deploy(format string, v ...interface{}) {
-	l.Printf("DEPLOYMENT: "+format, v...)
+	l.Printf("DEPLOYMENT: "+l.scope+format, v...)
 }
 
 // Docker logs a docker-related message
 func (l *Logger) Docker(format string, v ...interface{}) {
-	l.Printf("DOCKER: "+format, v...)
+	l.Printf("DOCKER: "+l.scope+format, v...)
 }
 
 // Git logs a git-related message
 func (l *Logger) Git(format string, v ...interface{}) {
-	l.Printf("GIT: "+format, v...)
+	l.Printf("GIT: "+l.scope+format, v...)
 }
 
 // Webhook logs a webhook message
 func (l *Logger) Webhook(format string, v ...interface{}) {
-	l.Printf("WEBHOOK: "+format, v...)
+	l.Printf("WEBHOOK: "+l.scope+format, v...)
 }
 
 // Worker logs a worker message
 func (l *Logger) Worker(format string, v ...interface{}) {
-	l.Printf("WORKER: "+format, v...)
+	l.Printf("WORKER: "+l.scope+format, v...)
 }
 
 // Repository logs a repository message
 func (l *Logger) Repository(format string, v ...interface{}) {
-	l.Printf("REPOSITORY: "+format, v...)
+	l.Printf("REPOSITORY: "+l.scope+format, v...)
 }
 
 // Config logs a configuration message
 func (l *Logger) Config(format string, v ...interface{}) {
-	l.Printf("CONFIG: "+format, v...)
+	l.Printf("CONFIG: "+l.scope+format, v...)
 }
 
 // Network logs a network-related message
 func (l *Logger) Network(format string, v ...interface{}) {
-	l.Printf("NETWORK: "+format, v...)
+	l.Printf("NETWORK: "+l.scope+format, v...)
 }
 
 // Security logs a security-related message
 func (l *Logger) Security(format string, v ...interface{}) {
-	l.Printf("SECURITY: "+format, v...)
+	l.Printf("SECURITY: "+l.scope+format, v...)
 }
 
 // Debug logs a debug message (only in debug mode)
 func (l *Logger) Debug(format string, v ...interface{}) {
 	// Only log debug messages if debug mode is enabled
 	if os.Getenv("DEBUG") == "true" {
-		l.Printf("DEBUG: "+format, v...)
+		l.Printf("DEBUG: "+l.scope+format, v...)
 	}
 }
 
 // Fatal logs a fatal error and exits
 func (l *Logger) Fatal(format string, v ...interface{}) {
-	l.Printf("FATAL: "+format, v...)
+	l.Printf("FATAL: "+l.scope+format, v...)
 	if l.logFile != nil {
 		l.logFile.Close()
 	}
@@ -159,20 +224,20 @@ func (l *Logger) Fatal(format string, v ...interface{}) {
 
 // Startup logs a startup message
 func (l *Logger) Startup(format string, v ...interface{}) {
-	l.Printf("STARTUP: "+format, v...)
+	l.Printf("STARTUP: "+l.scope+format, v...)
 }
 
 // Cleanup logs a cleanup message
 func (l *Logger) Cleanup(format string, v ...interface{}) {
-	l.Printf("CLEANUP: "+format, v...)
+	l.Printf("CLEANUP: "+l.scope+format, v...)
 }
 
 // Performance logs a performance-related message
 func (l *Logger) Performance(format string, v ...interface{}) {
-	l.Printf("PERFORMANCE: "+format, v...)
+	l.Printf("PERFORMANCE: "+l.scope+format, v...)
 }
 
 // Queue logs a queue-related message
 func (l *Logger) Queue(format string, v ...interface{}) {
-	l.Printf("QUEUE: "+format, v...)
+	l.Printf("QUEUE: "+l.scope+format, v...)
 }